@@ -42,6 +42,8 @@ func dispatcher(ws *webSocketConnection, req *webSocketMessage) (*webSocketMessa
 		return onExportTable(ws, req)
 	case "findSheet": //查找表格文件
 		return onFindSheet(ws, req)
+	case "drainGate": //gate排空
+		return onDrainGate(req)
 	}
 	return nil, errors.New("unknown message type")
 }
@@ -121,6 +123,30 @@ func onQueryServerList(req *webSocketMessage) (*webSocketMessage, error) {
 	return data, nil
 }
 
+// onDrainGate 通知target指定的gate排空, gate从etcd注销并通知客户端连接其他gate, 客户端全部断开或超时后退出
+func onDrainGate(req *webSocketMessage) (*webSocketMessage, error) {
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+
+	response := "ok"
+	if !strings.HasPrefix(req.Target, engine.ServiceGatePrefix) || len(engine.GetEtcd().Get(ctx, req.Target)) == 0 {
+		response = fmt.Sprintf("gate not found: %s", req.Target)
+	} else {
+		kv := engine.NewEtcdKV(engine.GetEtcdGateDrainKey(req.Target), engine.EtcdValue{engine.EtcdValueServer: engine.ServiceName()})
+		if err := engine.GetEtcd().Put(ctx, kv); err != nil {
+			response = err.Error()
+		} else {
+			log.Infof("[GM]drain gate: %s", req.Target)
+		}
+	}
+
+	return &webSocketMessage{
+		Type:   req.Type,
+		Target: req.Target,
+		Data:   response,
+	}, nil
+}
+
 func onConsoleMessage(ws *webSocketConnection, req *webSocketMessage) (*webSocketMessage, error) {
 	response := sendCommandToTarget(ws, engine.TelnetMessageTypeWebCmd, req.Target, req.Data.(string))
 
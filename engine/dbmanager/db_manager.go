@@ -56,6 +56,7 @@ func (m *eventLoop) OnClosed(c gnet.Conn, err error) (action gnet.Action) {
 
 func (m *eventLoop) React(frame []byte, c gnet.Conn) (out []byte, action gnet.Action) {
 	var err error
+	traceId := ""
 	ty := frame[0]
 	switch ty {
 	case engine.ServerMessageTypeDBCommand:
@@ -64,12 +65,13 @@ func (m *eventLoop) React(frame []byte, c gnet.Conn) (out []byte, action gnet.Ac
 			log.Error("can not UnMarshal message, error: ", err.Error())
 			return nil, gnet.Close
 		}
+		traceId = msg.TraceId
 		err = doDBCommand(c, &msg)
 	default:
 		err = errors.New("unknown message type")
 	}
 	if err != nil {
-		log.Warnf("message type: %d, error: %s", ty, err.Error())
+		engine.TraceLogger(traceId).Warnf("message type: %d, error: %s", ty, err.Error())
 	}
 	return nil, gnet.None
 }
@@ -81,9 +83,10 @@ func (m *eventLoop) Tick() (delay time.Duration, action gnet.Action) {
 
 func doDBCommand(c gnet.Conn, in *message.DBCommandRequest) error {
 	request := commandTaskRequester{
-		id:    engine.EntityIdType(in.EntityId),
-		conn:  c,
-		extra: in.GetEx(),
+		id:      engine.EntityIdType(in.EntityId),
+		conn:    c,
+		extra:   in.GetEx(),
+		traceId: in.TraceId,
 	}
 	filter := bson.D{}
 	if err := bson.Unmarshal(in.Filter, &filter); err != nil {
@@ -112,7 +115,7 @@ func responseCommandTask(requester *commandTaskRequester, taskType engine.DBTask
 		return
 	}
 	if requester.conn == nil {
-		log.Tracef("response db task type: %d, entityId: %d but conn is nil", taskType, requester.id)
+		requester.logger().Tracef("response db task type: %d, entityId: %d but conn is nil", taskType, requester.id)
 		return
 	}
 	entityId := requester.id
@@ -132,7 +135,7 @@ func responseCommandTask(requester *commandTaskRequester, taskType engine.DBTask
 			err = fmt.Errorf("unsupport data type %s", reflect.TypeOf(data).String())
 		}
 		if err != nil {
-			log.Errorf("response db task type: %d, entityId: %d error: %s", taskType, entityId, err.Error())
+			requester.logger().Errorf("response db task type: %d, entityId: %d error: %s", taskType, entityId, err.Error())
 			return
 		}
 	}
@@ -149,7 +152,7 @@ func responseCommandTask(requester *commandTaskRequester, taskType engine.DBTask
 		msg.ErrMsg = []byte(err.Error())
 	}
 	if buf, err := engine.GetProtocol().MessageWithHead([]byte{engine.ServerMessageTypeDBCommand}, msg); err != nil {
-		log.Warnf("entityId[%d] taskType[%d] taskInfo Pack error: %s", entityId, taskType, err.Error())
+		requester.logger().Warnf("entityId[%d] taskType[%d] taskInfo Pack error: %s", entityId, taskType, err.Error())
 		return
 	} else {
 		_ = requester.conn.AsyncWrite(buf)
@@ -97,6 +97,15 @@ func (m *mongoClient) UpdateOne(database, collection string, filter interface{},
 	return err
 }
 
+// UpdateFields update为包含$set/$unset等操作符的更新文档
+func (m *mongoClient) UpdateFields(database, collection string, filter interface{}, update interface{}, opts ...*options.UpdateOptions) error {
+	ctx, cancel := context.WithTimeout(context.TODO(), mongoOperationTimeout)
+	defer cancel()
+
+	_, err := m.GetCollection(database, collection).UpdateOne(ctx, filter, update, opts...)
+	return err
+}
+
 func (m *mongoClient) UpdateMany(database, collection string, filter interface{}, data interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
 	ctx, cancel := context.WithTimeout(context.TODO(), mongoOperationTimeout)
 	defer cancel()
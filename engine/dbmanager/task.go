@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/panjf2000/gnet"
+	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"rpg/engine/engine"
 	"rpg/engine/message"
@@ -22,9 +23,15 @@ type commandTaskInfo struct {
 }
 
 type commandTaskRequester struct {
-	id    engine.EntityIdType
-	conn  gnet.Conn
-	extra *message.ExtraInfo
+	id      engine.EntityIdType
+	conn    gnet.Conn
+	extra   *message.ExtraInfo
+	traceId string //发起请求的消息的trace id
+}
+
+// logger 带有请求trace id的日志
+func (m *commandTaskRequester) logger() *logrus.Entry {
+	return engine.TraceLogger(m.traceId)
 }
 
 func newTask(ty engine.DBTaskType, requester *commandTaskRequester, taskInfo *commandTaskInfo) Task {
@@ -41,6 +48,8 @@ func newTask(ty engine.DBTaskType, requester *commandTaskRequester, taskInfo *co
 		return &commandDeleteOneTask{requester: requester, taskInfo: taskInfo}
 	case engine.DBTaskTypeDeleteMany:
 		return &commandDeleteManyTask{requester: requester, taskInfo: taskInfo}
+	case engine.DBTaskTypeUpdateFields:
+		return &commandUpdateFieldsTask{requester: requester, taskInfo: taskInfo}
 	}
 
 	return nil
@@ -65,7 +74,7 @@ func (m *commandQueryOneTask) Process() {
 func (m *commandQueryOneTask) OnTaskFinished(data interface{}, err error) {
 	dbMgr.TaskMgr.FinishProcessTask(m.requester.id)
 	if err != nil {
-		log.Warnf("Process task[%s] error: %s, key: %v", m.Name(), err.Error(), m.taskInfo.filter)
+		m.requester.logger().Warnf("Process task[%s] error: %s, key: %v", m.Name(), err.Error(), m.taskInfo.filter)
 	}
 	responseCommandTask(m.requester, engine.DBTaskTypeQueryOne, err, data)
 }
@@ -89,7 +98,7 @@ func (m *commandQueryManyTask) Process() {
 func (m *commandQueryManyTask) OnTaskFinished(data interface{}, err error) {
 	dbMgr.TaskMgr.FinishProcessTask(m.requester.id)
 	if err != nil {
-		log.Warnf("Process task[%s] error: %s, key: %v", m.Name(), err.Error(), m.taskInfo.filter)
+		m.requester.logger().Warnf("Process task[%s] error: %s, key: %v", m.Name(), err.Error(), m.taskInfo.filter)
 	}
 	responseCommandTask(m.requester, engine.DBTaskTypeQueryMany, err, data)
 }
@@ -113,7 +122,7 @@ func (m *commandUpdateOneTask) Process() {
 func (m *commandUpdateOneTask) OnTaskFinished(data interface{}, err error) {
 	dbMgr.TaskMgr.FinishProcessTask(m.requester.id)
 	if err != nil {
-		log.Warnf("Process task[%s] error: %s, key: %v", m.Name(), err.Error(), m.taskInfo.filter)
+		m.requester.logger().Warnf("Process task[%s] error: %s, key: %v", m.Name(), err.Error(), m.taskInfo.filter)
 	}
 	responseCommandTask(m.requester, engine.DBTaskTypeUpdateOne, err, data)
 }
@@ -137,7 +146,7 @@ func (m *commandReplaceOneTask) Process() {
 func (m *commandReplaceOneTask) OnTaskFinished(data interface{}, err error) {
 	dbMgr.TaskMgr.FinishProcessTask(m.requester.id)
 	if err != nil {
-		log.Warnf("Process task[%s] error: %s, key: %v", m.Name(), err.Error(), m.taskInfo.filter)
+		m.requester.logger().Warnf("Process task[%s] error: %s, key: %v", m.Name(), err.Error(), m.taskInfo.filter)
 	}
 	responseCommandTask(m.requester, engine.DBTaskTypeReplaceOne, err, data)
 }
@@ -161,7 +170,7 @@ func (m *commandDeleteOneTask) Process() {
 func (m *commandDeleteOneTask) OnTaskFinished(data interface{}, err error) {
 	dbMgr.TaskMgr.FinishProcessTask(m.requester.id)
 	if err != nil {
-		log.Warnf("Process task[%s] error: %s, key: %v", m.Name(), err.Error(), m.taskInfo.filter)
+		m.requester.logger().Warnf("Process task[%s] error: %s, key: %v", m.Name(), err.Error(), m.taskInfo.filter)
 	}
 	responseCommandTask(m.requester, engine.DBTaskTypeDeleteOne, err, data)
 }
@@ -185,7 +194,31 @@ func (m *commandDeleteManyTask) Process() {
 func (m *commandDeleteManyTask) OnTaskFinished(data interface{}, err error) {
 	dbMgr.TaskMgr.FinishProcessTask(m.requester.id)
 	if err != nil {
-		log.Warnf("Process task[%s] error: %s, key: %v", m.Name(), err.Error(), m.taskInfo.filter)
+		m.requester.logger().Warnf("Process task[%s] error: %s, key: %v", m.Name(), err.Error(), m.taskInfo.filter)
 	}
 	responseCommandTask(m.requester, engine.DBTaskTypeDeleteMany, err, data)
 }
+
+// ================================按$set/$unset更新单条数据============================================
+
+type commandUpdateFieldsTask struct {
+	requester *commandTaskRequester
+	taskInfo  *commandTaskInfo
+}
+
+func (m *commandUpdateFieldsTask) Name() string {
+	return "commandUpdateFieldsTask"
+}
+
+func (m *commandUpdateFieldsTask) Process() {
+	err := dbMgr.GetDB(m.taskInfo.dbType).UpdateFields(m.taskInfo.database, m.taskInfo.collection, m.taskInfo.filter, m.taskInfo.data, options.Update().SetUpsert(true))
+	m.OnTaskFinished(nil, err)
+}
+
+func (m *commandUpdateFieldsTask) OnTaskFinished(data interface{}, err error) {
+	dbMgr.TaskMgr.FinishProcessTask(m.requester.id)
+	if err != nil {
+		m.requester.logger().Warnf("Process task[%s] error: %s, key: %v", m.Name(), err.Error(), m.taskInfo.filter)
+	}
+	responseCommandTask(m.requester, engine.DBTaskTypeUpdateFields, err, data)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"rpg/engine/engine"
+)
+
+// defcheck 检查def文件配置, 一次输出全部错误
+func main() {
+	path := ""
+	flag.StringVar(&path, "path", "", "工作路径, def文件位于该路径下的defs目录")
+	flag.Parse()
+	if path == "" {
+		fmt.Print("Usage: \n--path=/path/to/scripts\n")
+		os.Exit(2)
+	}
+
+	errCount, warnCount := 0, 0
+	for _, err := range engine.CheckDefs(path) {
+		fmt.Println(err.Error())
+		if err.Warn {
+			warnCount++
+		} else {
+			errCount++
+		}
+	}
+	//警告不影响退出码
+	if errCount > 0 {
+		fmt.Printf("%d error(s), %d warning(s) found\n", errCount, warnCount)
+		os.Exit(1)
+	}
+	if warnCount > 0 {
+		fmt.Printf("all def files ok, %d warning(s) found\n", warnCount)
+		return
+	}
+	fmt.Println("all def files ok")
+}
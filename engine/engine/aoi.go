@@ -0,0 +1,227 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+)
+
+type aoiCellKey struct {
+	x int64
+	y int64
+}
+
+type aoiNode struct {
+	entityId EntityIdType //entity id
+	x        float64      //x坐标
+	y        float64      //y坐标
+	cell     aoiCellKey   //所在格子
+	views    entityIdMap  //视野内的entity, 视野半径相同, 互相可见
+}
+
+func GetAoiManager() *aoiManager {
+	return aoiMgr
+}
+
+// aoiManager 九宫格aoi, 格子边长等于视野半径
+type aoiManager struct {
+	radius float64                                  //视野半径
+	nodes  map[EntityIdType]*aoiNode                //entityId->aoi节点
+	cells  map[aoiCellKey]map[EntityIdType]*aoiNode //格子->格子内的aoi节点
+}
+
+func initAoiManager() error {
+	aoiMgr = new(aoiManager)
+	aoiMgr.init(cfg.Aoi.Radius)
+
+	log.Infof("aoi manager inited, radius: %v", aoiMgr.radius)
+	return nil
+}
+
+func (m *aoiManager) init(radius float64) {
+	m.radius = radius
+	m.nodes = make(map[EntityIdType]*aoiNode)
+	m.cells = make(map[aoiCellKey]map[EntityIdType]*aoiNode)
+}
+
+func (m *aoiManager) cellKey(x, y float64) aoiCellKey {
+	return aoiCellKey{x: int64(math.Floor(x / m.radius)), y: int64(math.Floor(y / m.radius))}
+}
+
+func (m *aoiManager) addToCell(node *aoiNode) {
+	node.cell = m.cellKey(node.x, node.y)
+	if _, ok := m.cells[node.cell]; !ok {
+		m.cells[node.cell] = make(map[EntityIdType]*aoiNode)
+	}
+	m.cells[node.cell][node.entityId] = node
+}
+
+func (m *aoiManager) removeFromCell(node *aoiNode) {
+	if cell, ok := m.cells[node.cell]; ok {
+		delete(cell, node.entityId)
+		if len(cell) == 0 {
+			delete(m.cells, node.cell)
+		}
+	}
+}
+
+// inRange 查找周围九宫格内处于视野范围的节点
+func (m *aoiManager) inRange(node *aoiNode) entityIdMap {
+	r := make(entityIdMap)
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			cell, ok := m.cells[aoiCellKey{x: node.cell.x + dx, y: node.cell.y + dy}]
+			if !ok {
+				continue
+			}
+			for id, other := range cell {
+				if id == node.entityId {
+					continue
+				}
+				if math.Hypot(other.x-node.x, other.y-node.y) <= m.radius {
+					r[id] = true
+				}
+			}
+		}
+	}
+	return r
+}
+
+func (m *aoiManager) IsInAoi(entityId EntityIdType) bool {
+	_, ok := m.nodes[entityId]
+	return ok
+}
+
+// Views 视野内的entity
+func (m *aoiManager) Views(entityId EntityIdType) []EntityIdType {
+	r := make([]EntityIdType, 0)
+	if node, ok := m.nodes[entityId]; ok {
+		for id := range node.views {
+			r = append(r, id)
+		}
+	}
+	return r
+}
+
+func (m *aoiManager) Enter(e *entity, x, y float64) error {
+	if _, ok := m.nodes[e.entityId]; ok {
+		return fmt.Errorf("%s already in aoi", e.String())
+	}
+	m.dispatch(m.enter(e.entityId, x, y))
+	return nil
+}
+
+func (m *aoiManager) Leave(e *entity) {
+	if node, ok := m.nodes[e.entityId]; ok {
+		m.dispatch(m.leave(node))
+	}
+}
+
+func (m *aoiManager) Move(e *entity, x, y float64) error {
+	node, ok := m.nodes[e.entityId]
+	if !ok {
+		return fmt.Errorf("%s not in aoi", e.String())
+	}
+	m.dispatch(m.move(node, x, y))
+	return nil
+}
+
+// enter 加入节点, 返回待通知的视野变化事件
+func (m *aoiManager) enter(entityId EntityIdType, x, y float64) []aoiEvent {
+	node := &aoiNode{entityId: entityId, x: x, y: y, views: make(entityIdMap)}
+	m.nodes[entityId] = node
+	m.addToCell(node)
+	events := make([]aoiEvent, 0)
+	for id := range m.inRange(node) {
+		if other, ok := m.nodes[id]; ok {
+			events = append(events, m.link(node, other))
+		}
+	}
+	return events
+}
+
+// leave 移除节点, 返回待通知的视野变化事件
+func (m *aoiManager) leave(node *aoiNode) []aoiEvent {
+	m.removeFromCell(node)
+	delete(m.nodes, node.entityId)
+	events := make([]aoiEvent, 0, len(node.views))
+	for id := range node.views {
+		if other, ok := m.nodes[id]; ok {
+			events = append(events, m.unlink(node, other))
+		}
+	}
+	return events
+}
+
+// move 移动节点, 返回待通知的视野变化事件, 离开视野的事件在进入视野的事件之前
+func (m *aoiManager) move(node *aoiNode, x, y float64) []aoiEvent {
+	m.removeFromCell(node)
+	node.x, node.y = x, y
+	m.addToCell(node)
+
+	now := m.inRange(node)
+	events := make([]aoiEvent, 0)
+	for id := range node.views {
+		if _, ok := now[id]; !ok {
+			if other, ok := m.nodes[id]; ok {
+				events = append(events, m.unlink(node, other))
+			}
+		}
+	}
+	for id := range now {
+		if _, ok := node.views[id]; !ok {
+			if other, ok := m.nodes[id]; ok {
+				events = append(events, m.link(node, other))
+			}
+		}
+	}
+	return events
+}
+
+// aoiEvent 视野变化事件, 视野关系全部更新后再通知entity, 避免脚本在回调中移动或销毁entity时修改正在遍历的视野
+type aoiEvent struct {
+	a     EntityIdType
+	b     EntityIdType
+	enter bool //true为进入视野, false为离开视野
+}
+
+func (m *aoiManager) link(a, b *aoiNode) aoiEvent {
+	a.views[b.entityId] = true
+	b.views[a.entityId] = true
+	return aoiEvent{a: a.entityId, b: b.entityId, enter: true}
+}
+
+func (m *aoiManager) unlink(a, b *aoiNode) aoiEvent {
+	delete(a.views, b.entityId)
+	delete(b.views, a.entityId)
+	return aoiEvent{a: a.entityId, b: b.entityId, enter: false}
+}
+
+// dispatch 通知entity视野变化, 之前的回调已改变两者视野关系的事件不再通知
+func (m *aoiManager) dispatch(events []aoiEvent) {
+	for _, ev := range events {
+		if m.inView(ev.a, ev.b) != ev.enter {
+			continue
+		}
+		entA := GetEntityManager().GetEntityById(ev.a)
+		entB := GetEntityManager().GetEntityById(ev.b)
+		if entA == nil || entB == nil {
+			continue
+		}
+		if ev.enter {
+			entA.onEnterAoi(entB)
+			entB.onEnterAoi(entA)
+		} else {
+			entA.onLeaveAoi(entB)
+			entB.onLeaveAoi(entA)
+		}
+	}
+}
+
+// inView 两个entity当前是否互相可见
+func (m *aoiManager) inView(a, b EntityIdType) bool {
+	if node, ok := m.nodes[a]; ok {
+		_, find := node.views[b]
+		return find
+	}
+	return false
+}
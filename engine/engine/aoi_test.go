@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestAoiCellKey(t *testing.T) {
+	tests := []struct {
+		name string
+		x, y float64
+		want aoiCellKey
+	}{
+		{"origin", 0, 0, aoiCellKey{0, 0}},
+		{"inside first cell", 9.9, 9.9, aoiCellKey{0, 0}},
+		{"cell edge", 10, 20, aoiCellKey{1, 2}},
+		{"negative near zero", -0.1, -9.9, aoiCellKey{-1, -1}},
+		{"negative cell edge", -10, -20, aoiCellKey{-1, -2}},
+		{"negative beyond edge", -10.1, 5, aoiCellKey{-2, 0}},
+	}
+	m := new(aoiManager)
+	m.init(10)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.cellKey(tt.x, tt.y); got != tt.want {
+				t.Fatalf("cellKey(%v, %v) = %+v, want %+v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
+
+// aoiStep aoi操作, op为enter/move/leave, events为该操作产生的事件, +a:b表示进入视野, -a:b表示离开视野
+type aoiStep struct {
+	op     string
+	id     EntityIdType
+	x, y   float64
+	events []string
+}
+
+func TestAoiManager(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []aoiStep
+		views map[EntityIdType][]EntityIdType //操作完成后各节点的视野
+	}{
+		{"radius boundary inclusive", []aoiStep{
+			{"enter", 1, 0, 0, nil},
+			{"enter", 2, 10, 0, []string{"+2:1"}},
+		}, map[EntityIdType][]EntityIdType{1: {2}, 2: {1}}},
+		{"just outside radius", []aoiStep{
+			{"enter", 1, 0, 0, nil},
+			{"enter", 2, 10.01, 0, nil},
+		}, map[EntityIdType][]EntityIdType{1: nil, 2: nil}},
+		{"adjacent cell out of range", []aoiStep{
+			{"enter", 1, 0, 0, nil},
+			{"enter", 2, 8, 8, nil},
+		}, map[EntityIdType][]EntityIdType{1: nil, 2: nil}},
+		{"negative coordinates across zero", []aoiStep{
+			{"enter", 1, -0.5, -0.5, nil},
+			{"enter", 2, 0.5, 0.5, []string{"+2:1"}},
+			{"enter", 3, -10.5, -0.5, []string{"+3:1"}},
+		}, map[EntityIdType][]EntityIdType{1: {2, 3}, 2: {1}, 3: {1}}},
+		{"move within view", []aoiStep{
+			{"enter", 1, 0, 0, nil},
+			{"enter", 2, 5, 0, []string{"+2:1"}},
+			{"move", 1, -3, 0, nil},
+		}, map[EntityIdType][]EntityIdType{1: {2}, 2: {1}}},
+		{"move across cells", []aoiStep{
+			{"enter", 1, 0, 0, nil},
+			{"enter", 2, 5, 0, []string{"+2:1"}},
+			{"enter", 3, 25, 0, nil},
+			{"enter", 4, 22, 3, []string{"+4:3"}},
+			{"move", 1, 20, 0, []string{"-1:2", "+1:3", "+1:4"}},
+		}, map[EntityIdType][]EntityIdType{1: {3, 4}, 2: nil, 3: {1, 4}, 4: {1, 3}}},
+		{"move across negative cells", []aoiStep{
+			{"enter", 1, 1, 1, nil},
+			{"enter", 2, -1, -1, []string{"+2:1"}},
+			{"move", 1, -9, -1, nil},
+			{"move", 1, -25, -1, []string{"-1:2"}},
+		}, map[EntityIdType][]EntityIdType{1: nil, 2: nil}},
+		{"leave unlinks all", []aoiStep{
+			{"enter", 1, 0, 0, nil},
+			{"enter", 2, 5, 0, []string{"+2:1"}},
+			{"enter", 3, 0, 5, []string{"+3:1", "+3:2"}},
+			{"leave", 1, 0, 0, []string{"-1:2", "-1:3"}},
+		}, map[EntityIdType][]EntityIdType{2: {3}, 3: {2}}},
+		{"enter again after leave", []aoiStep{
+			{"enter", 1, 0, 0, nil},
+			{"enter", 2, 5, 0, []string{"+2:1"}},
+			{"leave", 2, 0, 0, []string{"-2:1"}},
+			{"enter", 2, -5, 0, []string{"+2:1"}},
+		}, map[EntityIdType][]EntityIdType{1: {2}, 2: {1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(aoiManager)
+			m.init(10)
+			for i, step := range tt.steps {
+				var events []aoiEvent
+				switch step.op {
+				case "enter":
+					events = m.enter(step.id, step.x, step.y)
+				case "move":
+					events = m.move(m.nodes[step.id], step.x, step.y)
+				case "leave":
+					events = m.leave(m.nodes[step.id])
+				}
+				if got := aoiEventStrings(events); !reflect.DeepEqual(got, step.events) {
+					t.Fatalf("step[%d] %s %d events = %v, want %v", i, step.op, step.id, got, step.events)
+				}
+			}
+			if len(m.nodes) != len(tt.views) {
+				t.Fatalf("nodes count = %d, want %d", len(m.nodes), len(tt.views))
+			}
+			for id, want := range tt.views {
+				node, ok := m.nodes[id]
+				if !ok {
+					t.Fatalf("node[%d] not in aoi", id)
+				}
+				if m.cells[node.cell][id] != node {
+					t.Fatalf("node[%d] not in cell %+v", id, node.cell)
+				}
+				got := m.Views(id)
+				sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+				if len(got) != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+					t.Fatalf("views of node[%d] = %v, want %v", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+// aoiEventStrings 事件转为字符串, 批次内离开视野的事件必须在进入视野的事件之前, 同类事件按id排序
+func aoiEventStrings(events []aoiEvent) []string {
+	var leaves, enters []string
+	for _, ev := range events {
+		if ev.enter {
+			enters = append(enters, fmt.Sprintf("+%d:%d", ev.a, ev.b))
+		} else if len(enters) > 0 {
+			return []string{"leave event after enter event"}
+		} else {
+			leaves = append(leaves, fmt.Sprintf("-%d:%d", ev.a, ev.b))
+		}
+	}
+	sort.Strings(leaves)
+	sort.Strings(enters)
+	return append(leaves, enters...)
+}
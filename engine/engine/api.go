@@ -35,6 +35,39 @@ var entityApiExports = map[string]lua.LGFunction{
 		返回值：无
 	*/
 	"save": saveEntity,
+	/*
+		aoiEnter: 进入aoi, self:aoiEnter(x, y). 视野半径由配置aoi.radius决定, 视野内的entity互相可见
+		参数1：x坐标
+		参数2：y坐标
+		返回值：是否成功
+	*/
+	"aoiEnter": aoiEnter,
+	/*
+		aoiLeave: 离开aoi, self:aoiLeave(). entity销毁时会自动离开
+		参数：无
+		返回值：无
+	*/
+	"aoiLeave": aoiLeave,
+	/*
+		aoiMove: 在aoi内移动, self:aoiMove(x, y)
+		参数1：x坐标
+		参数2：y坐标
+		返回值：是否成功
+	*/
+	"aoiMove": aoiMove,
+	/*
+		aoiViews: 获取视野内的entity, self:aoiViews()
+		参数：无
+		返回值：entityId数组
+	*/
+	"aoiViews": aoiViews,
+	/*
+		mailbox: 获取entity的mailbox, self:mailbox(). stub返回StubMailBox
+		mailbox可作为def中mailbox类型的参数传递, 通过mb:method(args)调用def中的服务端函数
+		参数：无
+		返回值：mailbox
+	*/
+	"mailbox": entityMailBox,
 }
 
 // 全局api
@@ -51,6 +84,18 @@ var entryApiExports = map[string]lua.LGFunction{
 		返回值：windows,linux
 	*/
 	"platform": getPlatform,
+	/*
+		getTraceId: 获取当前处理的消息的trace id, 可在定时器等后续调用中通过setTraceId延续
+		参数: 无
+		返回值: trace id, 没有时为空字符串
+	*/
+	"getTraceId": getTraceId,
+	/*
+		setTraceId: 设置当前的trace id, 之后的日志与发出的rpc、db请求都会带上该id
+		参数1: trace id
+		返回值: 无
+	*/
+	"setTraceId": setTraceId,
 }
 
 // 注册到debug的api
@@ -152,7 +197,7 @@ func addEntityTimer(L *lua.LState) int {
 	for i := 5; i <= top; i++ {
 		params = append(params, L.CheckAny(i))
 	}
-	timerId := ent.addEntityTimer(time.Duration(ms)*time.Millisecond, time.Duration(repeatMs)*time.Millisecond, entityScriptTimerCallback, params...)
+	timerId := ent.addScriptTimer(time.Duration(ms)*time.Millisecond, time.Duration(repeatMs)*time.Millisecond, params...)
 	L.Push(lua.LNumber(timerId))
 	return 1
 }
@@ -206,6 +251,96 @@ func saveEntity(L *lua.LState) int {
 	return 0
 }
 
+func aoiEnter(L *lua.LState) int {
+	//1: entity table
+	//2: x
+	//3: y
+	t := L.CheckTable(1)
+	x := float64(L.CheckNumber(2))
+	y := float64(L.CheckNumber(3))
+	ent := GetEntityManager().GetEntityByLua(t)
+	if ent == nil {
+		entityId := entityIdFromLua(t, entityFieldId)
+		log.Warnf("aoiEnter entity[%d] from lua but not found", entityId)
+		L.Push(lua.LFalse)
+		return 1
+	}
+	if err := GetAoiManager().Enter(ent, x, y); err != nil {
+		log.Warnf("aoiEnter error: %s", err.Error())
+		L.Push(lua.LFalse)
+		return 1
+	}
+	L.Push(lua.LTrue)
+	return 1
+}
+
+func aoiLeave(L *lua.LState) int {
+	//1: entity table
+	t := L.CheckTable(1)
+	ent := GetEntityManager().GetEntityByLua(t)
+	if ent == nil {
+		entityId := entityIdFromLua(t, entityFieldId)
+		log.Warnf("aoiLeave entity[%d] from lua but not found", entityId)
+		return 0
+	}
+	GetAoiManager().Leave(ent)
+	return 0
+}
+
+func aoiMove(L *lua.LState) int {
+	//1: entity table
+	//2: x
+	//3: y
+	t := L.CheckTable(1)
+	x := float64(L.CheckNumber(2))
+	y := float64(L.CheckNumber(3))
+	ent := GetEntityManager().GetEntityByLua(t)
+	if ent == nil {
+		entityId := entityIdFromLua(t, entityFieldId)
+		log.Warnf("aoiMove entity[%d] from lua but not found", entityId)
+		L.Push(lua.LFalse)
+		return 1
+	}
+	if err := GetAoiManager().Move(ent, x, y); err != nil {
+		log.Warnf("aoiMove error: %s", err.Error())
+		L.Push(lua.LFalse)
+		return 1
+	}
+	L.Push(lua.LTrue)
+	return 1
+}
+
+func aoiViews(L *lua.LState) int {
+	//1: entity table
+	t := L.CheckTable(1)
+	r := luaL.NewTable()
+	entityId := entityIdFromLua(t, entityFieldId)
+	for i, id := range GetAoiManager().Views(entityId) {
+		r.RawSetInt(i+1, EntityIdToLua(id))
+	}
+	L.Push(r)
+	return 1
+}
+
+func entityMailBox(L *lua.LState) int {
+	//1: entity table
+	t := L.CheckTable(1)
+	ent := GetEntityManager().GetEntityByLua(t)
+	if ent == nil {
+		entityId := entityIdFromLua(t, entityFieldId)
+		log.Warnf("get entity[%d] mailbox from lua but not found", entityId)
+		L.Push(lua.LNil)
+		return 1
+	}
+	mb := EntityMailBox{EntityId: ent.entityId, EntityName: ent.entityName, Server: ServiceName()}
+	if ent.def.volatile.isStub {
+		L.Push(MailBoxToTable(&StubMailBox{EntityMailBox: mb}))
+	} else {
+		L.Push(MailBoxToTable(&mb))
+	}
+	return 1
+}
+
 func debugGetRegistry(L *lua.LState) int {
 	v := L.Get(lua.RegistryIndex)
 	L.Push(v)
@@ -220,6 +355,17 @@ func debugUpValueId(L *lua.LState) int {
 	return 1
 }
 
+func getTraceId(L *lua.LState) int {
+	L.Push(lua.LString(TraceId()))
+	return 1
+}
+
+func setTraceId(L *lua.LState) int {
+	//1: trace id
+	SetTraceId(L.CheckString(1))
+	return 0
+}
+
 func getReloadFiles(L *lua.LState) int {
 	t := luaL.NewTable()
 
@@ -34,6 +34,7 @@ var (
 	cmdLineMgr    *commandLine       //命令行
 	timeOffset    int32              //时间偏移
 	svrStep       *ServerStep        //服务器状态
+	aoiMgr        *aoiManager        //aoi管理
 )
 
 // JsonToTable 不支持数组、字典混合格式
@@ -82,6 +83,9 @@ func mapKeyToNumber(key string) (lua.LNumber, error) {
 
 // TableToMap lua的table类型转换为golang map类型
 func TableToMap(t *lua.LTable) map[string]interface{} {
+	if target := persistTableTarget(t); target != nil {
+		t = target
+	}
 	r := make(map[string]interface{})
 	k, v := t.Next(lua.LNil)
 	for k != lua.LNil {
@@ -109,6 +113,31 @@ func TableToMap(t *lua.LTable) map[string]interface{} {
 	return r
 }
 
+// luaValueToInterface lua值转换为golang基础类型, table转换为map[string]interface{}, 数字key按numberToMapKey转换
+func luaValueToInterface(v lua.LValue) interface{} {
+	switch val := v.(type) {
+	case lua.LNumber:
+		return float64(val)
+	case lua.LString:
+		return string(val)
+	case lua.LBool:
+		return bool(val)
+	case *lua.LTable:
+		src := syncTableToPlain(val)
+		r := make(map[string]interface{})
+		for k, item := src.Next(lua.LNil); k != lua.LNil; k, item = src.Next(k) {
+			switch key := k.(type) {
+			case lua.LNumber:
+				r[numberToMapKey(key)] = luaValueToInterface(item)
+			case lua.LString:
+				r[string(key)] = luaValueToInterface(item)
+			}
+		}
+		return r
+	}
+	return nil
+}
+
 // TableToArray lua的table类型转换为golang数组类型
 func TableToArray(t *lua.LTable) []interface{} {
 	r := make([]interface{}, 0)
@@ -275,7 +304,8 @@ func entityIdToString(id EntityIdType) string {
 	return strconv.FormatInt(int64(id), 10)
 }
 
-func newClientFunction(name string, owner EntityIdType) *lua.LTable {
+// newClientFunction 生成调用客户端方法的table, target决定发给哪些客户端
+func newClientFunction(name string, owner EntityIdType, target clientTarget) *lua.LTable {
 	t := luaL.NewTable()
 	meta := luaL.NewTable()
 	luaL.SetField(meta, "name", lua.LString(name))
@@ -290,7 +320,8 @@ func newClientFunction(name string, owner EntityIdType) *lua.LTable {
 			log.Debugf("call client entity[%d] method[%s] but entity is nil", id, methodName)
 			return 0
 		}
-		if ent.client == nil {
+		mbs := ent.clientMailBoxes(target)
+		if len(mbs) == 0 {
 			log.Debugf("call client entity[%s] method[%s] but client conn is nil", ent.String(), methodName)
 			return 0
 		}
@@ -327,9 +358,7 @@ func newClientFunction(name string, owner EntityIdType) *lua.LTable {
 				if GetConfig().PrintRpcLog {
 					log.WithField("type", "RPC").Debugf("%s call client method: %s, args: %+v", ent.String(), name, args[1:])
 				}
-				if data, err := genEntityRpcMessage(uint8(ServerMessageTypeEntityRpc), buf, ent.client.mailbox.ClientId); err == nil {
-					ent.client.mailbox.Send(data)
-				} else {
+				if err := sendToClients(buf, mbs); err != nil {
 					log.Errorf("call %s client method[%s] error: %s", ent.String(), name, err.Error())
 				}
 			}
@@ -491,10 +520,24 @@ func dumpTable(t *lua.LTable) {
 	}
 }
 
+// newSyncTable 创建sync_table根节点, name为属性名
 func newSyncTable(name string) *lua.LTable {
+	t := newSyncTableNode()
+	t.RawSetString(SyncTableFieldName, lua.LString(name))
+	return t
+}
+
+// newSyncTableChild 创建sync_table的嵌套节点, 修改嵌套节点时通过parent与key还原出完整路径
+func newSyncTableChild(parent *lua.LTable, key lua.LValue) *lua.LTable {
+	t := newSyncTableNode()
+	t.RawSetString(SyncTableFieldParent, parent)
+	t.RawSetString(SyncTableFieldKey, key)
+	return t
+}
+
+func newSyncTableNode() *lua.LTable {
 	t := luaL.NewTable()
 	t.RawSetString(SyncTableFieldProps, luaL.NewTable())
-	t.RawSetString(SyncTableFieldName, lua.LString(name))
 	meta := luaL.NewTable()
 	meta.RawSetString("__index", luaL.NewFunction(func(L *lua.LState) int {
 		syncTable := L.CheckTable(1)
@@ -513,20 +556,13 @@ func newSyncTable(name string) *lua.LTable {
 		val := L.CheckAny(3)
 		propTable := syncTable.RawGetString(SyncTableFieldProps).(*lua.LTable)
 		if old := propTable.RawGet(key); old != val {
-			propTable.RawSet(key, val)
-			if entityId, ok := syncTable.RawGetString(SyncTableFieldOwner).(lua.LNumber); ok {
-				if propName, ok := syncTable.RawGetString(SyncTableFieldName).(lua.LString); ok {
-					if ent := GetEntityManager().GetEntityById(EntityIdType(entityId)); ent != nil {
-						if prop := ent.def.prop(propName.String()); prop != nil {
-							if prop.config.IsSyncProp() {
-								ent.onSyncTableUpdated(propName.String(), key, val)
-							}
-						}
-					}
-				}
-			} else {
-				log.Warnf("syncTable field updated but owner field not found")
+			if t, ok := val.(*lua.LTable); ok {
+				child := newSyncTableChild(syncTable, key)
+				fillSyncTable(child, syncTableToPlain(t))
+				val = child
 			}
+			propTable.RawSet(key, val)
+			onSyncTableNodeUpdated(syncTable, key, val)
 		}
 
 		return 0
@@ -535,6 +571,77 @@ func newSyncTable(name string) *lua.LTable {
 	return t
 }
 
+// isSyncTableNode 是否是sync_table节点
+func isSyncTableNode(t *lua.LTable) bool {
+	_, ok := t.RawGetString(SyncTableFieldProps).(*lua.LTable)
+	return ok
+}
+
+// fillSyncTable 将普通table的内容填充到sync_table节点, 嵌套的table转换为子节点
+func fillSyncTable(node *lua.LTable, data *lua.LTable) {
+	props := luaL.NewTable()
+	node.RawSetString(SyncTableFieldProps, props)
+	for k, v := data.Next(lua.LNil); k != lua.LNil; k, v = data.Next(k) {
+		if t, ok := v.(*lua.LTable); ok {
+			child := newSyncTableChild(node, k)
+			fillSyncTable(child, syncTableToPlain(t))
+			props.RawSet(k, child)
+		} else {
+			props.RawSet(k, v)
+		}
+	}
+}
+
+// syncTableToPlain 将sync_table节点还原为普通table(深拷贝), 传入普通table或存盘table代理时同样返回其深拷贝
+func syncTableToPlain(t *lua.LTable) *lua.LTable {
+	src := t
+	if isSyncTableNode(t) {
+		src = t.RawGetString(SyncTableFieldProps).(*lua.LTable)
+	} else if target := persistTableTarget(t); target != nil {
+		src = target
+	}
+	r := luaL.NewTable()
+	for k, v := src.Next(lua.LNil); k != lua.LNil; k, v = src.Next(k) {
+		if child, ok := v.(*lua.LTable); ok {
+			r.RawSet(k, syncTableToPlain(child))
+		} else {
+			r.RawSet(k, v)
+		}
+	}
+	return r
+}
+
+// onSyncTableNodeUpdated 向上找到根节点, 拼出修改路径后通知所属entity
+func onSyncTableNodeUpdated(node *lua.LTable, key lua.LValue, val lua.LValue) {
+	path := []lua.LValue{key}
+	root := node
+	for {
+		parent, ok := root.RawGetString(SyncTableFieldParent).(*lua.LTable)
+		if !ok {
+			break
+		}
+		path = append([]lua.LValue{root.RawGetString(SyncTableFieldKey)}, path...)
+		root = parent
+	}
+	entityId, ok := root.RawGetString(SyncTableFieldOwner).(lua.LNumber)
+	if !ok {
+		log.Warnf("syncTable field updated but owner field not found")
+		return
+	}
+	propName, ok := root.RawGetString(SyncTableFieldName).(lua.LString)
+	if !ok {
+		return
+	}
+	if ent := GetEntityManager().GetEntityById(EntityIdType(entityId)); ent != nil {
+		if prop := ent.def.prop(propName.String()); prop != nil {
+			if prop.config.IsSyncProp() {
+				ent.onSyncTableUpdated(propName.String(), path, val)
+			}
+			ent.onPersistTableUpdated(propName.String(), path[0])
+		}
+	}
+}
+
 // LuaArrayToBsonD 将{{"a", 1}, {"b", 2}}格式的lua数组转换为bsonD格式
 func LuaArrayToBsonD(t *lua.LTable) (bson.D, error) {
 	r := bson.D{}
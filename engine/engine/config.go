@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/spf13/viper"
+	"net"
 	"strconv"
 )
 
@@ -18,13 +19,17 @@ type config struct {
 	ServerId          ServerIdType  //服务器ID
 	Release           bool          //是否正式环境
 	SaveInterval      int64         //单位: 分钟
+	FullSaveInterval  int64         //全量存盘间隔, 单位: 分钟
 	SaveNumPerTick    int32         //每个tick存盘的entity数量
 	HeartBeatInterval int32         //心跳间隔,单位秒
 	PrintRpcLog       bool          //是否输出rpc日志
+	StrictDefVersion  bool          //登录时是否拒绝未上报def版本号的客户端, 默认只拒绝版本号与服务器不一致的客户端
+	CompressThreshold int           //消息包体超过该长度(字节)时压缩, 小于等于0不压缩(默认), 开启前所有对端(客户端、robot)需支持压缩标记位
 	Logger            loggerConfig  //日志配置
 	Etcd              etcdConfig    //etcd配置
 	Redis             *redisConfig  //redis配置
 	Server            *serverConfig //服务器配置
+	Aoi               aoiConfig     //aoi配置
 	vp                *viper.Viper  //配置文件读取模块
 }
 
@@ -39,6 +44,10 @@ type etcdConfig struct {
 	EndPoints string //etcd地址
 }
 
+type aoiConfig struct {
+	Radius float64 //视野半径
+}
+
 type redisConfig struct {
 	Hosts     string
 	AloneMode bool
@@ -52,7 +61,17 @@ type serverConfig struct {
 	//==============以上配置所有进程通用======================
 
 	//==============以下配置gate进程独有======================
-	IsInner bool `json:"inner,omitempty"` //是否为内部通信gate
+	IsInner    bool              `json:"inner,omitempty"`       //是否为内部通信gate
+	Encrypt    string            `json:"encrypt,omitempty"`     //客户端连接的加密算法(aes-gcm/chacha20-poly1305), 为空不加密, robot按此配置发起握手
+	EncryptKey string            `json:"encrypt_key,omitempty"` //gate身份私钥(hex编码的32字节ed25519种子), 开启加密时必须配置, 用于签名握手回包
+	EncryptPub string            `json:"encrypt_pub,omitempty"` //gate身份公钥(hex编码), robot开启加密时必须配置, 用于校验握手回包
+	WebSocket  string            `json:"websocket,omitempty"`   //websocket监听地址, 为空不监听
+	Kcp        string            `json:"kcp,omitempty"`         //kcp监听地址, 为空不监听, robot配置时通过kcp连接该地址
+	ResumeTime int               `json:"resume,omitempty"`      //断线后会话保留时间, 期间可凭凭证重连而无需重新登录, 单位: 秒, 为0不开启
+	Codec      string            `json:"codec,omitempty"`       //客户端消息编码(msgpack/typed), gate为typed时接受客户端协商typed编码, robot按此配置发起协商
+	RateLimit  *rateLimitConfig  `json:"rate_limit,omitempty"`  //客户端消息限流, 为空时使用默认配置
+	DrainTime  int               `json:"drain,omitempty"`       //排空时等待客户端断开的最长时间, 超时后直接退出, 单位: 秒, 为0使用默认值
+	Public     *publicAddrConfig `json:"public,omitempty"`      //对外公布的客户端连接地址, 其他gate排空时下发给客户端, 为空时使用监听地址
 	//==============以下配置gate进程独有======================
 
 	//==============以下配置game进程独有======================
@@ -70,6 +89,66 @@ type serverConfig struct {
 	//==============以上配置admin进程独有======================
 }
 
+// publicAddrConfig gate各连接方式对外公布的地址, 监听0.0.0.0等未指定地址或经过NAT、负载均衡时需要配置
+type publicAddrConfig struct {
+	Tcp       string `json:"tcp,omitempty"`
+	WebSocket string `json:"websocket,omitempty"`
+	Kcp       string `json:"kcp,omitempty"`
+}
+
+// RateLimit 令牌桶限流, 每秒补充Rate个令牌, 最多积攒Burst个, 每条消息消耗一个令牌
+type RateLimit struct {
+	Rate  float64 `json:"rate"`  //每秒补充的令牌数
+	Burst int     `json:"burst"` //桶容量, 即允许的突发消息数
+}
+
+func (m RateLimit) check() error {
+	if m.Rate <= 0 || m.Burst <= 0 {
+		return fmt.Errorf("rate[%v] and burst[%d] should be positive", m.Rate, m.Burst)
+	}
+	return nil
+}
+
+// rateLimitConfig gate对客户端消息的限流配置, 超限次数在统计窗口内逐级处罚: 丢弃消息、回复ErrMsgTooBusy、断开连接
+type rateLimitConfig struct {
+	Messages map[string]RateLimit `json:"messages,omitempty"` //消息类型编号 -> 限流, 未配置的非rpc消息使用默认限流
+	Methods  map[string]RateLimit `json:"methods,omitempty"`  //暴露给客户端的服务端函数名(不区分大小写) -> 限流, 优先于def中的配置
+	Window   int                  `json:"window,omitempty"`   //超限次数的统计窗口, 单位: 秒, 为0使用默认值
+	Busy     int                  `json:"busy,omitempty"`     //窗口内超限次数达到该值时回复ErrMsgTooBusy, 之前的超限消息直接丢弃, 为0使用默认值(第一次超限即回复)
+	Kick     int                  `json:"kick,omitempty"`     //窗口内超限次数达到该值时断开连接, 小于等于0不断开(默认)
+}
+
+func (m *rateLimitConfig) check() error {
+	for key, limit := range m.Messages {
+		if _, err := strconv.ParseUint(key, 10, 8); err != nil {
+			return fmt.Errorf("invalid message type: %s", key)
+		}
+		if err := limit.check(); err != nil {
+			return fmt.Errorf("message[%s] %s", key, err.Error())
+		}
+	}
+	for name, limit := range m.Methods {
+		if err := limit.check(); err != nil {
+			return fmt.Errorf("method[%s] %s", name, err.Error())
+		}
+	}
+	if m.Window < 0 || m.Busy < 0 {
+		return fmt.Errorf("window[%d] and busy[%d] should not be negative", m.Window, m.Busy)
+	}
+	return nil
+}
+
+// MessageLimits 按消息类型配置的限流, 消息类型 -> 限流
+func (m *rateLimitConfig) MessageLimits() map[uint8]RateLimit {
+	r := make(map[uint8]RateLimit)
+	for key, limit := range m.Messages {
+		if msgType, err := strconv.ParseUint(key, 10, 8); err == nil {
+			r[uint8(msgType)] = limit
+		}
+	}
+	return r
+}
+
 func initConfig() error {
 	cfg = &config{
 		vp: viper.New(),
@@ -88,6 +167,14 @@ func initConfig() error {
 		cfg.SaveInterval = defaultSaveInterval
 	}
 
+	if cfg.FullSaveInterval <= 0 {
+		cfg.FullSaveInterval = defaultFullSaveInterval
+	}
+
+	if cfg.Aoi.Radius <= 0 {
+		cfg.Aoi.Radius = defaultAoiRadius
+	}
+
 	if cfg.WorkPath == "" {
 		return errors.New("work path is empty")
 	}
@@ -100,6 +187,33 @@ func initConfig() error {
 		return fmt.Errorf("server key[%s] config load failed", cfg.ServerKey())
 	}
 
+	if cfg.Server.Encrypt != "" && !IsValidCipher(cfg.Server.Encrypt) {
+		return fmt.Errorf("server key[%s] invalid encrypt cipher: %s", cfg.ServerKey(), cfg.Server.Encrypt)
+	}
+
+	if cfg.Server.Encrypt != "" {
+		var err error
+		switch gSvrType {
+		case STGate:
+			_, err = ParseCryptoIdentityKey(cfg.Server.EncryptKey)
+		case STRobot:
+			_, err = ParseCryptoIdentityPub(cfg.Server.EncryptPub)
+		}
+		if err != nil {
+			return fmt.Errorf("server key[%s] invalid encrypt identity key: %s", cfg.ServerKey(), err.Error())
+		}
+	}
+
+	if cfg.Server.Codec != "" && !IsValidClientCodec(cfg.Server.Codec) {
+		return fmt.Errorf("server key[%s] invalid client codec: %s", cfg.ServerKey(), cfg.Server.Codec)
+	}
+
+	if cfg.Server.RateLimit != nil {
+		if err := cfg.Server.RateLimit.check(); err != nil {
+			return fmt.Errorf("server key[%s] invalid rate limit: %s", cfg.ServerKey(), err.Error())
+		}
+	}
+
 	return nil
 }
 
@@ -165,6 +279,37 @@ func (m *config) GetServerConfigByName(name string) *serverConfig {
 	return m.parseServerConfig(name)
 }
 
+// PublicAddrs gate各连接方式对外公布的地址, 未配置时使用监听地址, 监听地址的host未指定时不公布
+func (m *config) PublicAddrs() map[string]string {
+	server := m.ServerConfig()
+	public := server.Public
+	if public == nil {
+		public = &publicAddrConfig{}
+	}
+	r := make(map[string]string)
+	for transport, addrs := range map[string][2]string{
+		ClientTransportTcp:       {public.Tcp, server.Addr},
+		ClientTransportWebSocket: {public.WebSocket, server.WebSocket},
+		ClientTransportKcp:       {public.Kcp, server.Kcp},
+	} {
+		if addr := publicAddr(addrs[0], addrs[1]); addr != "" {
+			r[transport] = addr
+		}
+	}
+	return r
+}
+
+func publicAddr(public, listen string) string {
+	if public != "" {
+		return public
+	}
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil || host == "" || net.ParseIP(host).IsUnspecified() {
+		return ""
+	}
+	return listen
+}
+
 func (m *config) GetAddr() string {
 	return m.Server.Addr
 }
@@ -0,0 +1,27 @@
+package engine
+
+import "testing"
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		name   string
+		public string
+		listen string
+		want   string
+	}{
+		{"configured public", "gate.example.com:6300", "0.0.0.0:6300", "gate.example.com:6300"},
+		{"listen on ip", "", "10.0.0.1:6300", "10.0.0.1:6300"},
+		{"listen on host", "", "localhost:6300", "localhost:6300"},
+		{"unspecified ipv4", "", "0.0.0.0:6300", ""},
+		{"unspecified ipv6", "", "[::]:6300", ""},
+		{"empty host", "", ":6300", ""},
+		{"not listening", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := publicAddr(tt.public, tt.listen); got != tt.want {
+				t.Fatalf("publicAddr(%q, %q) = %q, want %q", tt.public, tt.listen, got, tt.want)
+			}
+		})
+	}
+}
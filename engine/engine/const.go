@@ -11,9 +11,11 @@ type ConnectIdType uint32 //客户端连接ID类型
 const entityIdTypeString = "int64" //entityId类型名
 
 const (
-	ServerTick          = 100 * time.Millisecond //服务器tick间隔
-	defaultSaveInterval = 5                      //自动存盘间隔, 单位: 分钟
-	HeartbeatTick       = 3                      //默认心跳时间,单位：秒
+	ServerTick              = 100 * time.Millisecond //服务器tick间隔
+	defaultSaveInterval     = 5                      //自动存盘间隔, 单位: 分钟
+	defaultFullSaveInterval = 30                     //全量存盘间隔, 单位: 分钟
+	HeartbeatTick           = 3                      //默认心跳时间,单位：秒
+	defaultAoiRadius        = 50                     //默认aoi视野半径
 )
 
 const (
@@ -37,9 +39,18 @@ const (
 )
 
 const (
-	SyncTableFieldProps = "__props"
-	SyncTableFieldOwner = "__owner"
-	SyncTableFieldName  = "__name"
+	SyncTableFieldProps  = "__props"  //sync_table的实际数据
+	SyncTableFieldOwner  = "__owner"  //根节点所属的entity
+	SyncTableFieldName   = "__name"   //根节点对应的属性名
+	SyncTableFieldParent = "__parent" //嵌套节点的父节点
+	SyncTableFieldKey    = "__key"    //嵌套节点在父节点中的key
+)
+
+const (
+	persistTableFieldTarget = "__target" //存盘table代理对应的实际数据
+	persistTableFieldOwner  = "__owner"  //代理所属的entity
+	persistTableFieldName   = "__name"   //代理对应的属性名
+	persistTableFieldKey    = "__key"    //嵌套代理所在的属性第一层key, 根代理为nil
 )
 
 const (
@@ -64,11 +75,19 @@ const (
 	entityFieldId   = "id"
 )
 
+// 引擎注册给entity的客户端rpc入口
+const (
+	entityFieldClient       = "client"       //自己的客户端
+	entityFieldOtherClients = "otherClients" //aoi内除自己外的客户端
+	entityFieldAllClients   = "allClients"   //aoi内包括自己的所有客户端
+)
+
 // mongo中记录的非def定义的字段
 const (
-	MongoFieldId   = entityFieldId
-	MongoFieldName = entityFieldName
-	MongoPrimaryId = "_id"
+	MongoFieldId      = entityFieldId
+	MongoFieldName    = entityFieldName
+	MongoFieldVersion = "__version" //存盘数据的版本号, 对应def中的Volatile.Version
+	MongoPrimaryId    = "_id"
 )
 
 type DBType uint32
@@ -83,12 +102,13 @@ type DBTaskType uint32
 
 // db的任务类型
 const (
-	DBTaskTypeQueryOne   DBTaskType = iota //查询单条数据
-	DBTaskTypeUpdateOne                    //更新单条数据
-	DBTaskTypeReplaceOne                   //替换单条数据
-	DBTaskTypeDeleteOne                    //删除单条数据
-	DBTaskTypeQueryMany                    //查询多条数据
-	DBTaskTypeDeleteMany                   //删除多条数据
+	DBTaskTypeQueryOne     DBTaskType = iota //查询单条数据
+	DBTaskTypeUpdateOne                      //更新单条数据
+	DBTaskTypeReplaceOne                     //替换单条数据
+	DBTaskTypeDeleteOne                      //删除单条数据
+	DBTaskTypeQueryMany                      //查询多条数据
+	DBTaskTypeDeleteMany                     //删除多条数据
+	DBTaskTypeUpdateFields                   //按$set/$unset更新单条数据
 
 	DBTaskTypeMax
 )
@@ -101,6 +121,9 @@ const (
 	ServiceRobotPrefix = "robot."
 	ServiceAdminPrefix = "admin."
 	StubPrefix         = "stub."
+	StubShardPrefix    = "stub_shard."
+	StubFailoverPrefix = "stub_failover."
+	GateDrainPrefix    = "gate_drain."
 	EntityPrefix       = "entity."
 )
 
@@ -111,16 +134,26 @@ const (
 	EtcdTypeEntity = "entity" //entity
 )
 
+// 客户端连接方式, gate对外公布地址的key
+const (
+	ClientTransportTcp       = "tcp"
+	ClientTransportWebSocket = "websocket"
+	ClientTransportKcp       = "kcp"
+)
+
 // 注册到etcd的value map的key
 const (
 	EtcdValueAddr   = "addr"   //进程地址
 	EtcdValueType   = "type"   //对象类型
 	EtcdValueIsStub = "isStub" //是否是stub进程
+	EtcdValueInner  = "inner"  //是否是内部通信gate
+	EtcdValuePublic = "public" //gate对外公布的客户端连接地址, 连接方式 -> 地址
 
 	EtcdValueServer    = "server"   //所在进程名称
 	EtcdValueName      = "name"     //名字
 	EtcdStubValueEntry = "entry"    //entry stub名字
 	EtcdValueEntityId  = "entityId" //entity的id
+	EtcdValueShard     = "shard"    //分片stub的分片序号
 )
 
 // etcd租约ttl
@@ -133,6 +166,8 @@ const (
 	ClientMsgDataFieldType     = "6d5e7" //__type, 消息类型
 	ClientMsgDataFieldEntityID = "2ec86" //__entity, entity id
 	ClientMsgDataFieldArgs     = "4ac22" //__args, 参数
+	ClientMsgDataFieldSeq      = "3e27e" //__seq, gate发往客户端的消息序号
+	ClientMsgDataFieldVersion  = "56679" //__version, 登录时客户端的def版本号
 )
 
 // 与客户端交互消息类型, 取值范围[1,150]
@@ -145,6 +180,12 @@ const (
 	ClientMsgTypeTips                      //服务器提示消息 S->C
 	ClientMsgTypePropSyncUpdate            //属性增量同步给客户端 S->C
 	ClientMsgTypeHeartBeat                 //客户端心跳 C->S & S->C
+	ClientMsgTypeDestroyEntity             //销毁客户端entity S->C
+	ClientMsgTypePropResync                //客户端请求全量同步属性 C->S
+	ClientMsgTypeHandshake                 //加密握手 C->S & S->C
+	ClientMsgTypeSessionToken              //下发断线重连使用的会话凭证 S->C
+	ClientMsgTypeResume                    //断线重连恢复会话 C->S & S->C
+	ClientMsgTypeCodec                     //协商客户端消息编码 C->S & S->C
 )
 
 // 服务器内部消息类型,取值范围[151,255]
@@ -163,6 +204,13 @@ const (
 	ServerMessageTypeServerError                      //服务器错误消息
 	ServerMessageTypeChangeEntityClient               //entity与客户端连接绑定/解绑
 	ServerMessageTypeSetServerTime                    //修改服务器时间
+	ServerMessageTypePropResync                       //客户端请求全量同步属性
+	ServerMessageTypeMigrateEntity                    //entity迁移到其他game
+	ServerMessageTypeMigrateEntityRsp                 //entity迁移结果
+	ServerMessageTypeForwardMessage                   //由gate原样转发给指定game的消息
+	ServerMessageTypeEntityRpcRsp                     //entity rpc的返回值
+	ServerMessageTypeResumeClient                     //客户端断线重连恢复会话
+	ServerMessageTypeMigrateEntityAbort               //取消超时的entity迁移
 )
 
 // ClientMsgTypeError类型的消息内容
@@ -174,21 +222,30 @@ const (
 	ErrMsgInvalidMessage          = "INVALID_MESSAGE"           //无效消息
 	ErrMsgTooBusy                 = "MESSAGE_TOO_BUSY"          //请求频率过于频繁
 	ErrMsgRetryLater              = "RETRY_LATER"               //稍后再试
+	ErrMsgHandshakeFailed         = "HANDSHAKE_FAILED"          //加密握手失败
+	ErrMsgResumeFailed            = "RESUME_FAILED"             //恢复会话失败, 需要重新登录
+	ErrMsgDefVersionMismatch      = "DEF_VERSION_MISMATCH"      //客户端def版本与服务器不一致, 附带服务器的def版本号
+	ErrMsgReconnectGate           = "RECONNECT_OTHER_GATE"      //gate即将关闭, 需连接其他gate, 附带其他gate的地址, 没有可用gate时为空
 )
 
 const StubEntryMethod = "entry" //entry stub必须定义的函数,登录主入口
 
 // 脚本层的接口名
 const (
-	onServerTimeUpdate = "on_server_time_update" //服务器时间变化时脚本层回调
-	onReload           = "on_reload"             //热更
-	doGmCommand        = "do_gm_command"         //执行gm命令
-	getGmListCommand   = "get_gm_list"           //获取gm列表
-	onEntityCreated    = "on_created"            //entity创建完成
-	onEntityDestroy    = "on_destroy"            //entity销毁
-	onEntityFinal      = "on_final"              //entity销毁完成
-	onEntityGetClient  = "on_get_client"         //entity绑定到客户端连接
-	onEntityLostClient = "on_lose_client"        //entity失去客户端连接
+	onServerTimeUpdate  = "on_server_time_update" //服务器时间变化时脚本层回调
+	onReload            = "on_reload"             //热更
+	doGmCommand         = "do_gm_command"         //执行gm命令
+	getGmListCommand    = "get_gm_list"           //获取gm列表
+	onEntityCreated     = "on_created"            //entity创建完成
+	onEntityDestroy     = "on_destroy"            //entity销毁
+	onEntityFinal       = "on_final"              //entity销毁完成
+	onEntityGetClient   = "on_get_client"         //entity绑定到客户端连接
+	onEntityLostClient  = "on_lose_client"        //entity失去客户端连接
+	onEntityEnterAoi    = "on_enter_aoi"          //其他entity进入视野
+	onEntityLeaveAoi    = "on_leave_aoi"          //其他entity离开视野
+	onEntityMigrate     = "on_migrate"            //加载的存盘数据版本低于def版本时迁移数据
+	onEntityMigratedIn  = "on_migrated_in"        //entity从其他game迁入
+	onEntityMigratedOut = "on_migrated_out"       //entity迁出到其他game
 )
 
 const (
@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	CipherAesGcm   = "aes-gcm"           //AES-256-GCM
+	CipherChaCha20 = "chacha20-poly1305" //ChaCha20-Poly1305
+)
+
+const (
+	cryptoLabelC2S = "rpg c2s" //客户端发往服务器方向的密钥派生标签
+	cryptoLabelS2C = "rpg s2c" //服务器发往客户端方向的密钥派生标签
+	cryptoLabelSig = "rpg sig" //握手签名内容的标签
+)
+
+// CryptoSession 握手完成后的加密会话, 两个方向使用不同的密钥与各自递增的nonce, 非并发安全
+type CryptoSession struct {
+	sealer  cipher.AEAD
+	opener  cipher.AEAD
+	sendSeq uint64 //发送方向的nonce序号
+	recvSeq uint64 //接收方向的nonce序号
+}
+
+// IsValidCipher 是否是支持的加密算法
+func IsValidCipher(name string) bool {
+	return name == CipherAesGcm || name == CipherChaCha20
+}
+
+// NewCryptoKey 生成握手用的X25519临时密钥
+func NewCryptoKey() (*ecdh.PrivateKey, error) {
+	return ecdh.X25519().GenerateKey(rand.Reader)
+}
+
+// ParseCryptoIdentityKey 解析hex编码的服务器身份私钥, 内容为32字节的ed25519种子
+func ParseCryptoIdentityKey(s string) (ed25519.PrivateKey, error) {
+	seed, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("identity key should be %d bytes but got %d", ed25519.SeedSize, len(seed))
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// ParseCryptoIdentityPub 解析hex编码的服务器身份公钥
+func ParseCryptoIdentityPub(s string) (ed25519.PublicKey, error) {
+	pub, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("identity public key should be %d bytes but got %d", ed25519.PublicKeySize, len(pub))
+	}
+	return pub, nil
+}
+
+// SignHandshake 服务器以身份私钥签名加密算法与双方临时公钥, 客户端校验后才能确认临时公钥来自服务器而非中间人
+func SignHandshake(key ed25519.PrivateKey, cipherName string, clientPub, serverPub []byte) []byte {
+	return ed25519.Sign(key, handshakeSignContent(cipherName, clientPub, serverPub))
+}
+
+// VerifyHandshake 客户端以预先配置的服务器身份公钥校验握手回包的签名
+func VerifyHandshake(pub ed25519.PublicKey, cipherName string, clientPub, serverPub, sig []byte) bool {
+	return ed25519.Verify(pub, handshakeSignContent(cipherName, clientPub, serverPub), sig)
+}
+
+func handshakeSignContent(cipherName string, clientPub, serverPub []byte) []byte {
+	r := make([]byte, 0, len(cryptoLabelSig)+len(cipherName)+len(clientPub)+len(serverPub)+1)
+	r = append(r, cryptoLabelSig...)
+	r = append(r, byte(len(cipherName)))
+	r = append(r, cipherName...)
+	r = append(r, clientPub...)
+	return append(r, serverPub...)
+}
+
+// NewCryptoSession 根据本端私钥与对端公钥协商会话密钥, isServer区分密钥的收发方向
+func NewCryptoSession(cipherName string, key *ecdh.PrivateKey, peerPub []byte, isServer bool) (*CryptoSession, error) {
+	if !IsValidCipher(cipherName) {
+		return nil, fmt.Errorf("unsupported cipher: %s", cipherName)
+	}
+	pub, err := ecdh.X25519().NewPublicKey(peerPub)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := key.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+	clientPub, serverPub := key.PublicKey().Bytes(), peerPub
+	if isServer {
+		clientPub, serverPub = serverPub, clientPub
+	}
+	c2s, err := newAEAD(cipherName, deriveCryptoKey(cryptoLabelC2S, shared, clientPub, serverPub))
+	if err != nil {
+		return nil, err
+	}
+	s2c, err := newAEAD(cipherName, deriveCryptoKey(cryptoLabelS2C, shared, clientPub, serverPub))
+	if err != nil {
+		return nil, err
+	}
+	if isServer {
+		return &CryptoSession{sealer: s2c, opener: c2s}, nil
+	}
+	return &CryptoSession{sealer: c2s, opener: s2c}, nil
+}
+
+func deriveCryptoKey(label string, shared, clientPub, serverPub []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(label))
+	h.Write(shared)
+	h.Write(clientPub)
+	h.Write(serverPub)
+	return h.Sum(nil)
+}
+
+func newAEAD(cipherName string, key []byte) (cipher.AEAD, error) {
+	if cipherName == CipherChaCha20 {
+		return chacha20poly1305.New(key)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func cryptoNonce(aead cipher.AEAD, seq uint64) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], seq)
+	return nonce
+}
+
+// Seal 加密一帧数据
+func (m *CryptoSession) Seal(data []byte) []byte {
+	out := m.sealer.Seal(nil, cryptoNonce(m.sealer, m.sendSeq), data, nil)
+	m.sendSeq++
+	return out
+}
+
+// Open 解密一帧数据, 帧被篡改、重放或乱序时返回错误
+func (m *CryptoSession) Open(data []byte) ([]byte, error) {
+	out, err := m.opener.Open(nil, cryptoNonce(m.opener, m.recvSeq), data, nil)
+	if err != nil {
+		return nil, err
+	}
+	m.recvSeq++
+	return out, nil
+}
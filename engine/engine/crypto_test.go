@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+)
+
+func TestVerifyHandshake(t *testing.T) {
+	key, err := ParseCryptoIdentityKey(hex.EncodeToString(bytes.Repeat([]byte{1}, 32)))
+	if err != nil {
+		t.Fatalf("parse identity key error: %s", err.Error())
+	}
+	pub, err := ParseCryptoIdentityPub(hex.EncodeToString(key.Public().(ed25519.PublicKey)))
+	if err != nil {
+		t.Fatalf("parse identity public key error: %s", err.Error())
+	}
+	other, _ := ParseCryptoIdentityKey(hex.EncodeToString(bytes.Repeat([]byte{2}, 32)))
+	clientPub, serverPub := bytes.Repeat([]byte{3}, 32), bytes.Repeat([]byte{4}, 32)
+	sig := SignHandshake(key, CipherAesGcm, clientPub, serverPub)
+	tests := []struct {
+		name      string
+		cipher    string
+		clientPub []byte
+		serverPub []byte
+		sig       []byte
+		want      bool
+	}{
+		{"valid", CipherAesGcm, clientPub, serverPub, sig, true},
+		{"cipher downgraded", CipherChaCha20, clientPub, serverPub, sig, false},
+		{"server key replaced", CipherAesGcm, clientPub, bytes.Repeat([]byte{5}, 32), sig, false},
+		{"client key replaced", CipherAesGcm, bytes.Repeat([]byte{5}, 32), serverPub, sig, false},
+		{"signed by other key", CipherAesGcm, clientPub, serverPub, SignHandshake(other, CipherAesGcm, clientPub, serverPub), false},
+		{"missing signature", CipherAesGcm, clientPub, serverPub, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyHandshake(pub, tt.cipher, tt.clientPub, tt.serverPub, tt.sig); got != tt.want {
+				t.Fatalf("VerifyHandshake = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCryptoIdentity(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{"valid", hex.EncodeToString(bytes.Repeat([]byte{1}, 32)), false},
+		{"not hex", "xyz", true},
+		{"too short", hex.EncodeToString(bytes.Repeat([]byte{1}, 16)), true},
+		{"empty", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCryptoIdentityKey(tt.value); (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCryptoIdentityKey error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, err := ParseCryptoIdentityPub(tt.value); (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCryptoIdentityPub error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
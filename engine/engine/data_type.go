@@ -773,6 +773,7 @@ func (m *dtTable) SetDefault(val string) error {
 }
 
 func (m *dtTable) IsSameType(v lua.LValue) bool {
+	v = unwrapPersistTable(v)
 	return v.Type() == lua.LTTable
 }
 
@@ -781,6 +782,7 @@ func (m *dtTable) ParseDefaultVal(v string) (lua.LValue, error) {
 }
 
 func (m *dtTable) ParseFromLua(v lua.LValue) interface{} {
+	v = unwrapPersistTable(v)
 	if m.IsSameType(v) == false {
 		v = m.Default()
 	}
@@ -788,6 +790,7 @@ func (m *dtTable) ParseFromLua(v lua.LValue) interface{} {
 }
 
 func (m *dtTable) ParseRawFromLua(v lua.LValue) interface{} {
+	v = unwrapPersistTable(v)
 	if m.IsSameType(v) == false {
 		v = m.Default()
 	}
@@ -833,6 +836,7 @@ func (m *dtMap) SetDefault(val string) error {
 }
 
 func (m *dtMap) IsSameType(v lua.LValue) bool {
+	v = unwrapPersistTable(v)
 	switch converted := v.(type) {
 	case *lua.LTable:
 		for ck, cv := converted.Next(lua.LNil); ck != lua.LNil; ck, cv = converted.Next(ck) {
@@ -859,6 +863,7 @@ func (m *dtMap) ParseDefaultVal(v string) (lua.LValue, error) {
 }
 
 func (m *dtMap) ParseFromLua(v lua.LValue) interface{} {
+	v = unwrapPersistTable(v)
 	if m.IsSameType(v) == false {
 		v = m.Default()
 	}
@@ -878,6 +883,7 @@ func (m *dtMap) ParseFromLua(v lua.LValue) interface{} {
 }
 
 func (m *dtMap) ParseRawFromLua(v lua.LValue) interface{} {
+	v = unwrapPersistTable(v)
 	if m.IsSameType(v) == false {
 		v = m.Default()
 	}
@@ -963,6 +969,7 @@ func (m *dtArray) SetDefault(val string) error {
 }
 
 func (m *dtArray) IsSameType(v lua.LValue) bool {
+	v = unwrapPersistTable(v)
 	switch converted := v.(type) {
 	case *lua.LTable:
 		expect := 1
@@ -990,6 +997,7 @@ func (m *dtArray) ParseDefaultVal(v string) (lua.LValue, error) {
 }
 
 func (m *dtArray) ParseFromLua(v lua.LValue) interface{} {
+	v = unwrapPersistTable(v)
 	if m.IsSameType(v) == false {
 		v = m.Default()
 	}
@@ -1007,6 +1015,7 @@ func (m *dtArray) ParseFromLua(v lua.LValue) interface{} {
 }
 
 func (m *dtArray) ParseRawFromLua(v lua.LValue) interface{} {
+	v = unwrapPersistTable(v)
 	if m.IsSameType(v) == false {
 		v = m.Default()
 	}
@@ -1090,6 +1099,7 @@ func (m *dtStruct) SetDefault(val string) error {
 }
 
 func (m *dtStruct) IsSameType(v lua.LValue) bool {
+	v = unwrapPersistTable(v)
 	switch converted := v.(type) {
 	case *lua.LTable:
 		fieldCount := 0
@@ -1143,6 +1153,7 @@ func (m *dtStruct) AssignToStruct(t *lua.LTable, v lua.LValue) error {
 }
 
 func (m *dtStruct) ParseFromLua(v lua.LValue) interface{} {
+	v = unwrapPersistTable(v)
 	if m.IsSameType(v) == false {
 		v = m.Default()
 	}
@@ -1159,6 +1170,7 @@ func (m *dtStruct) ParseFromLua(v lua.LValue) interface{} {
 }
 
 func (m *dtStruct) ParseRawFromLua(v lua.LValue) interface{} {
+	v = unwrapPersistTable(v)
 	if m.IsSameType(v) == false {
 		v = m.Default()
 	}
@@ -1244,7 +1256,8 @@ func (m *dtMailBox) ParseFromLua(v lua.LValue) interface{} {
 	if m.IsSameType(v) == false {
 		v = m.Default()
 	}
-	return TableToMailBox(v.(*lua.LTable))
+	//转换为带类型字段的字典, 对端通过MapToTable还原为mailbox
+	return TableToMap(MailBoxToTable(TableToMailBox(v.(*lua.LTable))))
 }
 
 func (m *dtMailBox) ParseRawFromLua(v lua.LValue) interface{} {
@@ -1274,8 +1287,13 @@ func (m *dtSyncTable) Detail() *dataTypeDetail {
 	return &m.detail
 }
 
+// Default sync_table会记录所属entity, 每次返回新的节点
 func (m *dtSyncTable) Default() lua.LValue {
-	return m.detail.defaultVal
+	t := newSyncTable(m.detail.name)
+	if def, ok := m.detail.defaultVal.(*lua.LTable); ok {
+		fillSyncTable(t, syncTableToPlain(def))
+	}
+	return t
 }
 
 func (m *dtSyncTable) SetDefault(val string) error {
@@ -1301,7 +1319,7 @@ func (m *dtSyncTable) ParseDefaultVal(v string) (lua.LValue, error) {
 	t := newSyncTable(m.detail.name)
 	r, err := JsonToTable(v)
 	if err == nil {
-		t.RawSetString(SyncTableFieldProps, r)
+		fillSyncTable(t, r)
 	}
 	return t, err
 }
@@ -1310,7 +1328,7 @@ func (m *dtSyncTable) ParseFromLua(v lua.LValue) interface{} {
 	if m.IsSameType(v) == false {
 		v = m.Default()
 	}
-	return TableToMap(luaL.GetField(v, SyncTableFieldProps).(*lua.LTable))
+	return TableToMap(syncTableToPlain(v.(*lua.LTable)))
 }
 
 func (m *dtSyncTable) ParseRawFromLua(v lua.LValue) interface{} {
@@ -1320,13 +1338,9 @@ func (m *dtSyncTable) ParseRawFromLua(v lua.LValue) interface{} {
 func (m *dtSyncTable) ParseToLua(v interface{}) lua.LValue {
 	switch val := v.(type) {
 	case map[string]interface{}:
-		r := MapToTable(val)
-		var t *lua.LTable
-		t = newSyncTable(m.detail.name)
-		t.RawSetString(SyncTableFieldProps, r)
-		if m.IsSameType(t) {
-			return t
-		}
+		t := newSyncTable(m.detail.name)
+		fillSyncTable(t, MapToTable(val))
+		return t
 	}
 	log.Warnf("value[%v] type[%+v] not match type %s, set to default[%+v]", v, reflect.TypeOf(v).Name(), m.Type(), m.Default())
 	return m.Default()
@@ -1336,18 +1350,9 @@ func (m *dtSyncTable) AssignToSyncTable(t *lua.LTable, v lua.LValue) error {
 	if v.Type() != lua.LTTable {
 		return fmt.Errorf("%s cannot assign to %s", v.Type().String(), m.Type())
 	}
-	newVal := v.(*lua.LTable)
-	props := luaL.GetField(v, SyncTableFieldProps)
-	var newPropTable *lua.LTable
-	if props.Type() == lua.LTTable {
-		newPropTable = props.(*lua.LTable)
-	} else {
-		newPropTable = luaL.NewTable()
-		for ck, cv := newVal.Next(lua.LNil); ck != lua.LNil; ck, cv = newVal.Next(ck) {
-			luaL.RawSet(newPropTable, ck, cv)
-		}
-	}
-	t.RawSetString(SyncTableFieldProps, newPropTable)
+	//深拷贝, 避免两个属性共用同一份数据
+	fillSyncTable(t, syncTableToPlain(v.(*lua.LTable)))
+	newPropTable := t.RawGetString(SyncTableFieldProps)
 	if ownerId, ok := luaL.GetField(t, SyncTableFieldOwner).(lua.LNumber); ok {
 		if ent := GetEntityManager().GetEntityById(EntityIdType(ownerId)); ent != nil {
 			if propName, ok := luaL.GetField(t, SyncTableFieldName).(lua.LString); ok {
@@ -80,12 +80,12 @@ func (m *dataTypes) newDataType(typeName string, name string) (dataType, error)
 		dt = &dtStruct{detail: detail}
 	case dataTypeNameMailBox:
 		dt = &dtMailBox{detail: detail}
-	//case dataTypeNameSyncTable:
-	//	if gSvrType == STRobot {
-	//		dt = &dtTable{detail: detail} //客户端按table处理即可
-	//	} else {
-	//		dt = &dtSyncTable{detail: detail}
-	//	}
+	case dataTypeNameSyncTable:
+		if gSvrType == STRobot {
+			dt = &dtTable{detail: detail} //客户端按table处理即可
+		} else {
+			dt = &dtSyncTable{detail: detail}
+		}
 	default:
 		return nil, fmt.Errorf("not support type[%s]", typeName)
 	}
@@ -29,6 +29,13 @@ func Init(st ServerType) error {
 			return err
 		}
 	}
+	if st == STGate {
+		//gate只加载def用于typed编码、def版本校验与rpc限流, 不运行脚本
+		luaL = lua.NewState()
+		if err = initEntityDefs(); err != nil {
+			return err
+		}
+	}
 	if err = initEtcd(); err != nil {
 		return err
 	}
@@ -48,6 +55,9 @@ func Init(st ServerType) error {
 		if err = initEntityManager(); err != nil {
 			return err
 		}
+		if err = initAoiManager(); err != nil {
+			return err
+		}
 	}
 	if st == STRobot {
 		if err = initRobotManager(); err != nil {
@@ -76,6 +86,9 @@ func Close() {
 	if luaL != nil {
 		luaL.Close()
 	}
+	if locationPub != nil {
+		locationPub.close()
+	}
 	if etcdMgr != nil {
 		etcdMgr.close()
 	}
@@ -117,6 +130,9 @@ func Tick() {
 		luaCmdMgr.doCommands()
 	}
 	GetTimer().Tick()
+	if entityMgr != nil {
+		entityMgr.flushSyncProps()
+	}
 }
 
 var lastCheckStopTime time.Time
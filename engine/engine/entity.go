@@ -8,6 +8,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"rpg/engine/message"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -20,6 +21,15 @@ const (
 	EntityDestroyed                      //entity销毁完成
 )
 
+// clientTarget 调用客户端方法时的目标客户端
+type clientTarget int
+
+const (
+	clientTargetOwn    clientTarget = iota //自己的客户端
+	clientTargetOthers                     //aoi内除自己外的客户端
+	clientTargetAll                        //aoi内包括自己的所有客户端
+)
+
 type EntityClient struct {
 	mailbox ClientMailBox
 	primary bool
@@ -30,21 +40,44 @@ func (m *EntityClient) MailBox() ClientMailBox {
 }
 
 type entity struct {
-	entityId             EntityIdType     //id
-	entityName           string           //名称
-	luaEntity            *lua.LTable      //脚本层entity
-	propsTable           *lua.LTable      //属性表
-	clientTable          *lua.LTable      //客户端rpc函数信息
-	def                  *entityDef       //def定义
-	client               *EntityClient    //客户端连接信息
-	destroyTimerId       int64            //延迟销毁定时器
-	destroyingStatusTime int64            //进入销毁中状态的时间
-	saveTimerId          int64            //自动存盘定时器
-	status               EntityStatus     //entity状态
-	stubLeaseResult      *etcdLeaseResult //stub在etcd的租约
-	lastHeartBeatTime    time.Time        //上次心跳时间
-	heartbeatTimerId     int64            //心跳定时器
-	activeTimerIds       map[int64]bool   //已添加的定时器id
+	entityId             EntityIdType                 //id
+	entityName           string                       //名称
+	luaEntity            *lua.LTable                  //脚本层entity
+	propsTable           *lua.LTable                  //属性表
+	clientTable          *lua.LTable                  //客户端rpc函数信息
+	def                  *entityDef                   //def定义
+	client               *EntityClient                //客户端连接信息
+	destroyTimerId       int64                        //延迟销毁定时器
+	destroyingStatusTime int64                        //进入销毁中状态的时间
+	saveTimerId          int64                        //自动存盘定时器
+	status               EntityStatus                 //entity状态
+	stubLeaseResult      *etcdLeaseResult             //stub在etcd的租约
+	shardIndex           int                          //分片stub的分片序号, -1表示不分片
+	shardLeaseResult     *etcdLeaseResult             //分片stub占用分片的租约
+	lastHeartBeatTime    time.Time                    //上次心跳时间
+	heartbeatTimerId     int64                        //心跳定时器
+	activeTimerIds       map[int64]bool               //已添加的定时器id
+	scriptTimerIds       map[int64]bool               //脚本层添加的定时器id
+	dirtySyncProps       map[string]bool              //待同步给客户端的属性
+	syncTableDeltas      map[string][]*syncTableDelta //待同步给客户端的sync_table增量变化
+	syncTableVersions    map[string]uint32            //sync_table属性的版本号, 每次变化加1
+	persistDirty         map[string]bool              //待存盘的字段, 属性名或"属性名.key"
+	pendingSave          map[string]bool              //已生成存盘信息但db尚未确认写入的字段
+	persistProxies       map[string]*lua.LTable       //table类存盘属性交给脚本层的代理
+	pendingFull          bool                         //db尚未确认写入的存盘信息中是否有全量存盘
+	saveSeq              uint64                       //最近一次生成的存盘信息序号
+	lastFullSaveTime     time.Time                    //上次全量存盘时间
+	obsoleteFields       map[string]bool              //数据迁移后需要从存盘数据中删除的旧字段, db确认写入后清除
+	migrating            bool                         //是否正在迁移到其他game
+	bufferedMessages     []*BufferedMessage           //迁移中收到的消息, 迁移完成后转发, 失败则本地处理
+	deferredTimers       [][]interface{}              //迁移中触发的脚本定时器参数, 迁移失败后补发
+}
+
+// syncTableDelta sync_table的一次增量变化
+type syncTableDelta struct {
+	version uint32        //变化后的版本号
+	path    []interface{} //从属性根节点到修改位置的key路径
+	value   interface{}   //新值, 删除时为LuaTableValueNilField
 }
 
 func NewEntity(entityId EntityIdType, entityName string) (*entity, error) {
@@ -60,6 +93,15 @@ func NewEntity(entityId EntityIdType, entityName string) (*entity, error) {
 
 func (e *entity) init() error {
 	e.activeTimerIds = make(map[int64]bool)
+	e.scriptTimerIds = make(map[int64]bool)
+	e.dirtySyncProps = make(map[string]bool)
+	e.syncTableDeltas = make(map[string][]*syncTableDelta)
+	e.syncTableVersions = make(map[string]uint32)
+	e.persistDirty = make(map[string]bool)
+	e.pendingSave = make(map[string]bool)
+	e.persistProxies = make(map[string]*lua.LTable)
+	e.obsoleteFields = make(map[string]bool)
+	e.shardIndex = -1
 	e.luaEntity = luaL.NewTable()
 	e.luaEntity.RawSetString(entityFieldId, EntityIdToLua(e.entityId))
 	luaL.SetMetatable(e.luaEntity, GetEntityManager().genMetaTable(e.entityName))
@@ -97,6 +139,13 @@ func (e *entity) addEntityTimer(d time.Duration, repeat time.Duration, cb func(.
 	return timerId
 }
 
+// addScriptTimer 添加脚本层定时器, 迁移时会随entity一起迁移
+func (e *entity) addScriptTimer(d time.Duration, repeat time.Duration, params ...interface{}) int64 {
+	timerId := e.addEntityTimer(d, repeat, entityScriptTimerCallback, params...)
+	e.scriptTimerIds[timerId] = true
+	return timerId
+}
+
 func (e *entity) cancelEntityTimer(timerId int64) {
 	GetTimer().Cancel(timerId)
 	e.removeActiveTimerId(timerId)
@@ -104,6 +153,7 @@ func (e *entity) cancelEntityTimer(timerId int64) {
 
 func (e *entity) removeActiveTimerId(timerId int64) {
 	delete(e.activeTimerIds, timerId)
+	delete(e.scriptTimerIds, timerId)
 }
 
 func (e *entity) cancelAllTimers() {
@@ -111,6 +161,7 @@ func (e *entity) cancelAllTimers() {
 		GetTimer().Cancel(timerId)
 	}
 	e.activeTimerIds = make(map[int64]bool)
+	e.scriptTimerIds = make(map[int64]bool)
 
 	e.saveTimerId = 0
 	e.destroyTimerId = 0
@@ -132,6 +183,23 @@ func (e *entity) registerSelf() error {
 		if entryEntityName == e.def.entityName {
 			val[EtcdStubValueEntry] = e.entityName
 		}
+		if e.def.shards > 0 && e.shardIndex < 0 {
+			return fmt.Errorf("%s is sharded stub, should be created by engine", e.String())
+		}
+		if e.shardIndex >= 0 {
+			//先在etcd事务中占用分片, 多个进程同时创建同一分片时只有一个成功, 已被其他entity占用时创建失败
+			shardKV := NewEtcdKV(GetEtcdStubShardKey(e.entityName, e.shardIndex), EtcdValue{
+				EtcdValueServer:   ServiceName(),
+				EtcdValueEntityId: e.entityId,
+			})
+			if r, err := GetEtcd().Claim(ctx, EtcdStubLeaseTTL, shardKV); err != nil {
+				log.Errorf("register %s shard[%d] to etcd error: %s", e.String(), e.shardIndex, err.Error())
+				return err
+			} else {
+				e.shardLeaseResult = r
+			}
+			val[EtcdValueShard] = e.shardIndex
+		}
 		if r, err := GetEtcd().Register(ctx, EtcdStubLeaseTTL, NewEtcdKV(GetEtcdStubKey(e.entityId), val)); err != nil {
 			log.Errorf("register stub to etcd error: %s", err.Error())
 			return err
@@ -149,10 +217,19 @@ func (e *entity) registerSelf() error {
 			log.Errorf("put %s to redis error: %s", e.String(), err.Error())
 			return err
 		}
+		e.publishLocation(ServiceName())
 	}
 	return nil
 }
 
+// publishLocation 通知其他进程entity位置变化, server为空表示entity已移除, 由发布队列异步发送
+func (e *entity) publishLocation(server string) {
+	info, _ := json.Marshal(EtcdValue{EtcdValueEntityId: e.entityId, EtcdValueServer: server})
+	if !getLocationPublisher().push(info) {
+		log.Warnf("publish %s location queue full, dropped", e.String())
+	}
+}
+
 func (e *entity) removeRegisterInfo() {
 	//移除entity信息
 	{
@@ -161,6 +238,19 @@ func (e *entity) removeRegisterInfo() {
 		if err := GetRedisMgr().Del(ctx, GetRedisEntityKey(e.entityId)); err != nil {
 			log.Errorf("remove %s from redis error: %s", e.String(), err.Error())
 		}
+		if e.def.volatile.isStub || e.def.volatile.router {
+			e.publishLocation("")
+		}
+	}
+	//释放占用的分片
+	if e.shardLeaseResult != nil {
+		e.shardLeaseResult.Close()
+		e.shardLeaseResult = nil
+		ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+		defer cancel()
+		if err := GetEtcd().Delete(ctx, GetEtcdStubShardKey(e.entityName, e.shardIndex)); err != nil {
+			log.Errorf("remove %s shard[%d] from etcd error: %s", e.String(), e.shardIndex, err.Error())
+		}
 	}
 	//移除stub信息
 	if e.def.volatile.isStub {
@@ -202,6 +292,7 @@ func (e *entity) Destroy(isSaveDB bool, destroyImmediately bool) {
 	if e.status <= EntityReady {
 		_ = CallLuaMethodByName(e.luaEntity, onEntityDestroy, 0, e.luaEntity)
 		e.cancelAllTimers()
+		GetAoiManager().Leave(e)
 	}
 
 	//初始化失败了, 直接销毁掉
@@ -255,99 +346,405 @@ func (e *entity) GetEntityId() EntityIdType {
 	return e.entityId
 }
 
-// onSyncPropChanged 需要同步给客户端的属性变化
-func (e *entity) onSyncPropChanged(propName string, newVal lua.LValue, dt dataType) {
-	//if e.client == nil {
-	//	return
-	//}
-	//propInfo := e.def.prop(propName)
-	//if propInfo == nil {
-	//	return
-	//}
-	//if !propInfo.config.IsSyncProp() {
-	//	return
-	//}
-	//buf := map[string]interface{}{
-	//	ClientMsgDataFieldType:     ClientMsgTypePropSync,
-	//	ClientMsgDataFieldEntityID: e.entityId,
-	//	ClientMsgDataFieldArgs:     []interface{}{propName, dt.ParseRawFromLua(newVal)},
-	//}
-	//if data, err := genEntityRpcMessage(uint8(ServerMessageTypeEntityRpc), buf, e.client.mailbox.ClientId); err == nil {
-	//	e.client.mailbox.Send(data)
-	//	log.Tracef("%s prop[%s] changed, new: %v", e.String(), propName, newVal)
-	//} else {
-	//	log.Errorf("%s sync prop[%s] error: %s", e.String(), propName, err.Error())
-	//}
+// onSyncPropChanged 需要同步给客户端的属性变化, 先记录下来, 每帧统一同步
+func (e *entity) onSyncPropChanged(propName string, _ lua.LValue, _ dataType) {
+	if e.client == nil && !GetAoiManager().IsInAoi(e.entityId) {
+		return
+	}
+	propInfo := e.def.prop(propName)
+	if propInfo == nil || !propInfo.config.IsSyncProp() {
+		return
+	}
+	if propInfo.dt.Name() == dataTypeNameSyncTable {
+		e.syncTableVersions[propName]++
+	}
+	e.dirtySyncProps[propName] = true
+	GetEntityManager().addSyncDirtyEntity(e)
 }
 
-// onSyncTableUpdated SyncOnUpdate标记的table变化
-func (e *entity) onSyncTableUpdated(propName string, key lua.LValue, val lua.LValue) {
-	//if e.client == nil {
-	//	return
-	//}
-	//buf := map[string]interface{}{
-	//	ClientMsgDataFieldType:     ClientMsgTypePropSyncUpdate,
-	//	ClientMsgDataFieldEntityID: e.entityId,
-	//}
-	//switch val.Type() {
-	//case lua.LTNil:
-	//	buf[ClientMsgDataFieldArgs] = []interface{}{propName, key, LuaTableValueNilField}
-	//case lua.LTTable:
-	//	buf[ClientMsgDataFieldArgs] = []interface{}{propName, key, TableToMap(val.(*lua.LTable))}
-	//default:
-	//	buf[ClientMsgDataFieldArgs] = []interface{}{propName, key, val}
-	//}
-	//if data, err := genEntityRpcMessage(uint8(ServerMessageTypeEntityRpc), buf, e.client.mailbox.ClientId); err == nil {
-	//	e.client.mailbox.Send(data)
-	//	log.Tracef("%s prop[%s] key[%s] changed to [%v]", e.String(), propName, key, val)
-	//} else {
-	//	log.Errorf("%s sync prop[%s] error: %s", e.String(), propName, err.Error())
-	//}
+// flushSyncProps 将本帧变化的属性合并为一个消息同步给客户端
+func (e *entity) flushSyncProps() {
+	if len(e.dirtySyncProps) == 0 && len(e.syncTableDeltas) == 0 {
+		return
+	}
+	dirty := e.dirtySyncProps
+	deltas := e.syncTableDeltas
+	e.dirtySyncProps = make(map[string]bool)
+	e.syncTableDeltas = make(map[string][]*syncTableDelta)
+
+	//普通属性参数格式: [propName1, value1, propName2, value2, ...]
+	ownArgs := make([]interface{}, 0)
+	otherArgs := make([]interface{}, 0)
+	//sync_table参数格式: [propName1, version1, path1, value1, ...], path为空表示整体替换
+	ownUpdates := make([]interface{}, 0)
+	otherUpdates := make([]interface{}, 0)
+	for propName := range dirty {
+		propInfo := e.def.prop(propName)
+		if propInfo == nil {
+			continue
+		}
+		val := propInfo.dt.ParseRawFromLua(luaL.GetField(e.propsTable, propName))
+		if propInfo.dt.Name() == dataTypeNameSyncTable {
+			update := []interface{}{propName, e.syncTableVersions[propName], []interface{}{}, val}
+			if propInfo.config.IsOwnClientProp() {
+				ownUpdates = append(ownUpdates, update...)
+			}
+			if propInfo.config.IsOtherClientsProp() {
+				otherUpdates = append(otherUpdates, update...)
+			}
+			continue
+		}
+		if propInfo.config.IsOwnClientProp() {
+			ownArgs = append(ownArgs, propName, val)
+		}
+		if propInfo.config.IsOtherClientsProp() {
+			otherArgs = append(otherArgs, propName, val)
+		}
+	}
+	for propName, list := range deltas {
+		//本帧整体替换过的属性已经全量下发
+		if dirty[propName] {
+			continue
+		}
+		propInfo := e.def.prop(propName)
+		if propInfo == nil {
+			continue
+		}
+		for _, delta := range list {
+			update := []interface{}{propName, delta.version, delta.path, delta.value}
+			if propInfo.config.IsOwnClientProp() {
+				ownUpdates = append(ownUpdates, update...)
+			}
+			if propInfo.config.IsOtherClientsProp() {
+				otherUpdates = append(otherUpdates, update...)
+			}
+		}
+	}
+	own := e.clientMailBoxes(clientTargetOwn)
+	others := e.clientMailBoxes(clientTargetOthers)
+	e.sendSyncProps(ClientMsgTypePropSync, ownArgs, own)
+	e.sendSyncProps(ClientMsgTypePropSync, otherArgs, others)
+	e.sendSyncProps(ClientMsgTypePropSyncUpdate, ownUpdates, own)
+	e.sendSyncProps(ClientMsgTypePropSyncUpdate, otherUpdates, others)
+}
+
+func (e *entity) sendSyncProps(msgType int, args []interface{}, mbs []*ClientMailBox) {
+	if len(args) == 0 || len(mbs) == 0 {
+		return
+	}
+	buf := map[string]interface{}{
+		ClientMsgDataFieldType:     msgType,
+		ClientMsgDataFieldEntityID: e.entityId,
+		ClientMsgDataFieldArgs:     args,
+	}
+	if err := sendToClients(buf, mbs); err == nil {
+		log.Tracef("%s sync props to %d client(s), type: %d, args: %+v", e.String(), len(mbs), msgType, args)
+	} else {
+		log.Errorf("%s sync props error: %s", e.String(), err.Error())
+	}
+}
+
+/*
+ResyncProp 客户端检测到sync_table版本不连续时请求全量同步, 只下发给请求的客户端, 版本号不变
+
+mb: 请求的客户端, 必须是entity自己的客户端或者aoi内其他entity的客户端
+*/
+func (e *entity) ResyncProp(propName string, mb *ClientMailBox) error {
+	propInfo := e.def.prop(propName)
+	if propInfo == nil {
+		return fmt.Errorf("%s has no prop[%s]", e.String(), propName)
+	}
+	own := e.client != nil && e.client.mailbox.Equal(mb)
+	observer := false
+	if !own {
+		for _, other := range e.clientMailBoxes(clientTargetOthers) {
+			if other.Equal(mb) {
+				observer = true
+				break
+			}
+		}
+	}
+	if !(own && propInfo.config.IsOwnClientProp()) && !(observer && propInfo.config.IsOtherClientsProp()) {
+		return fmt.Errorf("client %s cannot resync prop[%s] of %s", mb.String(), propName, e.String())
+	}
+	val := propInfo.dt.ParseRawFromLua(luaL.GetField(e.propsTable, propName))
+	if propInfo.dt.Name() == dataTypeNameSyncTable {
+		update := []interface{}{propName, e.syncTableVersions[propName], []interface{}{}, val}
+		e.sendSyncProps(ClientMsgTypePropSyncUpdate, update, []*ClientMailBox{mb})
+	} else {
+		e.sendSyncProps(ClientMsgTypePropSync, []interface{}{propName, val}, []*ClientMailBox{mb})
+	}
+	return nil
+}
+
+// clientMailBoxes 获取目标客户端的连接信息
+func (e *entity) clientMailBoxes(target clientTarget) []*ClientMailBox {
+	r := make([]*ClientMailBox, 0)
+	if target != clientTargetOthers && e.client != nil {
+		r = append(r, &e.client.mailbox)
+	}
+	if target != clientTargetOwn {
+		for _, id := range GetAoiManager().Views(e.entityId) {
+			if other := GetEntityManager().GetEntityById(id); other != nil && other.client != nil {
+				r = append(r, &other.client.mailbox)
+			}
+		}
+	}
+	return r
+}
+
+// onEnterAoi other进入视野
+func (e *entity) onEnterAoi(other *entity) {
+	if e.client != nil {
+		if err := other.sendCreateClientEntity(&e.client.mailbox, false); err != nil {
+			log.Errorf("%s create client entity %s error: %s", e.String(), other.String(), err.Error())
+		}
+	}
+	_ = CallLuaMethodByName(e.luaEntity, onEntityEnterAoi, 0, e.luaEntity, other.luaEntity)
+}
+
+// onLeaveAoi other离开视野
+func (e *entity) onLeaveAoi(other *entity) {
+	if e.client != nil {
+		other.sendDestroyClientEntity(&e.client.mailbox)
+	}
+	_ = CallLuaMethodByName(e.luaEntity, onEntityLeaveAoi, 0, e.luaEntity, other.luaEntity)
+}
+
+// onSyncTableUpdated sync_table内部字段变化, 记录增量每帧统一同步
+func (e *entity) onSyncTableUpdated(propName string, path []lua.LValue, val lua.LValue) {
+	if e.client == nil && !GetAoiManager().IsInAoi(e.entityId) {
+		return
+	}
+	e.syncTableVersions[propName]++
+	delta := &syncTableDelta{version: e.syncTableVersions[propName], path: make([]interface{}, 0, len(path))}
+	for _, key := range path {
+		delta.path = append(delta.path, key)
+	}
+	switch val.Type() {
+	case lua.LTNil:
+		delta.value = LuaTableValueNilField
+	case lua.LTTable:
+		delta.value = TableToMap(syncTableToPlain(val.(*lua.LTable)))
+	default:
+		delta.value = val
+	}
+	e.syncTableDeltas[propName] = append(e.syncTableDeltas[propName], delta)
+	GetEntityManager().addSyncDirtyEntity(e)
 }
 
 func (e *entity) String() string {
 	return "entity[" + e.entityName + ":" + strconv.FormatInt(int64(e.entityId), 10) + "]"
 }
 
+// onPersistPropChanged 存盘属性被整体赋值
+func (e *entity) onPersistPropChanged(propName string) {
+	if !e.def.volatile.persistent {
+		return
+	}
+	if propInfo := e.def.prop(propName); propInfo != nil && propInfo.config.Persistent {
+		e.persistDirty[propName] = true
+	}
+}
+
+// isPersistTableProp 是否是需要通过代理感知内部修改的存盘属性
+func (e *entity) isPersistTableProp(propName string) bool {
+	if !e.def.volatile.persistent {
+		return false
+	}
+	propInfo := e.def.prop(propName)
+	return propInfo != nil && propInfo.config.Persistent && isMutableDataType(propInfo.dt)
+}
+
+// persistTableProxy table类存盘属性交给脚本层时包装为代理, 属性被整体赋值前复用同一个代理
+func (e *entity) persistTableProxy(propName string, value lua.LValue) lua.LValue {
+	t, ok := value.(*lua.LTable)
+	if !ok || !e.isPersistTableProp(propName) {
+		return value
+	}
+	if proxy, find := e.persistProxies[propName]; find && persistTableTarget(proxy) == t {
+		return proxy
+	}
+	proxy := newPersistTable(t, e.entityId, propName, lua.LNil)
+	e.persistProxies[propName] = proxy
+	return proxy
+}
+
+// onPersistTableUpdated 存盘的sync_table或table类属性第一层key发生变化, 只更新该key
+func (e *entity) onPersistTableUpdated(propName string, key lua.LValue) {
+	if !e.def.volatile.persistent {
+		return
+	}
+	propInfo := e.def.prop(propName)
+	if propInfo == nil || !propInfo.config.Persistent {
+		return
+	}
+	field := ""
+	switch k := key.(type) {
+	case lua.LNumber:
+		field = numberToMapKey(k)
+	case lua.LString:
+		field = string(k)
+	}
+	//mongo字段路径不支持的key直接整体存盘
+	if field == "" || strings.Contains(field, ".") || strings.HasPrefix(field, "$") {
+		e.persistDirty[propName] = true
+	} else {
+		e.persistDirty[propName+"."+field] = true
+	}
+}
+
+/*
+OnSaveResult db返回存盘结果, 失败或超时时err不为nil
+
+新的存盘信息总是包含之前未确认的字段, 只有最近一次生成的存盘信息写入成功才清除未确认的字段; 写入失败时字段重新待存盘, 并强制下次全量存盘
+*/
+func (e *entity) OnSaveResult(info *EntitySaveInfo, err error) {
+	if err != nil {
+		log.Warnf("%s save[%d] failed: %s, save again next time", e.String(), info.Seq, err.Error())
+		for field := range e.pendingSave {
+			e.persistDirty[field] = true
+		}
+		e.pendingFull = true
+		return
+	}
+	if info.Full {
+		e.lastFullSaveTime = time.Now()
+	}
+	if info.Seq != e.saveSeq {
+		return
+	}
+	e.pendingSave = make(map[string]bool)
+	e.pendingFull = false
+	e.obsoleteFields = make(map[string]bool)
+	if info.NeedResponse {
+		e.SavedOnDestroyCallback()
+	}
+}
+
+// isMutableDataType table类属性可以直接修改内部字段, 无法通过entity的__newindex感知, 需通过代理感知
+func isMutableDataType(dt dataType) bool {
+	switch dt.Name() {
+	case dataTypeNameTable, dataTypeNameMap, dataTypeNameArray, dataTypeNameStruct:
+		return true
+	}
+	return false
+}
+
+func (e *entity) persistValue(name string, prop propertyInfo) interface{} {
+	v := luaL.GetField(e.propsTable, name)
+	if v != lua.LNil {
+		return prop.dt.ParseFromLua(v)
+	}
+	return prop.dt.Default()
+}
+
+/*
+genSaveInfo 生成存盘信息, 只包含变化的字段, 达到全量存盘间隔时存盘所有字段
+
+needResponse: 是否是销毁时的存盘, db确认写入后销毁entity, 为false且没有变化时返回nil
+*/
 func (e *entity) genSaveInfo(needResponse bool) *EntitySaveInfo {
 	if e.def.volatile.persistent == false {
 		return nil
 	}
-	r := make(map[string]interface{})
+	//未确认的全量存盘信息可能被本次替换, 本次也需全量存盘
+	full := e.pendingFull || e.lastFullSaveTime.IsZero() || time.Since(e.lastFullSaveTime) >= time.Duration(cfg.FullSaveInterval)*time.Minute
+	//之前生成但db尚未确认的存盘信息可能被替换或写入失败, 需要包含其字段
+	fields := make(map[string]bool)
+	for field := range e.pendingSave {
+		fields[field] = true
+	}
+	for field := range e.persistDirty {
+		fields[field] = true
+	}
+
+	set := bson.M{}
+	unset := bson.M{}
 	for name, prop := range e.def.properties {
 		if !prop.config.Persistent {
 			continue
 		}
-		v := luaL.GetField(e.luaEntity, name)
-		if v != lua.LNil {
-			r[name] = prop.dt.ParseFromLua(v)
+		if full || fields[name] {
+			set[name] = e.persistValue(name, prop)
+		}
+	}
+	for field := range fields {
+		propName, key, ok := strings.Cut(field, ".")
+		if !ok {
+			continue
+		}
+		if _, find := set[propName]; find {
+			continue
+		}
+		prop := e.def.prop(propName)
+		t, isTable := luaL.GetField(e.propsTable, propName).(*lua.LTable)
+		if prop == nil || !isTable {
+			continue
+		}
+		if isSyncTableNode(t) {
+			t = t.RawGetString(SyncTableFieldProps).(*lua.LTable)
+		}
+		var luaKey lua.LValue = lua.LString(key)
+		if num, err := mapKeyToNumber(key); err == nil {
+			luaKey = num
+		}
+		if v := t.RawGet(luaKey); v == lua.LNil {
+			unset[field] = ""
 		} else {
-			r[name] = prop.dt.Default()
+			set[field] = persistFieldValue(prop.dt, key, v)
 		}
 	}
-	r[MongoFieldId] = e.entityId
-	r[MongoFieldName] = e.entityName
-	_, data, err := bson.MarshalValue(r)
+	for field := range e.obsoleteFields {
+		//迁移后重新写入的字段不能同时删除
+		if _, ok := set[field]; !ok {
+			unset[field] = ""
+		}
+	}
+	if len(set) == 0 && len(unset) == 0 && !needResponse {
+		return nil
+	}
+
+	set[MongoFieldId] = e.entityId
+	set[MongoFieldName] = e.entityName
+	set[MongoFieldVersion] = e.def.volatile.version
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	_, data, err := bson.MarshalValue(update)
 	if err != nil {
 		log.Warnf("%s genSaveInfo marshal error: %s", e.String(), err.Error())
 		return nil
 	}
+	e.pendingSave = fields
+	e.pendingFull = full
+	e.persistDirty = make(map[string]bool)
+	e.saveSeq++
+	log.Debugf("%s genSaveInfo[%d], full: %v, set: %d field(s), unset: %d field(s)", e.String(), e.saveSeq, full, len(set)-3, len(unset))
 
 	return &EntitySaveInfo{
 		EntityId:     e.entityId,
 		Data:         data,
 		NeedResponse: needResponse,
+		Full:         full,
+		Seq:          e.saveSeq,
 	}
 }
 
 func (e *entity) loadData(data map[string]interface{}) {
+	oldVersion := dataVersion(data)
+	migrated := oldVersion < e.def.volatile.version
+	if migrated {
+		data = e.migrateData(oldVersion, data)
+	}
 	for name, value := range data {
-		if isEntityReserveProp(name) || name == MongoPrimaryId {
+		if isEntityReserveProp(name) || name == MongoPrimaryId || name == MongoFieldVersion {
 			continue
 		}
 		propInfo := e.def.prop(name)
 		if propInfo == nil {
-			log.Debugf("%s def has no prop[%s]", e.String(), name)
+			log.Warnf("%s def has no prop[%s], value[%v] dropped, use RenameFrom or %s to migrate it", e.String(), name, value, onEntityMigrate)
 			continue
 		}
 		val := propInfo.dt.ParseToLua(value)
@@ -356,6 +753,12 @@ func (e *entity) loadData(data map[string]interface{}) {
 		}
 		e.propsTable.RawSetString(name, val)
 	}
+	if migrated {
+		//迁移后的数据下次存盘时全量写入
+		e.lastFullSaveTime = time.Time{}
+	} else {
+		e.lastFullSaveTime = time.Now()
+	}
 }
 
 func (e *entity) SaveToDB() {
@@ -383,14 +786,8 @@ func (e *entity) CheckDefServerMethod(method string, args []lua.LValue, fromClie
 		if fromClient && mi.exposed == false {
 			return method, fmt.Errorf("client cannot call method[%s] for %s, add <Exposed/> after method in def file", method, e.String())
 		}
-		argLen := len(args)
-		for i := 0; i < len(mi.args); i++ {
-			if argLen <= i {
-				break
-			}
-			if mi.args[i].dt.IsSameType(args[i]) == false {
-				return method, fmt.Errorf("function[%s] arg[%d] expect %s but got %+v(%s)", method, i+1, mi.args[i].dt.Type(), args[i], args[i].Type())
-			}
+		if err := e.def.checkServerMethodArgs(mi, args); err != nil {
+			return method, err
 		}
 	}
 	return mi.methodName, nil
@@ -404,7 +801,7 @@ func (e *entity) CallDefServerMethod(method string, args []lua.LValue, fromClien
 	}
 
 	if GetConfig().PrintRpcLog {
-		log.WithField("type", "RPC").Debugf("call %s server method: %s, args: %+v, is from client: %+v", e.String(), name, args, fromClient)
+		MainLogger().WithField("type", "RPC").Debugf("call %s server method: %s, args: %+v, is from client: %+v", e.String(), name, args, fromClient)
 	}
 	params := append([]lua.LValue{e.luaEntity}, args...)
 	if err = CallLuaMethodByName(e.luaEntity, name, 0, params...); err != nil {
@@ -413,6 +810,45 @@ func (e *entity) CallDefServerMethod(method string, args []lua.LValue, fromClien
 	return nil
 }
 
+/*
+CallDefServerMethodWithReturns 调用def中声明了<Returns>的函数, method传入原始函数名, 返回值按def中声明的类型检查
+
+返回值: 与def声明的返回值一一对应, 已转换为可序列化的数据
+*/
+func (e *entity) CallDefServerMethodWithReturns(method string, args []lua.LValue) ([]interface{}, error) {
+	name, err := e.CheckDefServerMethod(method, args, false)
+	if err != nil {
+		return nil, err
+	}
+	mi := e.def.getServerMethod(name, true)
+	if !mi.hasReturns {
+		return nil, fmt.Errorf("method[%s] of %s has no <Returns> in def", name, e.String())
+	}
+
+	if GetConfig().PrintRpcLog {
+		MainLogger().WithField("type", "RPC").Debugf("call %s server method: %s with returns, args: %+v", e.String(), name, args)
+	}
+	top := luaL.GetTop()
+	defer luaL.SetTop(top)
+	params := append([]lua.LValue{e.luaEntity}, args...)
+	if err = CallLuaMethodByName(e.luaEntity, name, len(mi.returns), params...); err != nil {
+		return nil, err
+	}
+	r := make([]interface{}, 0, len(mi.returns))
+	for i, ret := range mi.returns {
+		v := luaL.Get(top + 1 + i)
+		if v == lua.LNil {
+			r = append(r, LuaTableValueNilField)
+			continue
+		}
+		if !ret.dt.IsSameType(v) {
+			return nil, fmt.Errorf("method[%s] return value[%d] expect %s but got %+v(%s)", name, i+1, ret.dt.Type(), v, v.Type())
+		}
+		r = append(r, ret.dt.ParseFromLua(v))
+	}
+	return r, nil
+}
+
 func (e *entity) GetClient() *EntityClient {
 	return e.client
 }
@@ -444,13 +880,7 @@ func (e *entity) setClient(c *ClientMailBox, primary bool) error {
 			return nil
 		}
 		//通知gate解绑entity与客户端连接
-		{
-			header := GenMessageHeader(ServerMessageTypeChangeEntityClient, 0)
-			body := message.ClientBindEntity{EntityId: int64(e.entityId), ClientId: uint32(e.client.mailbox.ClientId), Unbind: true}
-			if data, err := GetProtocol().MessageWithHead(header, &body); err == nil {
-				e.client.mailbox.Send(data)
-			}
-		}
+		e.sendClientBindInfo(true)
 		if c != nil {
 			//连接被替换, 通知前个连接被顶号
 			if e.client.primary {
@@ -493,34 +923,75 @@ func (e *entity) setClient(c *ClientMailBox, primary bool) error {
 			e.destroyTimerId = 0
 		}
 		//通知gate客户端连接绑定到了entity
-		header := GenMessageHeader(ServerMessageTypeChangeEntityClient, 0)
-		body := message.ClientBindEntity{EntityId: int64(e.entityId), ClientId: uint32(e.client.mailbox.ClientId)}
-		if data, err := GetProtocol().MessageWithHead(header, &body); err == nil {
-			e.client.mailbox.Send(data)
-		}
+		e.sendClientBindInfo(false)
 		e.onGetClient()
-		if e.def.volatile.persistent == true {
-			if e.saveTimerId == 0 {
-				interval := time.Duration(cfg.SaveInterval) * time.Minute
-				e.saveTimerId = e.addEntityTimer(interval, interval, e.saveTimerCb)
-				log.Infof("add entity save timer for %s, id: %d", e.String(), e.saveTimerId)
-			}
-		}
+		e.addSaveTimer()
 	}
 	return nil
 }
 
+// resumeClient 客户端断线重连后只替换连接信息, 客户端保留了断线前的entity, 需要全量同步时重新创建
+func (e *entity) resumeClient(oldClientId ConnectIdType, c *ClientMailBox, resync bool) bool {
+	if e.client == nil || e.client.mailbox.GateName != c.GateName || e.client.mailbox.ClientId != oldClientId {
+		return false
+	}
+	e.client.mailbox = *c
+	e.lastHeartBeatTime = time.Now()
+	e.sendClientBindInfo(false)
+	if resync {
+		if err := e.initClientEntity(); err != nil {
+			log.Errorf("%s resync client entity error: %s", e.String(), err.Error())
+		}
+	}
+	log.Infof("%s resume client, clientId: %d -> %d, resync: %v", e.String(), oldClientId, c.ClientId, resync)
+	return true
+}
+
+// sendClientBindInfo 通知gate客户端连接与entity绑定或解绑
+func (e *entity) sendClientBindInfo(unbind bool) {
+	header := GenMessageHeader(ServerMessageTypeChangeEntityClient, 0)
+	body := message.ClientBindEntity{EntityId: int64(e.entityId), ClientId: uint32(e.client.mailbox.ClientId), Unbind: unbind}
+	if data, err := GetProtocol().MessageWithHead(header, &body); err == nil {
+		e.client.mailbox.Send(data)
+	}
+}
+
+func (e *entity) addSaveTimer() {
+	if e.def.volatile.persistent == true {
+		if e.saveTimerId == 0 {
+			interval := time.Duration(cfg.SaveInterval) * time.Minute
+			e.saveTimerId = e.addEntityTimer(interval, interval, e.saveTimerCb)
+			log.Infof("add entity save timer for %s, id: %d", e.String(), e.saveTimerId)
+		}
+	}
+}
+
 func (e *entity) onGetClient() {
-	luaL.SetField(e.luaEntity, "client", e.clientTable)
-	if err := e.createClientEntity(); err != nil {
+	if err := e.initClientEntity(); err != nil {
 		log.Errorf("create client entity error: %s", err.Error())
 	} else {
 		_ = CallLuaMethodByName(e.luaEntity, onEntityGetClient, 0, e.luaEntity)
 	}
 }
 
+// initClientEntity 在新绑定的客户端上创建自己及视野内的entity
+func (e *entity) initClientEntity() error {
+	luaL.SetField(e.luaEntity, entityFieldClient, e.clientTable)
+	if err := e.createClientEntity(); err != nil {
+		return err
+	}
+	for _, id := range GetAoiManager().Views(e.entityId) {
+		if other := GetEntityManager().GetEntityById(id); other != nil {
+			if err := other.sendCreateClientEntity(&e.client.mailbox, false); err != nil {
+				log.Errorf("%s create client entity %s error: %s", e.String(), other.String(), err.Error())
+			}
+		}
+	}
+	return nil
+}
+
 func (e *entity) onLoseClient() {
-	luaL.SetField(e.luaEntity, "client", lua.LNil)
+	luaL.SetField(e.luaEntity, entityFieldClient, lua.LNil)
 	_ = CallLuaMethodByName(e.luaEntity, onEntityLostClient, 0, e.luaEntity)
 }
 
@@ -528,30 +999,59 @@ func (e *entity) createClientEntity() error {
 	if e.client == nil {
 		return fmt.Errorf("%s createClientEntity but client nil", e.String())
 	}
-	//props := make(map[string]interface{})
-	//for name, prop := range e.def.properties {
-	//	if prop.config.IsSyncProp() {
-	//		val := luaL.GetField(e.propsTable, name)
-	//		props[name] = prop.dt.ParseFromLua(val)
-	//	}
-	//}
-	//args := []interface{}{e.entityName, props}
-	args := []interface{}{e.entityName}
+	return e.sendCreateClientEntity(&e.client.mailbox, true)
+}
+
+/*
+sendCreateClientEntity 通知客户端创建entity, 参数格式: [entityName, props, own, syncTableVersions]
+
+mb: 客户端连接信息
+
+own: 是否是该客户端自己的entity, 决定下发哪些属性
+*/
+func (e *entity) sendCreateClientEntity(mb *ClientMailBox, own bool) error {
+	props := make(map[string]interface{})
+	versions := make(map[string]interface{})
+	for name, prop := range e.def.properties {
+		if (own && prop.config.IsOwnClientProp()) || (!own && prop.config.IsOtherClientsProp()) {
+			val := luaL.GetField(e.propsTable, name)
+			props[name] = prop.dt.ParseRawFromLua(val)
+			if prop.dt.Name() == dataTypeNameSyncTable {
+				versions[name] = e.syncTableVersions[name]
+			}
+		}
+	}
+	//之后收到的版本号不大于快照中版本号的sync_table增量可直接忽略
+	args := []interface{}{e.entityName, props, own, versions}
 	msg := map[string]interface{}{
 		ClientMsgDataFieldType:     ClientMsgTypeCreateEntity,
 		ClientMsgDataFieldEntityID: e.entityId,
 		ClientMsgDataFieldArgs:     args,
 	}
-	if data, err := genEntityRpcMessage(uint8(ServerMessageTypeEntityRpc), msg, e.client.mailbox.ClientId); err == nil {
-		e.client.mailbox.Send(data)
-		log.Debugf("ask client create entity, entityId: %d, entityName: %s, clientId: %d, dataLen: %d",
-			e.entityId, e.entityName, e.client.mailbox.ClientId, len(data))
+	if data, err := genEntityRpcMessage(uint8(ServerMessageTypeEntityRpc), msg, mb.ClientId); err == nil {
+		mb.Send(data)
+		log.Debugf("ask client create entity, entityId: %d, entityName: %s, clientId: %d, own: %v, dataLen: %d",
+			e.entityId, e.entityName, mb.ClientId, own, len(data))
 		return nil
 	} else {
 		return err
 	}
 }
 
+// sendDestroyClientEntity 通知客户端销毁entity
+func (e *entity) sendDestroyClientEntity(mb *ClientMailBox) {
+	msg := map[string]interface{}{
+		ClientMsgDataFieldType:     ClientMsgTypeDestroyEntity,
+		ClientMsgDataFieldEntityID: e.entityId,
+		ClientMsgDataFieldArgs:     []interface{}{},
+	}
+	if data, err := genEntityRpcMessage(uint8(ServerMessageTypeEntityRpc), msg, mb.ClientId); err == nil {
+		mb.Send(data)
+	} else {
+		log.Errorf("%s destroy client entity error: %s", e.String(), err.Error())
+	}
+}
+
 func (e *entity) checkHeartbeatCb(...interface{}) {
 	heartBeatDuration := cfg.HeartBeatInterval
 	if heartBeatDuration <= 0 {
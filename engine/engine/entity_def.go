@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/beevik/etree"
 	lua "github.com/seasondi/gopher-lua"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -37,6 +38,7 @@ const (
 	defFieldVolatilePersistent = "Persistent"    //entity是否需要存盘
 	defFieldVolatileIsStub     = "IsStub"        //entity是否为stub
 	defFieldVolatileRouter     = "Router"        //entity是否能跨进程通信
+	defFieldVolatileVersion    = "Version"       //存盘数据的版本号
 	defFieldImplements         = "Implements"    //继承的其他def
 	defFieldProperties         = "Properties"    //属性列表
 	defFieldClientMethods      = "ClientMethods" //客户端rpc函数声明
@@ -52,7 +54,15 @@ const (
 	defFieldPropFlags          = "Flags"         //属性同步方式
 	defFieldPropDefault        = "Default"       //属性默认值
 	defFieldPropPersistent     = "Persistent"    //属性是否持久化
+	defFieldPropRenameFrom     = "RenameFrom"    //旧版本存盘数据中的属性名
+	defFieldPropConvertFrom    = "ConvertFrom"   //旧版本存盘数据中的属性类型
+	defFieldPropRuleVersion    = "Version"       //RenameFrom/ConvertFrom的属性, 规则生效的def版本, 低于该版本的存盘数据才会迁移
 	defFieldRpcExposed         = "Exposed"       //服务器rpc函数是否暴露给客户端
+	defFieldRpcReturns         = "Returns"       //服务器rpc函数的返回值列表
+	defFieldRpcRateLimit       = "RateLimit"     //暴露给客户端的服务器rpc函数的限流
+	defFieldRateLimitRate      = "Rate"          //限流每秒补充的令牌数
+	defFieldRateLimitBurst     = "Burst"         //限流的桶容量
+	defFieldEntityShards       = "shards"        //entities.xml中stub的分片数量
 )
 
 var currentLoadDefFile string
@@ -87,43 +97,55 @@ type volatileDef struct {
 	persistent bool //是否持久化
 	isStub     bool //是否是stub类型
 	router     bool //是否可跨进程通信
+	version    int  //存盘数据版本号, 低于该版本的数据加载时需要迁移
 }
 
 // propertyDef def文件中的属性配置信息
 type propertyDef struct {
-	Type       propType //属性类型
-	Flags      syncFlag //属性同步方式
-	Default    string   //默认值
-	Persistent bool     //是否持久化
+	Type           propType  //属性类型
+	Flags          syncFlag  //属性同步方式
+	Default        string    //默认值
+	Persistent     bool      //是否持久化
+	RenameFrom     string    //旧版本存盘数据中的属性名
+	RenameVersion  int       //改名生效的def版本
+	ConvertFrom    *propType //旧版本存盘数据中的属性类型
+	ConvertVersion int       //类型转换生效的def版本
 }
 
 type propertyInfo struct {
-	config propertyDef
-	dt     dataType
+	config    propertyDef
+	dt        dataType
+	convertDt dataType //旧版本存盘数据中的属性类型, 未配置ConvertFrom时为nil
 }
 
 type argInfo struct {
-	ty propType
-	dt dataType
+	name string //def中参数的标签名
+	ty   propType
+	dt   dataType
 }
 
 // methodDef def文件中的函数参数
 type methodDef struct {
-	methodName string    //函数名
-	exposed    bool      //是否暴露给客户端访问
-	args       []argInfo //函数参数类型列表
+	methodName string     //函数名
+	exposed    bool       //是否暴露给客户端访问
+	args       []argInfo  //函数参数类型列表
+	hasReturns bool       //是否声明了返回值, 声明后才能通过callEntityAsync调用
+	returns    []argInfo  //返回值类型列表
+	rateLimit  *RateLimit //客户端调用的限流, 未配置时为nil
 }
 
 // entityDef def文件描述信息
 type entityDef struct {
 	entityName        string
 	volatile          volatileDef
+	shards            int //分片stub的分片数量, 0表示不分片
 	properties        map[string]propertyInfo
 	clientMethods     map[string]*methodDef //mask name -> method def
 	clientMethodsName map[string]string     //origin name -> mask name
 	serverMethods     map[string]*methodDef //mask name -> method def
 	serverMethodsName map[string]string     //origin name -> mask name
 	interfaces        []string              //entity关联的所有子类
+	loadingInterfaces []string              //正在加载的interface链, 用于检查循环继承
 }
 
 // flagStrToEnum 属性的Flags字段转枚举
@@ -137,17 +159,14 @@ func flagStrToEnum(flag string) syncFlag {
 	case defFieldAllClients:
 		return allClients
 	}
-	if len(flag) != 0 {
-		log.Errorf("unknown flag: %s, support list: [OWN_CLIENT, OTHER_CLIENTS, ALL_CLIENTS]", flag)
-	}
 	return noClient
 }
 
-// readPropType 读取属性、函数参数.
+// readPropType 读取属性、函数参数, 配置错误时记录错误并返回false.
 // el: 读取的xml标签
 // propName: 属性名称或者函数名
 // rtype: 读取类型
-func readPropType(el *etree.Element, propName string, rtype readType) propType {
+func readPropType(el *etree.Element, propName string, rtype readType) (propType, bool) {
 	typeName := strings.Trim(el.Text(), "\n ")
 	lowerTypeName := strings.ToLower(typeName)
 	r := propType{name: propName, typeName: typeName}
@@ -158,43 +177,63 @@ func readPropType(el *etree.Element, propName string, rtype readType) propType {
 			r.decimal = 2 //默认保留两位小数
 		} else {
 			if decimal, err := strconv.ParseInt(Unit.Text(), 10, 64); err != nil {
-				log.Fatalf("cannot parse \"Unit\" for prop[%s], error: %s", propName, err.Error())
+				defErrorf(Unit, "cannot parse \"Unit\" for prop[%s], error: %s", propName, err.Error())
+				return r, false
 			} else if decimal >= 0 && decimal <= 8 {
 				r.decimal = int(decimal)
 			} else {
-				log.Fatalf("Unit should between [0,8], prop[%s]", propName)
+				defErrorf(Unit, "Unit should between [0,8], prop[%s]", propName)
+				return r, false
 			}
 		}
 	case dataTypeNameArray:
 		of := el.FindElement(defFieldArrayValue)
 		if of == nil {
-			log.Fatalf("can not find \"%s\" element for ARRAY, propName[%s]", defFieldArrayValue, propName)
+			defErrorf(el, "can not find \"%s\" element for ARRAY, propName[%s]", defFieldArrayValue, propName)
+			return r, false
+		}
+		ptValue, ok := readPropType(of, propName, readTypeArrayValue)
+		if !ok {
+			return r, false
 		}
-		ptValue := readPropType(of, propName, readTypeArrayValue)
 		r.valueType = &ptValue
 	case dataTypeNameMap:
 		key := el.FindElement(defFieldMapKey)
 		if key == nil {
-			log.Fatalf("can not find \"%s\" element for MAP, propName[%s]", defFieldMapKey, propName)
+			defErrorf(el, "can not find \"%s\" element for MAP, propName[%s]", defFieldMapKey, propName)
+			return r, false
 		}
 		value := el.FindElement(defFieldMapValue)
 		if value == nil {
-			log.Fatalf("can not find \"%s\" element for MAP, propName[%s]", defFieldMapValue, propName)
+			defErrorf(el, "can not find \"%s\" element for MAP, propName[%s]", defFieldMapValue, propName)
+			return r, false
+		}
+		ptKey, keyOk := readPropType(key, propName, readTypeMapKey)
+		ptValue, valueOk := readPropType(value, propName, readTypeMapValue)
+		if !keyOk || !valueOk {
+			return r, false
 		}
-		ptKey := readPropType(key, propName, readTypeMapKey)
-		ptValue := readPropType(value, propName, readTypeMapValue)
 		r.keyType = &ptKey
 		r.valueType = &ptValue
 	case dataTypeNameStruct:
 		r.props = make(map[string]propertyInfo, 0)
+		success := true
 		for _, prop := range el.ChildElements() {
 			if _, find := r.props[prop.Tag]; find {
-				log.Fatalf("duplicate field[%s] for prop[%s]", prop.Tag, propName)
+				defErrorf(prop, "duplicate field[%s] for prop[%s]", prop.Tag, propName)
+				success = false
+				continue
+			}
+			propDef, ok := readPropertyDef(prop)
+			if !ok {
+				success = false
+				continue
 			}
-			propDef := readPropertyDef(prop)
 			dt, err := dataTypeMgr.NewDataTypeFromPropDef(propDef)
 			if err != nil {
-				log.Fatalf("cannot create dataType for prop[%s], error: %s", propName, err.Error())
+				defErrorf(prop, "cannot create dataType for prop[%s], error: %s", propName, err.Error())
+				success = false
+				continue
 			}
 
 			r.props[prop.Tag] = propertyInfo{
@@ -202,28 +241,42 @@ func readPropType(el *etree.Element, propName string, rtype readType) propType {
 				dt:     dt,
 			}
 		}
+		if !success {
+			return r, false
+		}
 	case dataTypeNameSyncTable:
 		if rtype != readTypeProp {
-			log.Fatalf("read %s failed, %s only can be defined on properties", propName, typeName)
+			defErrorf(el, "read %s failed, %s only can be defined on properties", propName, typeName)
+			return r, false
 		}
 	default:
 		if alias := defMgr.GetAlias(typeName); alias != nil {
+			defMgr.aliasUsed[typeName] = true
 			r = *alias
 			r.name = propName
 		}
 	}
-	return r
+	return r, true
 }
 
-func readPropertyDef(prop *etree.Element) propertyDef {
+// readPropertyDef 读取属性配置, 配置错误时记录错误并返回false
+func readPropertyDef(prop *etree.Element) (propertyDef, bool) {
 	r := propertyDef{}
+	success := true
 	for _, e := range prop.ChildElements() {
 		v := strings.Trim(e.Text(), "\n ")
 		switch e.Tag {
 		case defFieldPropType:
-			r.Type = readPropType(e, prop.Tag, readTypeProp)
+			pt, ok := readPropType(e, prop.Tag, readTypeProp)
+			if !ok {
+				success = false
+			}
+			r.Type = pt
 		case defFieldPropFlags:
-			r.Flags = flagStrToEnum(v)
+			if r.Flags = flagStrToEnum(v); r.Flags == noClient && len(v) != 0 {
+				defErrorf(e, "prop[%s] has unknown flag: %s, support list: [OWN_CLIENT, OTHER_CLIENTS, ALL_CLIENTS]", prop.Tag, v)
+				success = false
+			}
 		case defFieldPropDefault:
 			r.Default = v
 		case defFieldPropPersistent:
@@ -231,25 +284,71 @@ func readPropertyDef(prop *etree.Element) propertyDef {
 				if p, err := strconv.ParseBool(v); err == nil {
 					r.Persistent = p
 				} else {
-					log.Fatalf("prop[%s] Persistent field parse error[%s]", prop.Tag, err.Error())
+					defErrorf(e, "prop[%s] Persistent field parse error[%s]", prop.Tag, err.Error())
+					success = false
 				}
 			}
+		case defFieldPropRenameFrom:
+			r.RenameFrom = v
+			version, ok := readRuleVersion(e, prop.Tag)
+			if !ok {
+				success = false
+			}
+			r.RenameVersion = version
+		case defFieldPropConvertFrom:
+			if pt, ok := readPropType(e, prop.Tag, readTypeProp); ok {
+				r.ConvertFrom = &pt
+			} else {
+				success = false
+			}
+			version, ok := readRuleVersion(e, prop.Tag)
+			if !ok {
+				success = false
+			}
+			r.ConvertVersion = version
 		default:
-			log.Fatalf("prop[%s] has unknown tag[%s]", prop.Tag, e.Tag)
+			defErrorf(e, "prop[%s] has unknown tag[%s]", prop.Tag, e.Tag)
+			success = false
 		}
 	}
 	if strings.ToLower(r.Type.typeName) == dataTypeNameSyncTable {
 		if r.Flags == noClient {
-			log.Fatalf("prop[%s] is %s, \"Flags\" field must be set", prop.Tag, r.Type.typeName)
+			defErrorf(prop, "prop[%s] is %s, \"Flags\" field must be set", prop.Tag, r.Type.typeName)
+			success = false
 		}
 	}
-	return r
+	return r, success
 }
 
 func (m *propertyDef) IsSyncProp() bool {
 	return m.Flags != noClient
 }
 
+// IsOwnClientProp 是否需要同步给entity自己的客户端
+func (m *propertyDef) IsOwnClientProp() bool {
+	return m.Flags == ownClient || m.Flags == allClients
+}
+
+// IsOtherClientsProp 是否需要同步给aoi内其他entity的客户端
+func (m *propertyDef) IsOtherClientsProp() bool {
+	return m.Flags == otherClients || m.Flags == allClients
+}
+
+// readRuleVersion 读取迁移规则生效的def版本, 如<RenameFrom Version="2">old_name</RenameFrom>, 版本号需为正整数
+func readRuleVersion(el *etree.Element, propName string) (int, bool) {
+	attr := el.SelectAttr(defFieldPropRuleVersion)
+	if attr == nil {
+		defErrorf(el, "prop[%s] %s should have attribute %s", propName, el.Tag, defFieldPropRuleVersion)
+		return 0, false
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(attr.Value))
+	if err != nil || version <= 0 {
+		defErrorf(el, "prop[%s] %s.%s should be positive integer, value[%s]", propName, el.Tag, defFieldPropRuleVersion, attr.Value)
+		return 0, false
+	}
+	return version, true
+}
+
 func (m *entityDef) GetEntityFileName() string {
 	return m.entityName + ".def"
 }
@@ -262,15 +361,20 @@ func (m *entityDef) Load(name string) {
 	m.serverMethods = make(map[string]*methodDef)
 	m.serverMethodsName = make(map[string]string)
 
-	doc := etree.NewDocument()
+	parentFile := currentLoadDefFile
+	defer func() { currentLoadDefFile = parentFile }()
+
 	fileName := cfg.WorkPath + "/defs/" + m.GetEntityFileName()
-	if err := doc.ReadFromFile(fileName); err != nil {
-		log.Fatalf("load entity[%s] def failed, error: %s", name, err.Error())
+	doc, err := readDefFile(fileName)
+	if err != nil {
+		defErrorf(nil, "load entity[%s] def failed, error: %s", name, err.Error())
+		return
 	}
 	currentLoadDefFile = fileName
 	root := doc.SelectElement(defFieldRoot)
 	if root == nil {
-		log.Fatalf("def file[%s] must start with \"root\"", currentLoadDefFile)
+		defErrorf(nil, "def file[%s] must start with \"root\"", currentLoadDefFile)
+		return
 	}
 
 	m.loadVolatile(root)
@@ -278,25 +382,30 @@ func (m *entityDef) Load(name string) {
 	m.loadProperties(root)
 	m.loadClientMethods(root)
 	m.loadServerMethods(root)
-	currentLoadDefFile = ""
 }
 
 func (m *entityDef) loadInterface(name string) {
-	doc := etree.NewDocument()
+	parentFile := currentLoadDefFile
+	defer func() { currentLoadDefFile = parentFile }()
+
 	fileName := cfg.WorkPath + "/defs/interfaces/" + name + ".def"
-	if err := doc.ReadFromFile(fileName); err != nil {
-		log.Fatalf("load interface[%s] in file[%s], error: %s", name, currentLoadDefFile, err.Error())
+	doc, err := readDefFile(fileName)
+	if err != nil {
+		defErrorf(nil, "load interface[%s] failed, error: %s", name, err.Error())
+		return
 	}
 	currentLoadDefFile = fileName
 	root := doc.SelectElement(defFieldRoot)
 	if root == nil {
-		log.Fatalf("def file[%s] must start with \"root\"", fileName)
+		defErrorf(nil, "def file[%s] must start with \"root\"", fileName)
+		return
 	}
+	m.loadingInterfaces = append(m.loadingInterfaces, name)
 	m.interfaces = append(m.loadImplements(root), m.interfaces...)
+	m.loadingInterfaces = m.loadingInterfaces[:len(m.loadingInterfaces)-1]
 	m.loadProperties(root)
 	m.loadClientMethods(root)
 	m.loadServerMethods(root)
-	currentLoadDefFile = fileName
 }
 
 func (m *entityDef) loadVolatile(el *etree.Element) {
@@ -308,7 +417,7 @@ func (m *entityDef) loadVolatile(el *etree.Element) {
 					if r, err := strconv.ParseBool(v.Text()); err == nil {
 						m.volatile.hasClient = r
 					} else {
-						log.Fatalf("Volatile.HasClient should be bool error[%s], file[%s]", err.Error(), currentLoadDefFile)
+						defErrorf(v, "Volatile.HasClient should be bool error[%s]", err.Error())
 					}
 				}
 			case defFieldVolatilePersistent:
@@ -316,7 +425,7 @@ func (m *entityDef) loadVolatile(el *etree.Element) {
 					if r, err := strconv.ParseBool(v.Text()); err == nil {
 						m.volatile.persistent = r
 					} else {
-						log.Fatalf("Volatile.Persistent should be bool error[%s], file[%s]", err.Error(), currentLoadDefFile)
+						defErrorf(v, "Volatile.Persistent should be bool error[%s]", err.Error())
 					}
 				}
 			case defFieldVolatileIsStub:
@@ -324,7 +433,7 @@ func (m *entityDef) loadVolatile(el *etree.Element) {
 					if r, err := strconv.ParseBool(v.Text()); err == nil {
 						m.volatile.isStub = r
 					} else {
-						log.Fatalf("Volatile.IsStub should be bool error[%s], file[%s]", err.Error(), currentLoadDefFile)
+						defErrorf(v, "Volatile.IsStub should be bool error[%s]", err.Error())
 					}
 				}
 			case defFieldVolatileRouter:
@@ -332,7 +441,15 @@ func (m *entityDef) loadVolatile(el *etree.Element) {
 					if r, err := strconv.ParseBool(v.Text()); err == nil {
 						m.volatile.router = r
 					} else {
-						log.Fatalf("Volatile.Router should be bool error[%s], file[%s]", err.Error(), currentLoadDefFile)
+						defErrorf(v, "Volatile.Router should be bool error[%s]", err.Error())
+					}
+				}
+			case defFieldVolatileVersion:
+				{
+					if r, err := strconv.Atoi(strings.Trim(v.Text(), "\n ")); err == nil && r >= 0 {
+						m.volatile.version = r
+					} else {
+						defErrorf(v, "Volatile.Version should be non-negative integer, value[%s]", v.Text())
 					}
 				}
 			}
@@ -343,39 +460,75 @@ func (m *entityDef) loadVolatile(el *etree.Element) {
 func (m *entityDef) loadImplements(el *etree.Element) []string {
 	iFace := make([]string, 0)
 	if iel := el.SelectElement(defFieldImplements); iel != nil {
+		loading := make([]*etree.Element, 0)
 		for _, v := range iel.ChildElements() {
-			iFace = append(iFace, strings.Trim(v.Text(), " \t"))
+			name := strings.Trim(v.Text(), "\n \t")
+			if cycle := m.interfaceCycle(name); cycle != "" {
+				defErrorf(v, "interface cycle: %s", cycle)
+				continue
+			}
+			iFace = append(iFace, name)
+			loading = append(loading, v)
 		}
-		for _, v := range iel.ChildElements() {
-			m.loadInterface(v.Text())
+		for _, v := range loading {
+			m.loadInterface(strings.Trim(v.Text(), "\n \t"))
 		}
 	}
 	return iFace
 }
 
+// interfaceCycle 继承name是否会形成循环, 返回循环路径
+func (m *entityDef) interfaceCycle(name string) string {
+	for i, v := range m.loadingInterfaces {
+		if v == name {
+			return strings.Join(append(append([]string{}, m.loadingInterfaces[i:]...), name), " -> ")
+		}
+	}
+	return ""
+}
+
 func (m *entityDef) loadProperties(el *etree.Element) {
 	if pel := el.SelectElement(defFieldProperties); pel != nil {
 		for _, prop := range pel.ChildElements() {
 			if strings.HasPrefix(prop.Tag, "_") {
-				log.Fatalf("prop[%s] startswith _ is not allowed in file[%s]", prop.Tag, currentLoadDefFile)
+				defErrorf(prop, "prop[%s] startswith _ is not allowed", prop.Tag)
+				continue
 			}
 			if _, ok := m.properties[prop.Tag]; ok {
-				log.Fatalf("duplicate defined prop[%s] in file[%s]", prop.Tag, currentLoadDefFile)
+				defErrorf(prop, "duplicate defined prop[%s]", prop.Tag)
+				continue
 			}
 			if isEntityReserveProp(prop.Tag) {
-				log.Fatalf("cannot define prop[%s] in file[%s], it is reversed", prop.Tag, currentLoadDefFile)
+				defErrorf(prop, "cannot define prop[%s], it is reversed", prop.Tag)
+				continue
+			}
+			propDef, ok := readPropertyDef(prop)
+			if !ok {
+				continue
+			}
+			if propDef.RenameVersion > m.volatile.version || propDef.ConvertVersion > m.volatile.version {
+				defErrorf(prop, "prop[%s] migrate rule version is greater than def version %d", prop.Tag, m.volatile.version)
+				continue
 			}
-			propDef := readPropertyDef(prop)
 			dt, err := dataTypeMgr.NewDataTypeFromPropDef(propDef)
 			if err != nil {
-				log.Fatalf("read prop[%s] in file[%s], error: %s", prop.Tag, currentLoadDefFile, err.Error())
+				defErrorf(prop, "read prop[%s] error: %s", prop.Tag, err.Error())
+				continue
 			} else if dt.Name() == (&dtMailBox{}).Name() {
-				log.Fatalf("prop[%s] in file[%s] type error, %s can only be as function argument", prop.Tag, currentLoadDefFile, dt.Type())
+				defErrorf(prop, "prop[%s] type error, %s can only be as function argument", prop.Tag, dt.Type())
+				continue
 			}
-			m.properties[prop.Tag] = propertyInfo{
+			info := propertyInfo{
 				config: propDef,
 				dt:     dt,
 			}
+			if propDef.ConvertFrom != nil {
+				if info.convertDt, err = dataTypeMgr.NewDataTypeFromPropDef(propertyDef{Type: *propDef.ConvertFrom}); err != nil {
+					defErrorf(prop, "read prop[%s] ConvertFrom error: %s", prop.Tag, err.Error())
+					continue
+				}
+			}
+			m.properties[prop.Tag] = info
 		}
 	}
 }
@@ -385,7 +538,8 @@ func (m *entityDef) loadClientMethods(el *etree.Element) {
 	if cmEl := el.SelectElement(defFieldClientMethods); cmEl != nil {
 		for _, method := range cmEl.ChildElements() {
 			if _, ok := m.clientMethodsName[method.Tag]; ok {
-				log.Fatalf("duplicate defined def client method[%s]", method.Tag)
+				defErrorf(method, "duplicate defined def client method[%s]", method.Tag)
+				continue
 			}
 			methods[method.Tag] = m.readMethodArgs(method)
 		}
@@ -405,19 +559,23 @@ func (m *entityDef) loadServerMethods(el *etree.Element) {
 		for _, method := range smEl.ChildElements() {
 			if method.Tag == StubEntryMethod {
 				if entryEntityName != "" && entryEntityName != m.entityName {
-					log.Fatalf("duplicate entry method[%s] in file[%s], already defined in entity[%s]", method.Tag, currentLoadDefFile, entryEntityName)
+					defErrorf(method, "duplicate entry method[%s], already defined in entity[%s]", method.Tag, entryEntityName)
+					continue
 				} else {
 					if m.volatile.isStub == false {
-						log.Fatalf("entry method[%s] can only be defined in stub def file", method.Tag)
+						defErrorf(method, "entry method[%s] can only be defined in stub def file", method.Tag)
+						continue
 					}
 					entryEntityName = m.entityName
 				}
 			}
 			if _, ok := m.serverMethodsName[method.Tag]; ok {
-				log.Fatalf("duplicate defined def server method[%s]", method.Tag)
+				defErrorf(method, "duplicate defined def server method[%s]", method.Tag)
+				continue
 			}
 			if _, ok := entityApiExports[method.Tag]; ok {
-				log.Fatalf("server method[%s] is reversed.", method.Tag)
+				defErrorf(method, "server method[%s] is reversed.", method.Tag)
+				continue
 			}
 			methods[method.Tag] = m.readMethodArgs(method)
 		}
@@ -440,19 +598,66 @@ func (m *entityDef) readMethodArgs(el *etree.Element) *methodDef {
 	for _, arg := range el.ChildElements() {
 		if arg.Tag == defFieldRpcExposed {
 			r.exposed = true
-			if gSvrType != STRobot {
+			//调用方的entityId由game在收到客户端消息时添加, 客户端与gate看到的参数列表不包含该参数
+			if gSvrType == STGame {
 				r.args = append(r.args, m.genExposedArg())
 			}
+		} else if arg.Tag == defFieldRpcReturns {
+			r.hasReturns = true
+			for _, ret := range arg.ChildElements() {
+				pType, ok := readPropType(ret, r.methodName, readTypeFunctionArg)
+				if !ok {
+					continue
+				}
+				dt, err := dataTypeMgr.NewDataTypeFromPropType(pType)
+				if err != nil {
+					defErrorf(ret, "read method[%s] return value error: %s", r.methodName, err.Error())
+					continue
+				}
+				r.returns = append(r.returns, argInfo{name: ret.Tag, ty: pType, dt: dt})
+			}
+		} else if arg.Tag == defFieldRpcRateLimit {
+			r.rateLimit = readRateLimit(arg, r.methodName)
 		} else {
-			pType := readPropType(arg, r.methodName, readTypeFunctionArg)
+			pType, ok := readPropType(arg, r.methodName, readTypeFunctionArg)
+			if !ok {
+				continue
+			}
 			dt, err := dataTypeMgr.NewDataTypeFromPropType(pType)
 			if err != nil {
-				log.Fatalf("read method[%s] arg in file[%s] error: %s", r.methodName, currentLoadDefFile, err.Error())
+				defErrorf(arg, "read method[%s] arg error: %s", r.methodName, err.Error())
+				continue
 			}
-			r.args = append(r.args, argInfo{ty: pType, dt: dt})
+			r.args = append(r.args, argInfo{name: arg.Tag, ty: pType, dt: dt})
 		}
 	}
 
+	if r.rateLimit != nil && !r.exposed {
+		defErrorf(el, "method[%s] RateLimit only works with Exposed", r.methodName)
+	}
+
+	return r
+}
+
+// readRateLimit 读取函数的RateLimit配置, Burst未配置时为1, 配置错误时返回nil
+func readRateLimit(el *etree.Element, methodName string) *RateLimit {
+	r := &RateLimit{Burst: 1}
+	if v := el.SelectElement(defFieldRateLimitRate); v != nil {
+		if rate, err := strconv.ParseFloat(strings.Trim(v.Text(), "\n "), 64); err == nil {
+			r.Rate = rate
+		}
+	}
+	if v := el.SelectElement(defFieldRateLimitBurst); v != nil {
+		if burst, err := strconv.Atoi(strings.Trim(v.Text(), "\n ")); err == nil {
+			r.Burst = burst
+		} else {
+			r.Burst = 0
+		}
+	}
+	if err := r.check(); err != nil {
+		defErrorf(el, "method[%s] RateLimit %s", methodName, err.Error())
+		return nil
+	}
 	return r
 }
 
@@ -470,7 +675,14 @@ func (m *entityDef) genExposedArg() argInfo {
 func (m *entityDef) maskMethods(methods map[string]*methodDef) (map[string]*methodDef, map[string]string) {
 	result := make(map[string]*methodDef)
 	mapping := make(map[string]string)
-	for name, def := range methods {
+	//按函数名顺序生成, 保证mask name冲突时结果稳定, 与生成的客户端代码一致
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def := methods[name]
 		md5Name := Md5(name)
 		success := false
 		for n := 4; n <= 32; n++ {
@@ -483,7 +695,7 @@ func (m *entityDef) maskMethods(methods map[string]*methodDef) (map[string]*meth
 			}
 		}
 		if !success {
-			log.Fatalf("method[%s] conflict", name)
+			defErrorf(nil, "method[%s] conflict", name)
 		}
 	}
 	return result, mapping
@@ -531,13 +743,23 @@ func (m *entityDef) registerPropsToEntity(ent *entity) {
 }
 
 func (m *entityDef) registerClientMethodsToEntity(ent *entity) {
+	//没有客户端的entity(如npc)也可以调用aoi内其他客户端的方法
+	otherClientsTable := luaL.NewTable()
+	allClientsTable := luaL.NewTable()
+	for _, method := range m.clientMethods {
+		luaL.SetField(otherClientsTable, method.methodName, newClientFunction(method.methodName, ent.entityId, clientTargetOthers))
+		luaL.SetField(allClientsTable, method.methodName, newClientFunction(method.methodName, ent.entityId, clientTargetAll))
+	}
+	ent.luaEntity.RawSetString(entityFieldOtherClients, otherClientsTable)
+	ent.luaEntity.RawSetString(entityFieldAllClients, allClientsTable)
+
 	if m.volatile.hasClient != true {
 		return
 	}
 	ent.clientTable = luaL.NewTable()
 
 	for _, method := range m.clientMethods {
-		luaL.SetField(ent.clientTable, method.methodName, newClientFunction(method.methodName, ent.entityId))
+		luaL.SetField(ent.clientTable, method.methodName, newClientFunction(method.methodName, ent.entityId, clientTargetOwn))
 	}
 }
 
@@ -584,6 +806,20 @@ func (m *entityDef) getServerMethod(method string, isOriginName bool) *methodDef
 	return nil
 }
 
+// checkServerMethodArgs 按def检查服务端函数的参数类型
+func (m *entityDef) checkServerMethodArgs(mi *methodDef, args []lua.LValue) error {
+	argLen := len(args)
+	for i := 0; i < len(mi.args); i++ {
+		if argLen <= i {
+			break
+		}
+		if mi.args[i].dt.IsSameType(args[i]) == false {
+			return fmt.Errorf("function[%s] arg[%d] expect %s but got %+v(%s)", mi.methodName, i+1, mi.args[i].dt.Type(), args[i], args[i].Type())
+		}
+	}
+	return nil
+}
+
 func (m *entityDef) getClientMethod(method string, isOriginName bool) *methodDef {
 	name := method
 	if isOriginName {
@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/beevik/etree"
+	lua "github.com/seasondi/gopher-lua"
+	"github.com/sirupsen/logrus"
+	"os"
+	"sort"
+)
+
+var (
+	defErrors    []*DefError            //加载def文件时收集的配置错误, 只有defcheck会收集警告
+	defElemLines map[*etree.Element]int //def文件中标签所在的行号
+)
+
+// DefError def文件配置错误
+type DefError struct {
+	File string //def文件
+	Line int    //行号, 0表示无法定位到具体标签
+	Path string //标签路径
+	Msg  string //错误信息
+	Warn bool   //是否只是警告, 警告不影响def加载
+}
+
+func (e *DefError) Error() string {
+	msg := e.Msg
+	if e.Warn {
+		msg = "warning: " + msg
+	}
+	if e.Path == "" {
+		return fmt.Sprintf("%s:%d %s", e.File, e.Line, msg)
+	}
+	return fmt.Sprintf("%s:%d [%s] %s", e.File, e.Line, e.Path, msg)
+}
+
+// defErrorf 记录当前加载的def文件中el标签的配置错误, el为nil时只记录文件
+func defErrorf(el *etree.Element, format string, args ...interface{}) {
+	err := &DefError{File: currentLoadDefFile, Msg: fmt.Sprintf(format, args...)}
+	if el != nil {
+		err.Line = defElemLines[el]
+		err.Path = el.GetPath()
+	}
+	defErrors = append(defErrors, err)
+}
+
+// defWarnf 记录当前加载的def文件中el标签的可疑配置, 不视为错误
+func defWarnf(el *etree.Element, format string, args ...interface{}) {
+	defErrorf(el, format, args...)
+	defErrors[len(defErrors)-1].Warn = true
+}
+
+// readDefFile 读取def文件, 并记录文件中每个标签的行号
+func readDefFile(fileName string) (*etree.Document, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	doc := etree.NewDocument()
+	if err = doc.ReadFromBytes(data); err != nil {
+		return nil, err
+	}
+
+	//etree不记录行号, 按标签出现的顺序与etree的先序遍历一一对应
+	lines := make([]int, 0)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	line, offset := 1, int64(0)
+	for {
+		start := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err != nil {
+			break
+		}
+		if _, ok := token.(xml.StartElement); ok {
+			line += bytes.Count(data[offset:start], []byte("\n"))
+			offset = start
+			lines = append(lines, line)
+		}
+	}
+	index := 0
+	var walk func(el *etree.Element)
+	walk = func(el *etree.Element) {
+		if index < len(lines) && defElemLines != nil {
+			defElemLines[el] = lines[index]
+		}
+		index++
+		for _, child := range el.ChildElements() {
+			walk(child)
+		}
+	}
+	for _, el := range doc.ChildElements() {
+		walk(el)
+	}
+	return doc, nil
+}
+
+/*
+CheckDefs 检查workPath下的全部def文件, 返回所有配置错误与警告
+
+加载entities.xml、alias.xml以及entity继承的interface, 并检查interface的循环继承, 未被引用的alias作为警告返回
+*/
+func CheckDefs(workPath string) []*DefError {
+	if err := loadDefsForTool(workPath, STGame); err == nil {
+		defMgr.checkUnusedAlias()
+	}
+
+	sort.SliceStable(defErrors, func(i, j int) bool {
+		if defErrors[i].File != defErrors[j].File {
+			return defErrors[i].File < defErrors[j].File
+		}
+		return defErrors[i].Line < defErrors[j].Line
+	})
+	return defErrors
+}
+
+// loadDefsForTool 工具中加载def文件, 只依赖工作路径, 不读取配置文件
+func loadDefsForTool(workPath string, st ServerType) error {
+	gSvrType = st
+	cfg = &config{WorkPath: workPath}
+	if log == nil {
+		log = logrus.NewEntry(logrus.StandardLogger())
+	}
+	if luaL == nil {
+		//sync_table等类型生成默认值时需要lua虚拟机
+		luaL = lua.NewState()
+	}
+	entryEntityName = ""
+	_ = initDataTypes()
+	defMgr = new(entityDefs)
+	return defMgr.load()
+}
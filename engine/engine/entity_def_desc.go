@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TypeDesc def中的类型描述, alias已展开为实际类型
+type TypeDesc struct {
+	Name   string      //类型名称, 小写, 如int32、string、array、map、struct
+	Key    *TypeDesc   //map的key类型
+	Value  *TypeDesc   //array、map的value类型
+	Fields []*PropDesc //struct的字段, 按名称排序
+}
+
+// PropDesc 属性描述
+type PropDesc struct {
+	Name         string    //属性名
+	Type         *TypeDesc //属性类型
+	Default      string    //默认值
+	OwnClient    bool      //是否同步给自己的客户端
+	OtherClients bool      //是否同步给aoi内其他entity的客户端
+}
+
+// ArgDesc 函数参数描述
+type ArgDesc struct {
+	Name string    //def中参数的标签名
+	Type *TypeDesc //参数类型
+}
+
+// MethodDesc rpc函数描述
+type MethodDesc struct {
+	Name     string     //函数名
+	MaskName string     //通信时使用的函数名
+	Args     []*ArgDesc //参数列表
+}
+
+// EntityDesc 客户端可见的entity描述
+type EntityDesc struct {
+	Name          string        //entity名称
+	HasClient     bool          //是否绑定客户端连接
+	Props         []*PropDesc   //需要同步给客户端的属性, 按名称排序
+	ServerMethods []*MethodDesc //暴露给客户端的服务端函数, 按名称排序
+	ClientMethods []*MethodDesc //客户端函数, 按名称排序
+}
+
+/*
+ClientDefs 加载workPath下的def文件, 返回客户端可见的entity描述, 用于生成客户端代码
+
+stub不会同步到客户端, 不在返回结果中
+*/
+func ClientDefs(workPath string) ([]*EntityDesc, error) {
+	err := loadDefsForTool(workPath, STRobot)
+	if len(defErrors) > 0 {
+		return nil, fmt.Errorf("def files have %d error(s), first: %s", len(defErrors), defErrors[0].Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return clientEntityDescs(), nil
+}
+
+// clientEntityDescs 已加载def中客户端可见的entity描述, 按entity名称排序
+func clientEntityDescs() []*EntityDesc {
+	names := defMgr.GetAllEntityNames()
+	sort.Strings(names)
+	r := make([]*EntityDesc, 0, len(names))
+	for _, name := range names {
+		def := defMgr.GetEntityDef(name)
+		if def.volatile.isStub {
+			continue
+		}
+		desc := &EntityDesc{Name: name, HasClient: def.volatile.hasClient}
+		for propName, prop := range def.properties {
+			if prop.config.IsSyncProp() {
+				desc.Props = append(desc.Props, newPropDesc(propName, prop.config))
+			}
+		}
+		sort.Slice(desc.Props, func(i, j int) bool { return desc.Props[i].Name < desc.Props[j].Name })
+		for maskName, method := range def.serverMethods {
+			if method.exposed {
+				desc.ServerMethods = append(desc.ServerMethods, newMethodDesc(maskName, method))
+			}
+		}
+		sort.Slice(desc.ServerMethods, func(i, j int) bool { return desc.ServerMethods[i].Name < desc.ServerMethods[j].Name })
+		for maskName, method := range def.clientMethods {
+			desc.ClientMethods = append(desc.ClientMethods, newMethodDesc(maskName, method))
+		}
+		sort.Slice(desc.ClientMethods, func(i, j int) bool { return desc.ClientMethods[i].Name < desc.ClientMethods[j].Name })
+		r = append(r, desc)
+	}
+	return r
+}
+
+// hashEntityDescs 参与def版本号计算的描述, 在clientEntityDescs之后追加各stub暴露给客户端的函数与登录入口函数
+func hashEntityDescs() []*EntityDesc {
+	r := clientEntityDescs()
+	names := defMgr.GetAllEntityNames()
+	sort.Strings(names)
+	for _, name := range names {
+		def := defMgr.GetEntityDef(name)
+		if !def.volatile.isStub {
+			continue
+		}
+		desc := &EntityDesc{Name: name}
+		for maskName, method := range def.serverMethods {
+			if method.exposed || method.methodName == StubEntryMethod {
+				desc.ServerMethods = append(desc.ServerMethods, newMethodDesc(maskName, method))
+			}
+		}
+		if len(desc.ServerMethods) == 0 {
+			continue
+		}
+		sort.Slice(desc.ServerMethods, func(i, j int) bool { return desc.ServerMethods[i].Name < desc.ServerMethods[j].Name })
+		r = append(r, desc)
+	}
+	return r
+}
+
+func newTypeDesc(pt *propType) *TypeDesc {
+	r := &TypeDesc{Name: strings.ToLower(pt.typeName)}
+	if pt.keyType != nil {
+		r.Key = newTypeDesc(pt.keyType)
+	}
+	if pt.valueType != nil {
+		r.Value = newTypeDesc(pt.valueType)
+	}
+	if r.Name == dataTypeNameStruct {
+		r.Fields = make([]*PropDesc, 0, len(pt.props))
+		for name, prop := range pt.props {
+			r.Fields = append(r.Fields, newPropDesc(name, prop.config))
+		}
+		sort.Slice(r.Fields, func(i, j int) bool { return r.Fields[i].Name < r.Fields[j].Name })
+	}
+	return r
+}
+
+func newPropDesc(name string, config propertyDef) *PropDesc {
+	return &PropDesc{
+		Name:         name,
+		Type:         newTypeDesc(&config.Type),
+		Default:      config.Default,
+		OwnClient:    config.IsOwnClientProp(),
+		OtherClients: config.IsOtherClientsProp(),
+	}
+}
+
+func newMethodDesc(maskName string, method *methodDef) *MethodDesc {
+	r := &MethodDesc{Name: method.methodName, MaskName: maskName, Args: make([]*ArgDesc, 0, len(method.args))}
+	for _, arg := range method.args {
+		//game在参数列表中添加的调用方entityId没有标签名, 客户端不传该参数
+		if arg.name == "" {
+			continue
+		}
+		r.Args = append(r.Args, &ArgDesc{Name: arg.name, Type: newTypeDesc(&arg.ty)})
+	}
+	return r
+}
@@ -1,8 +1,11 @@
 package engine
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/beevik/etree"
+	"strconv"
+	"strings"
 )
 
 func initEntityDefs() error {
@@ -19,30 +22,91 @@ func initEntityDefs() error {
 }
 
 type entityDefs struct {
-	defMap map[string]*entityDef
-	alias  map[string]propType
+	defMap    map[string]*entityDef
+	alias     map[string]propType
+	aliasEl   map[string]*etree.Element //alias名称->alias.xml中的标签
+	aliasUsed map[string]bool           //被def引用过的alias
+	hash      string                    //客户端可见def内容的摘要, 首次使用时计算
 }
 
+// Init 加载全部def文件, 任意def文件配置错误时返回错误, 错误详情记录在日志中
 func (m *entityDefs) Init() error {
-	if err := m.LoadAlias(); err != nil {
-		return err
+	_ = m.load()
+	for _, err := range defErrors {
+		log.Errorf("def error: %s", err.Error())
 	}
-	if err := m.LoadEntityDef(); err != nil {
-		return err
+	if len(defErrors) > 0 {
+		return fmt.Errorf("load def files failed, %d error(s)", len(defErrors))
 	}
 	return nil
 }
 
-func (m *entityDefs) LoadAlias() error {
-	doc := etree.NewDocument()
-	if err := doc.ReadFromFile(cfg.WorkPath + "/defs/alias.xml"); err != nil {
-		log.Errorf("read alias.xml failed, msg: %s", err.Error())
+// Hash 客户端可见def内容(同步属性、rpc函数签名与mask name, 包括stub暴露给客户端的函数与登录入口)的摘要, 客户端登录时据此校验def版本
+func (m *entityDefs) Hash() string {
+	if m.hash == "" {
+		data, err := json.Marshal(hashEntityDescs())
+		if err != nil {
+			log.Errorf("marshal def descs error: %s", err.Error())
+			return ""
+		}
+		m.hash = Md5(string(data))
+	}
+	return m.hash
+}
+
+// DefHash 已加载def的版本号, 未加载def的进程返回空字符串
+func DefHash() string {
+	if defMgr == nil {
+		return ""
+	}
+	return defMgr.Hash()
+}
+
+// CheckDefVersion 校验客户端登录消息中的def版本号, 版本号不一致时返回false;
+// 未上报版本号的旧客户端只在配置了StrictDefVersion时拒绝, 便于客户端逐步升级
+func CheckDefVersion(data map[string]interface{}) bool {
+	version, _ := data[ClientMsgDataFieldVersion].(string)
+	if version == "" {
+		return !cfg.StrictDefVersion
+	}
+	return version == DefHash()
+}
+
+// load 加载alias.xml与entities.xml, 配置错误记录在defErrors中, 无法继续加载时返回错误
+func (m *entityDefs) load() error {
+	defErrors = make([]*DefError, 0)
+	defElemLines = make(map[*etree.Element]int)
+	if err := m.LoadAlias(); err != nil {
 		return err
 	}
+	return m.LoadEntityDef()
+}
+
+func (m *entityDefs) LoadAlias() error {
 	m.alias = make(map[string]propType)
+	m.aliasEl = make(map[string]*etree.Element)
+	m.aliasUsed = make(map[string]bool)
+	currentLoadDefFile = cfg.WorkPath + "/defs/alias.xml"
+	defer func() { currentLoadDefFile = "" }()
+	doc, err := readDefFile(currentLoadDefFile)
+	if err != nil {
+		defErrorf(nil, "read alias.xml failed, msg: %s", err.Error())
+		return err
+	}
 	root := doc.SelectElement(defFieldRoot)
+	if root == nil {
+		defErrorf(nil, "alias.xml must start with \"root\"")
+		return fmt.Errorf("alias.xml must start with \"root\"")
+	}
 	for _, tp := range root.ChildElements() {
-		m.alias[tp.Tag] = readPropType(tp, tp.Tag, readTypeAlias)
+		if _, ok := m.alias[tp.Tag]; ok {
+			defErrorf(tp, "duplicate defined alias[%s]", tp.Tag)
+			continue
+		}
+		if pt, ok := readPropType(tp, tp.Tag, readTypeAlias); ok {
+			m.alias[tp.Tag] = pt
+			m.aliasEl[tp.Tag] = tp
+		}
 	}
 	return nil
 }
@@ -50,28 +114,74 @@ func (m *entityDefs) LoadAlias() error {
 func (m *entityDefs) LoadEntityDef() error {
 	m.defMap = make(map[string]*entityDef)
 	entityXmlFile := cfg.WorkPath + "/defs/entities.xml"
-	doc := etree.NewDocument()
-	if err := doc.ReadFromFile(entityXmlFile); err != nil {
-		log.Errorf("read file[%s] error: %s", entityXmlFile, err.Error())
+	currentLoadDefFile = entityXmlFile
+	defer func() { currentLoadDefFile = "" }()
+	doc, err := readDefFile(entityXmlFile)
+	if err != nil {
+		defErrorf(nil, "read file error: %s", err.Error())
 		return err
 	}
 	root := doc.SelectElement("root")
 	if root == nil {
-		log.Errorf("[%s] must start with \"root\"", entityXmlFile)
+		defErrorf(nil, "entities.xml must start with \"root\"")
 		return fmt.Errorf("[%s] must start with \"root\"", entityXmlFile)
 	}
 	for _, ent := range root.SelectElements("entity") {
+		name := strings.Trim(ent.Text(), "\n \t")
+		if _, ok := m.defMap[name]; ok {
+			defErrorf(ent, "duplicate defined entity[%s]", name)
+			continue
+		}
 		entDef := new(entityDef)
-		entDef.Load(ent.Text())
+		entDef.Load(name)
 		m.defMap[entDef.entityName] = entDef
+		m.loadShards(ent, entDef)
 	}
 	if entryEntityName == "" {
-		log.Errorf("not found entry method[%s] in all def files", StubEntryMethod)
+		defErrorf(nil, "not found entry method[%s] in all def files", StubEntryMethod)
 		return fmt.Errorf("not found entry method[%s] in all def files", StubEntryMethod)
 	}
 	return nil
 }
 
+// loadShards 读取entities.xml中stub的分片数量, 如<entity shards="4">RoleStub</entity>
+func (m *entityDefs) loadShards(el *etree.Element, def *entityDef) {
+	attr := el.SelectAttr(defFieldEntityShards)
+	if attr == nil {
+		return
+	}
+	shards, err := strconv.Atoi(strings.TrimSpace(attr.Value))
+	if err != nil || shards <= 0 {
+		defErrorf(el, "entity[%s] shards should be positive integer, value[%s]", def.entityName, attr.Value)
+		return
+	}
+	if !def.volatile.isStub {
+		defErrorf(el, "entity[%s] is not stub, cannot be sharded", def.entityName)
+		return
+	}
+	if def.entityName == entryEntityName {
+		defErrorf(el, "entry stub[%s] cannot be sharded", def.entityName)
+		return
+	}
+	//分片失效后由其他进程以新的entity id重建, 无法加载原分片的存盘数据
+	if def.volatile.persistent {
+		defErrorf(el, "persistent stub[%s] cannot be sharded", def.entityName)
+		return
+	}
+	def.shards = shards
+}
+
+// checkUnusedAlias 检查未被任何def引用的alias
+func (m *entityDefs) checkUnusedAlias() {
+	currentLoadDefFile = cfg.WorkPath + "/defs/alias.xml"
+	defer func() { currentLoadDefFile = "" }()
+	for name, el := range m.aliasEl {
+		if !m.aliasUsed[name] {
+			defWarnf(el, "alias[%s] is not used", name)
+		}
+	}
+}
+
 func (m *entityDefs) GetEntityDef(name string) *entityDef {
 	return m.defMap[name]
 }
@@ -84,6 +194,51 @@ func (m *entityDefs) GetAllEntityNames() []string {
 	return result
 }
 
+// ShardedStubs 所有分片stub, stub名称 -> 分片数量
+func ShardedStubs() map[string]int {
+	r := make(map[string]int)
+	for name, def := range defMgr.defMap {
+		if def.shards > 0 {
+			r[name] = def.shards
+		}
+	}
+	return r
+}
+
+// ExposedMethod 暴露给客户端的服务端函数
+type ExposedMethod struct {
+	Name      string     //函数名
+	RateLimit *RateLimit //def中配置的限流, 未配置时为nil
+}
+
+// ExposedMethods 所有entity暴露给客户端的服务端函数, mask name -> 函数, 同名函数的限流配置不同时取每秒令牌数最小的
+func ExposedMethods() map[string]ExposedMethod {
+	r := make(map[string]ExposedMethod)
+	if defMgr == nil {
+		return r
+	}
+	for _, def := range defMgr.defMap {
+		for maskName, method := range def.serverMethods {
+			if !method.exposed {
+				continue
+			}
+			if exist, ok := r[maskName]; ok && (method.rateLimit == nil || (exist.RateLimit != nil && exist.RateLimit.Rate <= method.rateLimit.Rate)) {
+				continue
+			}
+			r[maskName] = ExposedMethod{Name: method.methodName, RateLimit: method.rateLimit}
+		}
+	}
+	return r
+}
+
+// IsPersistentEntity entity是否需要存盘
+func IsPersistentEntity(name string) bool {
+	if def := defMgr.GetEntityDef(name); def != nil {
+		return def.volatile.persistent
+	}
+	return false
+}
+
 func (m *entityDefs) GetAlias(name string) *propType {
 	if pt, ok := m.alias[name]; ok {
 		return &pt
@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"github.com/beevik/etree"
+	"testing"
+)
+
+func TestCheckDefVersion(t *testing.T) {
+	if err := loadDefsForTool("../../scripts", STRobot); err != nil {
+		t.Fatalf("load defs error: %s", err.Error())
+	}
+	hash := DefHash()
+	if hash == "" {
+		t.Fatal("def hash is empty")
+	}
+	tests := []struct {
+		name    string
+		strict  bool
+		version interface{}
+		want    bool
+	}{
+		{"same version", false, hash, true},
+		{"different version", false, "0123", false},
+		{"missing version allowed", false, nil, true},
+		{"empty version allowed", false, "", true},
+		{"missing version strict", true, nil, false},
+		{"same version strict", true, hash, true},
+		{"invalid type strict", true, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.StrictDefVersion = tt.strict
+			data := map[string]interface{}{}
+			if tt.version != nil {
+				data[ClientMsgDataFieldVersion] = tt.version
+			}
+			if got := CheckDefVersion(data); got != tt.want {
+				t.Fatalf("CheckDefVersion(%v) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefHashIncludesStubEntry(t *testing.T) {
+	if err := loadDefsForTool("../../scripts", STRobot); err != nil {
+		t.Fatalf("load defs error: %s", err.Error())
+	}
+	found := false
+	for _, desc := range hashEntityDescs() {
+		for _, method := range desc.ServerMethods {
+			if desc.Name == entryEntityName && method.Name == StubEntryMethod {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("entry method of stub[%s] not in def hash", entryEntityName)
+	}
+}
+
+func TestCheckDefsScripts(t *testing.T) {
+	for _, err := range CheckDefs("../../scripts") {
+		if !err.Warn {
+			t.Fatalf("def error in scripts: %s", err.Error())
+		}
+	}
+}
+
+func TestLoadShards(t *testing.T) {
+	tests := []struct {
+		name       string
+		shards     string
+		stub       bool
+		persistent bool
+		entry      bool
+		want       int
+		wantErr    bool
+	}{
+		{"not sharded", "", true, false, false, 0, false},
+		{"sharded stub", "4", true, false, false, 4, false},
+		{"invalid count", "0", true, false, false, 0, true},
+		{"not stub", "4", false, false, false, 0, true},
+		{"entry stub", "4", true, false, true, 0, true},
+		{"persistent stub", "4", true, true, false, 0, true},
+	}
+	oldEntry := entryEntityName
+	defer func() { entryEntityName = oldEntry; defErrors = nil }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defErrors = nil
+			entryEntityName = ""
+			if tt.entry {
+				entryEntityName = "TestStub"
+			}
+			el := etree.NewElement("entity")
+			if tt.shards != "" {
+				el.CreateAttr(defFieldEntityShards, tt.shards)
+			}
+			def := &entityDef{entityName: "TestStub", volatile: volatileDef{isStub: tt.stub, persistent: tt.persistent}}
+			new(entityDefs).loadShards(el, def)
+			if def.shards != tt.want {
+				t.Fatalf("shards = %d, want %d", def.shards, tt.want)
+			}
+			if got := len(defErrors) > 0; got != tt.wantErr {
+				t.Fatalf("has error = %v, want %v, errors: %v", got, tt.wantErr, defErrors)
+			}
+		})
+	}
+}
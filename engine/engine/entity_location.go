@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	locationPublishQueueSize = 4096            //待发布的位置变化通知队列长度, 队列满时丢弃, 其他进程的位置缓存过期后重新查询
+	locationPublishTimeout   = time.Second     //发布超时时间
+	locationPublishFlushTime = 3 * time.Second //进程退出时等待队列发布完的最长时间
+)
+
+var locationPub *locationPublisher
+
+// locationPublisher entity位置变化通知在单独的goroutine中按入队顺序发布到redis, 主线程只入队不等待
+type locationPublisher struct {
+	queue  chan []byte
+	done   chan struct{}
+	closed bool
+}
+
+func getLocationPublisher() *locationPublisher {
+	if locationPub == nil {
+		locationPub = &locationPublisher{
+			queue: make(chan []byte, locationPublishQueueSize),
+			done:  make(chan struct{}),
+		}
+		go locationPub.loop()
+	}
+	return locationPub
+}
+
+// push 只能在主线程调用
+func (m *locationPublisher) push(info []byte) bool {
+	if m.closed {
+		return false
+	}
+	select {
+	case m.queue <- info:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *locationPublisher) loop() {
+	defer close(m.done)
+	for info := range m.queue {
+		ctx, cancel := context.WithTimeout(context.TODO(), locationPublishTimeout)
+		if err := GetRedisMgr().Publish(ctx, RedisEntityLocationChannel(), info); err != nil {
+			log.Warnf("publish entity location %s error: %s", info, err.Error())
+		}
+		cancel()
+	}
+}
+
+// close 停止接收新的通知, 等待已入队的通知发布完, 只能在主线程调用
+func (m *locationPublisher) close() {
+	if m.closed {
+		return
+	}
+	m.closed = true
+	close(m.queue)
+	select {
+	case <-m.done:
+	case <-time.After(locationPublishFlushTime):
+		log.Warnf("publish entity location not finished in %s, %d message(s) dropped", locationPublishFlushTime, len(m.queue))
+	}
+}
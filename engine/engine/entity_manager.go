@@ -27,6 +27,8 @@ type entityManager struct {
 	connFinder        entityGateConnFinder             //查询entity的gate连接
 	connMap           map[string]clientIdToEntitiesMap //gate server name -> clientId->entities
 	entryStubEntityId EntityIdType
+	syncDirtyEntities entityIdMap                          //有属性待同步给客户端的entity
+	migratedEntities  map[EntityIdType]*migratedEntityInfo //已迁出的entity, 用于转发迁移后收到的消息
 }
 
 func initEntityManager() error {
@@ -41,6 +43,26 @@ func (em *entityManager) init() {
 	em.metas = make(map[string]*lua.LTable)
 	em.allEntities = make(map[EntityIdType]*entity)
 	em.connMap = make(map[string]clientIdToEntitiesMap)
+	em.syncDirtyEntities = make(entityIdMap)
+	em.migratedEntities = make(map[EntityIdType]*migratedEntityInfo)
+}
+
+func (em *entityManager) addSyncDirtyEntity(e *entity) {
+	em.syncDirtyEntities[e.entityId] = true
+}
+
+// flushSyncProps 每帧将entity变化的属性同步给客户端
+func (em *entityManager) flushSyncProps() {
+	if len(em.syncDirtyEntities) == 0 {
+		return
+	}
+	dirty := em.syncDirtyEntities
+	em.syncDirtyEntities = make(entityIdMap)
+	for entityId := range dirty {
+		if ent := em.GetEntityById(entityId); ent != nil {
+			ent.flushSyncProps()
+		}
+	}
 }
 
 func (em *entityManager) saveEntity(e *entity) {
@@ -99,6 +121,32 @@ func (em *entityManager) CreateEntityWithId(entityId EntityIdType, entityName st
 	return ent, nil
 }
 
+// CreateStubShard 创建分片stub的第index个分片
+func (em *entityManager) CreateStubShard(entityName string, index int) (*entity, error) {
+	def := defMgr.GetEntityDef(entityName)
+	if def == nil || def.shards <= 0 {
+		return nil, fmt.Errorf("entity[%s] is not sharded stub", entityName)
+	}
+	if index < 0 || index >= def.shards {
+		return nil, fmt.Errorf("stub[%s] shard index %d out of range [0, %d)", entityName, index, def.shards)
+	}
+	entityId := generateEntityId()
+	log.Infof("create stub shard[%s:%d] start, index: %d", entityName, entityId, index)
+	ent, err := NewEntity(entityId, entityName)
+	if err != nil {
+		log.Errorf("entity create failed, entityName: %s, id: %d, error: %s", entityName, entityId, err.Error())
+		return nil, err
+	}
+	ent.shardIndex = index
+	if err = ent.completeEntity(); err != nil {
+		ent.Destroy(false, true)
+		return nil, err
+	}
+
+	log.Infof("create %s success, shard index: %d", ent.String(), index)
+	return ent, nil
+}
+
 func (em *entityManager) CreateEntityFromData(entityId EntityIdType, data map[string]interface{}) *entity {
 	if name, ok := data[entityFieldName].(string); ok == false || defMgr.GetEntityDef(name) == nil {
 		log.Warnf("CreateEntityFromData unknown entity name[%s] for entityId[%d], data: %+v", name, entityId, data)
@@ -120,6 +168,56 @@ func (em *entityManager) CreateEntityFromData(entityId EntityIdType, data map[st
 	}
 }
 
+// CreateEntityFromMigrateData 根据其他game发来的迁移数据创建entity
+func (em *entityManager) CreateEntityFromMigrateData(entityId EntityIdType, buf []byte) (*entity, error) {
+	if em.GetEntityById(entityId) != nil {
+		return nil, fmt.Errorf("entity[%d] already exists", entityId)
+	}
+	data, err := GetProtocol().UnMarshal(buf)
+	if err != nil {
+		return nil, err
+	}
+	props, ok := data[migrateFieldProps].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("entity[%d] migrate data has no props", entityId)
+	}
+	name, _ := props[entityFieldName].(string)
+	ent, err := NewEntity(entityId, name)
+	if err != nil {
+		return nil, err
+	}
+	ent.loadData(props)
+	//源进程可能还有未存盘的修改, 迁入后下次存盘时全量写入
+	ent.lastFullSaveTime = time.Time{}
+	if err = ent.completeMigratedEntity(data); err != nil {
+		//redis中仍是源进程的位置信息, 不能走Destroy流程移除
+		em.unRegisterEntity(ent)
+		ent.status = EntityDestroyed
+		return nil, err
+	}
+	delete(em.migratedEntities, entityId)
+	log.Infof("%s CreateEntityFromMigrateData success.", ent.String())
+	return ent, nil
+}
+
+func (em *entityManager) addMigratedEntity(entityId EntityIdType, target string) {
+	now := time.Now()
+	for id, info := range em.migratedEntities {
+		if now.After(info.expire) {
+			delete(em.migratedEntities, id)
+		}
+	}
+	em.migratedEntities[entityId] = &migratedEntityInfo{target: target, expire: now.Add(migratedEntityKeepTime)}
+}
+
+// MigratedEntityTarget entity刚迁出本进程时返回迁移的目标game, 否则返回空字符串
+func (em *entityManager) MigratedEntityTarget(entityId EntityIdType) string {
+	if info, ok := em.migratedEntities[entityId]; ok && time.Now().Before(info.expire) {
+		return info.target
+	}
+	return ""
+}
+
 func (em *entityManager) registerEntity(ent *entity) {
 	em.allEntities[ent.entityId] = ent
 	luaL.RawSet(getLuaEntities(), EntityIdToLua(ent.entityId), ent.luaEntity)
@@ -146,8 +244,7 @@ func (em *entityManager) genMetaTable(name string) *lua.LTable {
 			key := L.CheckString(2)
 			ent := em.GetEntityByLua(entTable)
 			if ent.def.isDefProp(key) {
-				value := L.GetField(ent.propsTable, key)
-				L.Push(value)
+				L.Push(ent.persistTableProxy(key, L.GetField(ent.propsTable, key)))
 			} else {
 				v := L.RawGet(newMetaTable, lua.LString(key))
 				if v == lua.LNil {
@@ -165,9 +262,13 @@ func (em *entityManager) genMetaTable(name string) *lua.LTable {
 			if ent.def.isDefProp(propName) {
 				dt := ent.def.propDataType(propName)
 				typeName := dt.Name()
+				//代理与存盘属性赋值时深拷贝, 避免脚本层保留的引用绕过代理修改数据
+				if t, ok := newValue.(*lua.LTable); ok && (persistTableTarget(t) != nil || ent.isPersistTableProp(propName)) {
+					newValue = persistTablePlain(t)
+				}
 				switch typeName {
 				case dataTypeNameStruct:
-					value := luaL.GetField(entTable, propName).(*lua.LTable)
+					value := luaL.GetField(ent.propsTable, propName).(*lua.LTable)
 					if err := dt.(*dtStruct).AssignToStruct(value, newValue); err != nil {
 						log.Errorf("value[%s](type[%s]) cannot set to prop[%s] error: %s stack info: %s", newValue, newValue.Type().String(), propName, err.Error(), GetLuaTraceback())
 						return 0
@@ -193,6 +294,7 @@ func (em *entityManager) genMetaTable(name string) *lua.LTable {
 					newValue = lua.LNumber(dt.ParseFromLua(newValue).(float64))
 				}
 				L.SetField(ent.propsTable, propName, newValue)
+				ent.onPersistPropChanged(propName)
 				if ent.def.isSyncClientProp(propName) {
 					ent.onSyncPropChanged(propName, newValue, dt)
 				}
@@ -271,6 +373,11 @@ func (em *entityManager) UpdateEntityConnInfo(mailbox *ClientMailBox, entityId E
 		return fmt.Errorf("%s cannot has client", ent.String())
 	}
 
+	em.addEntityConn(mailbox, entityId)
+	return ent.setClient(mailbox, primary)
+}
+
+func (em *entityManager) addEntityConn(mailbox *ClientMailBox, entityId EntityIdType) {
 	if _, ok := em.connMap[mailbox.GateName]; ok == false {
 		em.connMap[mailbox.GateName] = make(clientIdToEntitiesMap)
 	}
@@ -278,7 +385,17 @@ func (em *entityManager) UpdateEntityConnInfo(mailbox *ClientMailBox, entityId E
 		em.connMap[mailbox.GateName][mailbox.ClientId] = make(entityIdMap)
 	}
 	em.connMap[mailbox.GateName][mailbox.ClientId][entityId] = true
-	return ent.setClient(mailbox, primary)
+}
+
+func (em *entityManager) removeEntityConn(mailbox *ClientMailBox, entityId EntityIdType) {
+	if clientsMap, ok := em.connMap[mailbox.GateName]; ok {
+		if entityMap, ok := clientsMap[mailbox.ClientId]; ok {
+			delete(entityMap, entityId)
+			if len(entityMap) == 0 {
+				delete(clientsMap, mailbox.ClientId)
+			}
+		}
+	}
 }
 
 func (em *entityManager) RemoveEntityConnInfo(gateName string, clientId ConnectIdType) {
@@ -296,6 +413,32 @@ func (em *entityManager) RemoveEntityConnInfo(gateName string, clientId ConnectI
 	}
 }
 
+/*
+ResumeEntityConnInfo 客户端断线重连, 原连接绑定的entity转移到新连接, 不触发on_lose_client/on_get_client
+
+resync: 客户端需要全量同步, 重新下发entity创建消息
+*/
+func (em *entityManager) ResumeEntityConnInfo(gateName string, oldClientId, newClientId ConnectIdType, resync bool) error {
+	clientsMap, ok := em.connMap[gateName]
+	if !ok || len(clientsMap[oldClientId]) == 0 {
+		return fmt.Errorf("client[%s:%d] has no entity", gateName, oldClientId)
+	}
+	entityMap := clientsMap[oldClientId]
+	delete(clientsMap, oldClientId)
+	mailbox := &ClientMailBox{GateName: gateName, ClientId: newClientId}
+	resumed := 0
+	for entityId := range entityMap {
+		if ent := em.GetEntityById(entityId); ent != nil && ent.resumeClient(oldClientId, mailbox, resync) {
+			em.addEntityConn(mailbox, entityId)
+			resumed++
+		}
+	}
+	if resumed == 0 {
+		return fmt.Errorf("client[%s:%d] has no entity to resume", gateName, oldClientId)
+	}
+	return nil
+}
+
 func (em *entityManager) RemoveGateEntitiesConn(gateName string) {
 	if clientsMap, ok := em.connMap[gateName]; ok {
 		for _, entityMap := range clientsMap {
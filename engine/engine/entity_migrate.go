@@ -0,0 +1,317 @@
+package engine
+
+import (
+	"fmt"
+	lua "github.com/seasondi/gopher-lua"
+	"time"
+)
+
+// dataVersion 存盘数据的版本号, 未记录版本号的数据视为版本0
+func dataVersion(data map[string]interface{}) int {
+	switch v := data[MongoFieldVersion].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+/*
+migrateData 将低版本的存盘数据迁移到def当前版本, 返回迁移后的数据
+1. 按def中属性的RenameFrom将旧字段改名, 旧字段在下次存盘时删除
+2. 按def中属性的ConvertFrom将旧类型的值转换为新类型
+3. 调用脚本层on_migrate(self, oldVersion, data), 脚本可直接修改data, 从data中删除的字段在下次存盘时删除
+RenameFrom与ConvertFrom只对版本号低于规则Version的数据生效
+*/
+func (e *entity) migrateData(oldVersion int, data map[string]interface{}) map[string]interface{} {
+	log.Infof("%s migrate data from version %d to %d", e.String(), oldVersion, e.def.volatile.version)
+	for _, field := range migrateProps(e.String(), oldVersion, data, e.def.properties) {
+		e.obsoleteFields[field] = true
+	}
+
+	if luaL.GetField(e.luaEntity, onEntityMigrate).Type() == lua.LTNil {
+		return data
+	}
+	t := MapToTable(data)
+	if err := CallLuaMethodByName(e.luaEntity, onEntityMigrate, 0, e.luaEntity, lua.LNumber(oldVersion), t); err != nil {
+		log.Errorf("%s %s error: %s", e.String(), onEntityMigrate, err.Error())
+		return data
+	}
+	r := make(map[string]interface{})
+	for k, v := t.Next(lua.LNil); k != lua.LNil; k, v = t.Next(k) {
+		if key, ok := k.(lua.LString); ok {
+			r[string(key)] = luaValueToInterface(v)
+		}
+	}
+	for _, field := range removedFields(data, r) {
+		e.obsoleteFields[field] = true
+	}
+	return r
+}
+
+// migrateProps 对版本号为oldVersion的数据执行def中的改名与类型转换规则, 返回需要从存盘数据中删除的旧字段
+func migrateProps(owner string, oldVersion int, data map[string]interface{}, props map[string]propertyInfo) []string {
+	var obsolete []string
+	for name, prop := range props {
+		if oldName := prop.config.RenameFrom; oldName != "" && oldVersion < prop.config.RenameVersion {
+			if value, ok := data[oldName]; ok {
+				if _, exist := data[name]; !exist {
+					data[name] = value
+				}
+				delete(data, oldName)
+				obsolete = append(obsolete, oldName)
+			}
+		}
+		if prop.convertDt != nil && oldVersion < prop.config.ConvertVersion {
+			if value, ok := data[name]; ok {
+				if converted, err := convertPropValue(value, prop.convertDt, prop.dt); err != nil {
+					log.Warnf("%s migrate prop[%s] from %s to %s failed: %s, use default value", owner, name, prop.convertDt.Type(), prop.dt.Type(), err.Error())
+					delete(data, name)
+				} else {
+					data[name] = converted
+				}
+			}
+		}
+	}
+	return obsolete
+}
+
+// removedFields 脚本迁移时从数据中删除的字段, 不包含存盘保留字段
+func removedFields(before, after map[string]interface{}) []string {
+	var removed []string
+	for name := range before {
+		if _, ok := after[name]; ok {
+			continue
+		}
+		if isEntityReserveProp(name) || name == MongoPrimaryId || name == MongoFieldVersion {
+			continue
+		}
+		removed = append(removed, name)
+	}
+	return removed
+}
+
+// convertPropValue 将存盘数据按旧类型解析后转换为新类型的值
+func convertPropValue(value interface{}, from, to dataType) (interface{}, error) {
+	old := from.ParseToLua(value)
+	if t, ok := old.(*lua.LTable); ok {
+		//table之间的转换交给新类型解析
+		return luaValueToInterface(t), nil
+	}
+	if old.Type() == lua.LTNil {
+		return nil, fmt.Errorf("value[%v] is not %s", value, from.Type())
+	}
+	v, err := to.ParseDefaultVal(old.String())
+	if err != nil {
+		return nil, err
+	}
+	return luaValueToInterface(v), nil
+}
+
+// 迁移数据中的字段
+const (
+	migrateFieldProps   = "props"   //属性
+	migrateFieldTimers  = "timers"  //脚本层定时器
+	migrateFieldClient  = "client"  //客户端连接
+	migrateFieldPrimary = "primary" //是否是连接的主entity
+
+	migrateTimerFieldId     = "id"     //迁移前的定时器id
+	migrateTimerFieldRemain = "remain" //剩余触发时间, 毫秒
+	migrateTimerFieldRepeat = "repeat" //循环触发间隔, 毫秒
+	migrateTimerFieldMethod = "method" //回调函数名
+	migrateTimerFieldArgs   = "args"   //回调参数
+)
+
+const migratedEntityKeepTime = 30 * time.Second //迁出的entity目标进程记录的保留时间, 期间收到的消息转发到目标进程
+
+// BufferedMessage entity迁移中收到的消息
+type BufferedMessage struct {
+	MsgType  uint8
+	ClientId ConnectIdType
+	Data     []byte
+}
+
+type migratedEntityInfo struct {
+	target string    //迁移的目标game
+	expire time.Time //记录过期时间
+}
+
+func (e *entity) IsMigrating() bool {
+	return e.migrating
+}
+
+// BufferMessage 迁移中收到的消息先缓存
+func (e *entity) BufferMessage(msgType uint8, clientId ConnectIdType, data []byte) {
+	e.bufferedMessages = append(e.bufferedMessages, &BufferedMessage{MsgType: msgType, ClientId: clientId, Data: data})
+}
+
+/*
+BeginMigrate 开始迁移, 返回entity的迁移数据
+迁移数据包含全部属性、未触发的脚本定时器以及客户端连接信息
+迁移期间收到的消息与触发的脚本定时器先暂存, 由EndMigrate处理
+*/
+func (e *entity) BeginMigrate() ([]byte, error) {
+	if e.status != EntityReady {
+		return nil, fmt.Errorf("%s status %d cannot migrate", e.String(), e.status)
+	}
+	if e.migrating {
+		return nil, fmt.Errorf("%s is migrating", e.String())
+	}
+	if e.def.volatile.isStub {
+		return nil, fmt.Errorf("%s is stub, cannot migrate", e.String())
+	}
+
+	props := map[string]interface{}{
+		MongoFieldName:    e.entityName,
+		MongoFieldVersion: e.def.volatile.version,
+	}
+	for name, prop := range e.def.properties {
+		props[name] = e.persistValue(name, prop)
+	}
+
+	now := time.Now().UnixMilli()
+	timers := make([]interface{}, 0, len(e.scriptTimerIds))
+	for timerId := range e.scriptTimerIds {
+		tm := GetTimer().getTimer(timerId)
+		if tm == nil || !tm.IsActive() {
+			continue
+		}
+		//参数格式: entityId, 回调函数名, 回调参数..., 定时器id
+		params := tm.Params()
+		args := make([]interface{}, 0, len(params)-3)
+		for _, v := range params[2 : len(params)-1] {
+			if v == lua.LNil {
+				args = append(args, LuaTableValueNilField)
+			} else {
+				args = append(args, luaValueToInterface(v.(lua.LValue)))
+			}
+		}
+		remain := tm.Expiration() - now
+		if remain < 0 {
+			remain = 0
+		}
+		timers = append(timers, map[string]interface{}{
+			migrateTimerFieldId:     timerId,
+			migrateTimerFieldRemain: remain,
+			migrateTimerFieldRepeat: tm.RepeatDuration().Milliseconds(),
+			migrateTimerFieldMethod: params[1].(lua.LString).String(),
+			migrateTimerFieldArgs:   args,
+		})
+	}
+
+	data := map[string]interface{}{
+		migrateFieldProps:  props,
+		migrateFieldTimers: timers,
+	}
+	if e.client != nil {
+		data[migrateFieldClient] = map[string]interface{}{
+			clientMailBoxFieldGateName: e.client.mailbox.GateName,
+			clientMailBoxFieldClientId: e.client.mailbox.ClientId,
+			migrateFieldPrimary:        e.client.primary,
+		}
+	}
+	buf, err := GetProtocol().Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	e.migrating = true
+	log.Infof("%s begin migrate, props: %d, timers: %d", e.String(), len(props), len(timers))
+	return buf, nil
+}
+
+/*
+EndMigrate 迁移结束, 返回迁移中缓存的消息
+
+success: 迁移成功时entity在本进程移除, 缓存的消息由调用方转发给目标进程; 失败时补发迁移中触发的定时器, 缓存的消息由调用方本地处理
+
+target: 迁移的目标game
+*/
+func (e *entity) EndMigrate(success bool, target string) []*BufferedMessage {
+	if !e.migrating {
+		return nil
+	}
+	e.migrating = false
+	messages := e.bufferedMessages
+	e.bufferedMessages = nil
+	deferred := e.deferredTimers
+	e.deferredTimers = nil
+
+	if !success {
+		log.Infof("%s migrate to %s failed, resume. buffered messages: %d", e.String(), target, len(messages))
+		for _, params := range deferred {
+			e.callScriptTimer(params)
+		}
+		return messages
+	}
+
+	_ = CallLuaMethodByName(e.luaEntity, onEntityMigratedOut, 0, e.luaEntity, lua.LString(target))
+	e.cancelAllTimers()
+	e.delCheckHeartBeatTimer()
+	GetAoiManager().Leave(e)
+	if e.client != nil {
+		//目标进程已重新绑定连接, 这里不再通知gate解绑
+		GetEntityManager().removeEntityConn(&e.client.mailbox, e.entityId)
+		luaL.SetField(e.luaEntity, entityFieldClient, lua.LNil)
+		e.client = nil
+	}
+	GetEntityManager().unRegisterEntity(e)
+	GetEntityManager().addMigratedEntity(e.entityId, target)
+	//redis中的位置信息已由目标进程更新, 不需要移除
+	e.status = EntityDestroyed
+	log.Infof("%s migrate to %s success, buffered messages: %d", e.String(), target, len(messages))
+	return messages
+}
+
+// completeMigratedEntity 迁入的entity恢复定时器与客户端连接, 更新redis中的位置信息
+func (e *entity) completeMigratedEntity(data map[string]interface{}) error {
+	if err := e.registerSelf(); err != nil {
+		return err
+	}
+	e.status = EntityReady
+
+	timerIds := luaL.NewTable()
+	timers, _ := data[migrateFieldTimers].([]interface{})
+	for _, item := range timers {
+		info, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		method, _ := info[migrateTimerFieldMethod].(string)
+		params := []interface{}{e.entityId, lua.LString(method)}
+		args, _ := info[migrateTimerFieldArgs].([]interface{})
+		for _, arg := range args {
+			params = append(params, InterfaceToLValue(arg))
+		}
+		remain := time.Duration(InterfaceToInt(info[migrateTimerFieldRemain])) * time.Millisecond
+		repeat := time.Duration(InterfaceToInt(info[migrateTimerFieldRepeat])) * time.Millisecond
+		timerId := e.addScriptTimer(remain, repeat, params...)
+		timerIds.RawSet(lua.LNumber(InterfaceToInt(info[migrateTimerFieldId])), lua.LNumber(timerId))
+	}
+
+	if info, ok := data[migrateFieldClient].(map[string]interface{}); ok {
+		gateName, _ := info[clientMailBoxFieldGateName].(string)
+		mb := &ClientMailBox{GateName: gateName, ClientId: ConnectIdType(InterfaceToInt(info[clientMailBoxFieldClientId]))}
+		primary, _ := info[migrateFieldPrimary].(bool)
+		GetEntityManager().addEntityConn(mb, e.entityId)
+		e.client = &EntityClient{mailbox: *mb, primary: primary}
+		if primary {
+			e.addCheckHeartbeatTimer()
+		}
+		e.sendClientBindInfo(false)
+		if err := e.initClientEntity(); err != nil {
+			log.Errorf("%s create client entity after migrate error: %s", e.String(), err.Error())
+		}
+		e.addSaveTimer()
+	} else if e.def.volatile.persistent {
+		e.destroyTimerId = e.addEntityTimer(time.Duration(cfg.SaveInterval)*time.Minute, 0, e.destroyTimerCb)
+	}
+
+	//定时器id在迁移后发生变化, 通过旧id->新id的映射通知脚本层
+	_ = CallLuaMethodByName(e.luaEntity, onEntityMigratedIn, 0, e.luaEntity, timerIds)
+	return nil
+}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestMigrateProps(t *testing.T) {
+	if err := loadDefsForTool("../../scripts", STRobot); err != nil {
+		t.Fatalf("load defs error: %s", err.Error())
+	}
+	//level在版本2由lv改名, 在版本3由STRING改为UINT32
+	from := propType{typeName: dataTypeNameString}
+	config := propertyDef{
+		Type:           propType{name: "level", typeName: dataTypeNameUint32},
+		RenameFrom:     "lv",
+		RenameVersion:  2,
+		ConvertFrom:    &from,
+		ConvertVersion: 3,
+	}
+	dt, err := dataTypeMgr.NewDataTypeFromPropDef(config)
+	if err != nil {
+		t.Fatalf("new level dataType error: %s", err.Error())
+	}
+	convertDt, err := dataTypeMgr.NewDataTypeFromPropDef(propertyDef{Type: from})
+	if err != nil {
+		t.Fatalf("new convert dataType error: %s", err.Error())
+	}
+	props := map[string]propertyInfo{"level": {config: config, dt: dt, convertDt: convertDt}}
+
+	tests := []struct {
+		name     string
+		version  int
+		data     map[string]interface{}
+		want     map[string]interface{}
+		obsolete []string
+	}{
+		{"rename and convert", 0, map[string]interface{}{"lv": "5"}, map[string]interface{}{"level": 5}, []string{"lv"}},
+		{"renamed field kept when new exists", 1, map[string]interface{}{"lv": "5", "level": "6"}, map[string]interface{}{"level": 6}, []string{"lv"}},
+		{"rename skipped at rule version", 2, map[string]interface{}{"lv": 7, "level": "9"}, map[string]interface{}{"lv": 7, "level": 9}, nil},
+		{"no rules at current version", 3, map[string]interface{}{"lv": 7, "level": 4}, map[string]interface{}{"lv": 7, "level": 4}, nil},
+		{"convert failed use default", 2, map[string]interface{}{"level": "abc"}, map[string]interface{}{}, nil},
+		{"missing field untouched", 0, map[string]interface{}{"other": 1}, map[string]interface{}{"other": 1}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obsolete := migrateProps("test", tt.version, tt.data, props)
+			if !reflect.DeepEqual(obsolete, tt.obsolete) {
+				t.Fatalf("obsolete = %v, want %v", obsolete, tt.obsolete)
+			}
+			if len(tt.data) != len(tt.want) {
+				t.Fatalf("data = %v, want %v", tt.data, tt.want)
+			}
+			for k, v := range tt.want {
+				if InterfaceToInt(tt.data[k]) != InterfaceToInt(v) {
+					t.Fatalf("data[%s] = %v, want %v", k, tt.data[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestRemovedFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		before map[string]interface{}
+		after  map[string]interface{}
+		want   []string
+	}{
+		{"nothing removed", map[string]interface{}{"a": 1}, map[string]interface{}{"a": 2}, nil},
+		{"field removed", map[string]interface{}{"a": 1, "b": 2}, map[string]interface{}{"a": 1}, []string{"b"}},
+		{"field added", map[string]interface{}{"a": 1}, map[string]interface{}{"a": 1, "c": 3}, nil},
+		{"reserved fields kept", map[string]interface{}{MongoPrimaryId: 1, MongoFieldId: 1, MongoFieldName: "Avatar", MongoFieldVersion: 1, "a": 1}, map[string]interface{}{}, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := removedFields(tt.before, tt.after)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("removedFields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
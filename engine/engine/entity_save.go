@@ -6,8 +6,10 @@ var entitySaveMgr *EntitySaveManager
 
 type EntitySaveInfo struct {
 	EntityId     EntityIdType //玩家ID
-	Data         []byte       //存盘信息
-	NeedResponse bool         //是否需要数据库操作完成回调
+	Data         []byte       //存盘信息, $set/$unset格式的更新文档
+	NeedResponse bool         //是否是销毁时的存盘, 写入成功后销毁entity
+	Full         bool         //是否是全量存盘
+	Seq          uint64       //entity生成存盘信息的序号
 }
 
 type EntitySaveManager struct {
@@ -29,12 +31,22 @@ func (m *EntitySaveManager) init() {
 }
 
 func (m *EntitySaveManager) Add(save *EntitySaveInfo) {
-	if save != nil {
-		el := m.saveList.PushBack(save)
-		m.entityInfo[save.EntityId] = el
+	if save == nil {
+		return
 	}
+	//同一个entity只保留最新的存盘信息, 新的存盘信息已包含之前未发出的字段, 替换未发出的全量存盘时entity已按全量生成
+	if el, ok := m.entityInfo[save.EntityId]; ok {
+		old := el.Value.(*EntitySaveInfo)
+		save.NeedResponse = save.NeedResponse || old.NeedResponse
+		save.Full = save.Full || old.Full
+		el.Value = save
+		return
+	}
+	el := m.saveList.PushBack(save)
+	m.entityInfo[save.EntityId] = el
 }
 
+// Remove 存盘信息已发往db, 由db返回的结果通知entity
 func (m *EntitySaveManager) Remove(entityId EntityIdType) {
 	if el, ok := m.entityInfo[entityId]; ok {
 		m.saveList.Remove(el)
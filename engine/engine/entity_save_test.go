@@ -0,0 +1,207 @@
+package engine
+
+import (
+	"errors"
+	lua "github.com/seasondi/gopher-lua"
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestEntitySaveManagerAdd(t *testing.T) {
+	tests := []struct {
+		name         string
+		saves        []EntitySaveInfo
+		wantFull     bool
+		wantResponse bool
+		wantData     string
+	}{
+		{"single partial", []EntitySaveInfo{{Data: []byte("a")}}, false, false, "a"},
+		{"full replaced by partial", []EntitySaveInfo{{Data: []byte("a"), Full: true}, {Data: []byte("b")}}, true, false, "b"},
+		{"partial replaced by full", []EntitySaveInfo{{Data: []byte("a")}, {Data: []byte("b"), Full: true}}, true, false, "b"},
+		{"partial replaced by partial", []EntitySaveInfo{{Data: []byte("a")}, {Data: []byte("b")}}, false, false, "b"},
+		{"response kept", []EntitySaveInfo{{Data: []byte("a"), NeedResponse: true}, {Data: []byte("b")}}, false, true, "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &EntitySaveManager{}
+			m.init()
+			for i := range tt.saves {
+				save := tt.saves[i]
+				save.EntityId = 1
+				m.Add(&save)
+			}
+			if m.Length() != 1 {
+				t.Fatalf("length = %d, want 1", m.Length())
+			}
+			got := m.Get(1)[0]
+			if got.Full != tt.wantFull || got.NeedResponse != tt.wantResponse || string(got.Data) != tt.wantData {
+				t.Fatalf("save = (full %v, response %v, data %s), want (full %v, response %v, data %s)",
+					got.Full, got.NeedResponse, got.Data, tt.wantFull, tt.wantResponse, tt.wantData)
+			}
+		})
+	}
+}
+
+// newPersistTestEntity 创建带table类存盘属性的entity, bag为map[STRING]UINT32, items为table
+func newPersistTestEntity(t *testing.T) *entity {
+	if err := loadDefsForTool("../../scripts", STRobot); err != nil {
+		t.Fatalf("load defs error: %s", err.Error())
+	}
+	registerPersistTableApi()
+	cfg.FullSaveInterval = 30
+	newProp := func(pt propType) propertyInfo {
+		config := propertyDef{Type: pt, Persistent: true}
+		dt, err := dataTypeMgr.NewDataTypeFromPropDef(config)
+		if err != nil {
+			t.Fatalf("new dataType[%s] error: %s", pt.typeName, err.Error())
+		}
+		return propertyInfo{config: config, dt: dt}
+	}
+	key := propType{typeName: dataTypeNameString}
+	value := propType{typeName: dataTypeNameUint32}
+	e := &entity{
+		entityId:   1,
+		entityName: "Avatar",
+		def: &entityDef{volatile: volatileDef{persistent: true}, properties: map[string]propertyInfo{
+			"bag":   newProp(propType{typeName: dataTypeNameMap, keyType: &key, valueType: &value}),
+			"items": newProp(propType{typeName: dataTypeNameTable}),
+			"level": newProp(propType{typeName: dataTypeNameUint32}),
+		}},
+		persistDirty:   make(map[string]bool),
+		pendingSave:    make(map[string]bool),
+		persistProxies: make(map[string]*lua.LTable),
+		obsoleteFields: make(map[string]bool),
+		propsTable:     luaL.NewTable(),
+	}
+	if err := luaL.DoString(`return {a = 1}, {{count = 1}, {count = 2}}`); err != nil {
+		t.Fatalf("init props error: %s", err.Error())
+	}
+	e.propsTable.RawSetString("bag", luaL.Get(-2))
+	e.propsTable.RawSetString("items", luaL.Get(-1))
+	luaL.Pop(2)
+	e.lastFullSaveTime = time.Now()
+	oldMgr := entityMgr
+	entityMgr = new(entityManager)
+	entityMgr.init()
+	entityMgr.allEntities[e.entityId] = e
+	t.Cleanup(func() { entityMgr = oldMgr })
+	return e
+}
+
+func TestPersistTableDirty(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		dirty  []string
+		set    []string
+		unset  []string
+	}{
+		{"read only", `local n = bag.a + #items + items[1].count; for k, v in pairs(bag) do n = n + v end; for i, v in ipairs(items) do n = n + v.count end`, nil, nil, nil},
+		{"same value", `bag.a = 1`, nil, nil, nil},
+		{"map key set", `bag.b = 2`, []string{"bag.b"}, []string{"bag.b"}, nil},
+		{"map key removed", `bag.a = nil`, []string{"bag.a"}, nil, []string{"bag.a"}},
+		{"nested write", `items[2].count = 5`, []string{"items." + numberToMapKey(2)}, []string{"items." + numberToMapKey(2)}, nil},
+		{"nested write by pairs", `for k, v in pairs(items) do if k == 1 then v.count = 3 end end`, []string{"items." + numberToMapKey(1)}, []string{"items." + numberToMapKey(1)}, nil},
+		{"nested write by ipairs", `for i, v in ipairs(items) do if i == 2 then v.count = 3 end end`, []string{"items." + numberToMapKey(2)}, []string{"items." + numberToMapKey(2)}, nil},
+		{"table insert", `table.insert(items, {count = 3})`, []string{"items"}, []string{"items"}, nil},
+		{"assign nested table", `local t = {count = 1}; items[3] = t; t.count = 9`, []string{"items." + numberToMapKey(3)}, []string{"items." + numberToMapKey(3)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newPersistTestEntity(t)
+			luaL.SetGlobal("bag", e.persistTableProxy("bag", e.propsTable.RawGetString("bag")))
+			luaL.SetGlobal("items", e.persistTableProxy("items", e.propsTable.RawGetString("items")))
+			if err := luaL.DoString(tt.script); err != nil {
+				t.Fatalf("run script error: %s", err.Error())
+			}
+			var dirty []string
+			for field := range e.persistDirty {
+				dirty = append(dirty, field)
+			}
+			sort.Strings(dirty)
+			if !reflect.DeepEqual(dirty, tt.dirty) {
+				t.Fatalf("dirty = %v, want %v", dirty, tt.dirty)
+			}
+			info := e.genSaveInfo(false)
+			if len(tt.set) == 0 && len(tt.unset) == 0 {
+				if info != nil {
+					t.Fatalf("save info generated without changes")
+				}
+				return
+			}
+			update := bson.M{}
+			if err := bson.Unmarshal(info.Data, &update); err != nil {
+				t.Fatalf("unmarshal save info error: %s", err.Error())
+			}
+			if got := updateFields(update, "$set"); !reflect.DeepEqual(got, tt.set) {
+				t.Fatalf("$set = %v, want %v", got, tt.set)
+			}
+			if got := updateFields(update, "$unset"); !reflect.DeepEqual(got, tt.unset) {
+				t.Fatalf("$unset = %v, want %v", got, tt.unset)
+			}
+		})
+	}
+	e := newPersistTestEntity(t)
+	if v := e.persistTableProxy("level", lua.LNumber(1)); v != lua.LNumber(1) {
+		t.Fatalf("scalar prop wrapped as proxy: %v", v)
+	}
+}
+
+// updateFields 存盘信息中$set或$unset的字段, 不含entity固定写入的字段
+func updateFields(update bson.M, op string) []string {
+	doc, _ := update[op].(bson.M)
+	var r []string
+	for field := range doc {
+		if field == MongoFieldId || field == MongoFieldName || field == MongoFieldVersion {
+			continue
+		}
+		r = append(r, field)
+	}
+	sort.Strings(r)
+	return r
+}
+
+func TestEntityOnSaveResult(t *testing.T) {
+	tests := []struct {
+		name        string
+		info        EntitySaveInfo
+		err         error
+		wantPending bool
+		wantDirty   bool
+		wantFull    bool
+		wantFullAt  bool
+	}{
+		{"latest success", EntitySaveInfo{Seq: 2}, nil, false, false, false, false},
+		{"latest full success", EntitySaveInfo{Seq: 2, Full: true}, nil, false, false, false, true},
+		{"older success keeps pending", EntitySaveInfo{Seq: 1}, nil, true, false, true, false},
+		{"failure restores dirty", EntitySaveInfo{Seq: 2}, errors.New("timeout"), true, true, true, false},
+		{"older failure restores dirty", EntitySaveInfo{Seq: 1}, errors.New("timeout"), true, true, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newPersistTestEntity(t)
+			e.lastFullSaveTime = time.Time{}
+			e.saveSeq = 2
+			e.pendingSave = map[string]bool{"bag.a": true}
+			e.pendingFull = true
+			e.obsoleteFields = map[string]bool{"old": true}
+			info := tt.info
+			e.OnSaveResult(&info, tt.err)
+			if got := e.pendingSave["bag.a"] && e.obsoleteFields["old"]; got != tt.wantPending {
+				t.Fatalf("pending = %v, want %v", got, tt.wantPending)
+			}
+			if got := e.persistDirty["bag.a"]; got != tt.wantDirty {
+				t.Fatalf("dirty = %v, want %v", got, tt.wantDirty)
+			}
+			if e.pendingFull != tt.wantFull {
+				t.Fatalf("pendingFull = %v, want %v", e.pendingFull, tt.wantFull)
+			}
+			if got := !e.lastFullSaveTime.IsZero(); got != tt.wantFullAt {
+				t.Fatalf("lastFullSaveTime set = %v, want %v", got, tt.wantFullAt)
+			}
+		})
+	}
+}
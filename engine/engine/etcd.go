@@ -13,6 +13,9 @@ import (
 	"time"
 )
 
+// ErrEtcdKeyExist Claim时key已被其他进程注册
+var ErrEtcdKeyExist = errors.New("etcd key already exist")
+
 func initEtcd() error {
 	etcdMgr = new(etcd)
 	if err := etcdMgr.init(); err != nil {
@@ -84,7 +87,8 @@ func NewEtcdKV(key string, value EtcdValue) *EtcdKV {
 }
 
 type etcd struct {
-	cli *clientV3.Client
+	cli    *clientV3.Client
+	server *etcdLeaseResult //本进程注册的租约
 }
 
 func (m *etcd) init() error {
@@ -202,6 +206,57 @@ func (m *etcd) Watch(handle EtcdWatchHandle, opts ...clientV3.OpOption) {
 	log.Info("stop watch key: ", handle.Key())
 }
 
+// Claim 在事务中仅当key不存在时写入带租约的key, 多个进程同时注册时只有一个成功, key已存在时返回ErrEtcdKeyExist
+// 与Register不同, 租约失效后不会重新注册, 避免覆盖其他进程在此期间的注册
+func (m *etcd) Claim(ctx context.Context, ttl int64, data *EtcdKV) (*etcdLeaseResult, error) {
+	if data == nil {
+		return nil, errors.New("etcd claim data nil")
+	}
+	lease := clientV3.NewLease(m.cli)
+	rsp, err := lease.Grant(ctx, ttl)
+	if err != nil {
+		_ = lease.Close()
+		return nil, err
+	}
+	txn, err := m.cli.Txn(ctx).
+		If(clientV3.Compare(clientV3.CreateRevision(data.Key()), "=", 0)).
+		Then(clientV3.OpPut(data.Key(), data.ValueJson(), clientV3.WithLease(rsp.ID))).
+		Commit()
+	if err == nil && !txn.Succeeded {
+		err = ErrEtcdKeyExist
+	}
+	var keepChan <-chan *clientV3.LeaseKeepAliveResponse
+	if err == nil {
+		keepChan, err = lease.KeepAlive(context.TODO(), rsp.ID)
+	}
+	if err != nil {
+		revokeCtx, cancel := context.WithTimeout(context.TODO(), time.Second)
+		_, _ = lease.Revoke(revokeCtx, rsp.ID)
+		cancel()
+		_ = lease.Close()
+		return nil, err
+	}
+	log.Infof("claim etcd key success, ttl: %d, info: %+v", ttl, *data)
+	result := &etcdLeaseResult{lease: lease}
+	go func() {
+		for range keepChan {
+		}
+		if !result.stopped.Load() {
+			log.Errorf("etcd claim of key[%s] lost", data.Key())
+		}
+	}()
+	return result, nil
+}
+
+// Elect 原子地注册带租约的key进行竞选, key已存在时竞选失败, 成功时返回释放函数, 释放后key在租约到期后删除
+func (m *etcd) Elect(ctx context.Context, key string, ttl int64) (func(), error) {
+	r, err := m.Claim(ctx, ttl, NewEtcdKV(key, EtcdValue{EtcdValueServer: ServiceName()}))
+	if err != nil {
+		return nil, err
+	}
+	return r.Close, nil
+}
+
 func (m *etcd) Get(ctx context.Context, key string, opts ...clientV3.OpOption) []EtcdKV {
 	r := make([]EtcdKV, 0)
 	rsp, err := m.cli.Get(ctx, key, opts...)
@@ -239,10 +294,15 @@ func (m *etcd) RegisterServer() error {
 		EtcdValueAddr:   GetConfig().ServerConfig().Addr,
 		EtcdValueType:   EtcdTypeServer,
 		EtcdValueIsStub: GetConfig().ServerConfig().IsStub,
+		EtcdValueInner:  GetConfig().ServerConfig().IsInner,
+	}
+	if gSvrType == STGate && !GetConfig().ServerConfig().IsInner {
+		val[EtcdValuePublic] = GetConfig().PublicAddrs()
 	}
 	kv := NewEtcdKV(ServiceName(), val)
 	retryCount := 0
-	for _, err := m.Register(ctx, EtcdServerLeaseTTL, kv); err != nil; _, err = m.Register(ctx, EtcdServerLeaseTTL, kv) {
+	r, err := m.Register(ctx, EtcdServerLeaseTTL, kv)
+	for ; err != nil; r, err = m.Register(ctx, EtcdServerLeaseTTL, kv) {
 		if strings.Contains(err.Error(), "already exist") {
 			log.Infof("wait for server register to etcd")
 			time.Sleep(time.Second)
@@ -255,9 +315,21 @@ func (m *etcd) RegisterServer() error {
 			return err
 		}
 	}
+	m.server = r
 	return nil
 }
 
+// UnregisterServer 停止本进程的续约并删除注册信息, 其他进程将不再发现本进程
+func (m *etcd) UnregisterServer() error {
+	if m.server != nil {
+		m.server.Close()
+		m.server = nil
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	return m.Delete(ctx, ServiceName())
+}
+
 func GetEtcdPrefixWithServer(prefix string) string {
 	if !strings.HasSuffix(prefix, ".") {
 		prefix += "."
@@ -282,10 +354,25 @@ func ParseEtcdServerKey(s string) (string, ServerIdType, ServerTagType, error) {
 	return r[0] + ".", ServerIdType(serverId), ServerTagType(serverTag), nil
 }
 
+// GetEtcdGateDrainKey 通知gate排空的key, 由admin写入
+func GetEtcdGateDrainKey(gateName string) string {
+	return GateDrainPrefix + gateName
+}
+
 func GetEtcdStubKey(id EntityIdType) string {
 	return fmt.Sprintf("%s%d.%d", StubPrefix, GetConfig().ServerId, id)
 }
 
+// GetEtcdStubShardKey 分片stub占用分片的key, 同一分片只能由一个entity持有
+func GetEtcdStubShardKey(name string, index int) string {
+	return fmt.Sprintf("%s%d.%s.%d", StubShardPrefix, GetConfig().ServerId, name, index)
+}
+
+// GetEtcdStubFailoverKey 重建stub时竞选使用的key
+func GetEtcdStubFailoverKey(name string) string {
+	return fmt.Sprintf("%s%d.%s", StubFailoverPrefix, GetConfig().ServerId, name)
+}
+
 // ParseEtcdStubKey 返回值: 前缀,服务器ID,entityId,err
 func ParseEtcdStubKey(s string) (string, ServerIdType, EntityIdType, error) {
 	r := strings.Split(s, ".")
@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"github.com/xtaci/kcp-go/v5"
+	"net"
+)
+
+const (
+	kcpWindowSize = 256 //收发窗口大小
+	kcpInterval   = 10  //内部刷新间隔, 单位: 毫秒
+	kcpResend     = 2   //快速重传的ack跨越次数
+)
+
+// SetKcpSessionOptions kcp会话统一使用极速模式, gate与robot两端保持一致
+func SetKcpSessionOptions(sess *kcp.UDPSession) {
+	sess.SetStreamMode(true)
+	sess.SetNoDelay(1, kcpInterval, kcpResend, 1)
+	sess.SetWindowSize(kcpWindowSize, kcpWindowSize)
+	sess.SetACKNoDelay(true)
+}
+
+// ListenKcp 监听kcp连接, 不使用kcp自带的加密与FEC, 加密由握手协商的会话负责
+func ListenKcp(addr string) (*kcp.Listener, error) {
+	return kcp.ListenWithOptions(addr, nil, 0, 0)
+}
+
+// DialKcp 建立kcp连接, 可作为TcpClient的拨号函数
+func DialKcp(addr string) (net.Conn, error) {
+	sess, err := kcp.DialWithOptions(addr, nil, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	SetKcpSessionOptions(sess)
+	return sess, nil
+}
@@ -20,6 +20,7 @@ func initLogger() error {
 	log = logBase.WithFields(logrus.Fields{
 		"Name": ServiceName(),
 	})
+	mainLog = TraceLogger(currentTraceId)
 	if cfg.Logger.JsonFormat {
 		logBase.SetFormatter(&logrus.JSONFormatter{
 			DisableHTMLEscape: true,
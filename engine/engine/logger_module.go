@@ -3,7 +3,6 @@ package engine
 import (
 	"fmt"
 	lua "github.com/seasondi/gopher-lua"
-	"github.com/sirupsen/logrus"
 	"strings"
 )
 
@@ -17,8 +16,6 @@ var logExports = map[string]lua.LGFunction{
 	"trace": logTrace,
 }
 
-var scriptLogger *logrus.Entry
-
 func preloadLogger() {
 	luaL.PreloadModule("logger", loggerLoader)
 }
@@ -26,8 +23,6 @@ func preloadLogger() {
 func loggerLoader(L *lua.LState) int {
 	mod := L.SetFuncs(L.NewTable(), logExports)
 	L.Push(mod)
-	scriptLogger = log
-	//scriptLogger = log.WithFields(logrus.Fields{"source": "LUA"})
 	return 1
 }
 
@@ -77,26 +72,26 @@ func getLuaFile(L *lua.LState) string {
 }
 
 func logInfo(L *lua.LState) int {
-	scriptLogger.WithField(fileKey, getLuaFile(L)).Info(getLuaLogMessage(L))
+	MainLogger().WithField(fileKey, getLuaFile(L)).Info(getLuaLogMessage(L))
 	return 0
 }
 
 func logDebug(L *lua.LState) int {
-	scriptLogger.WithField(fileKey, getLuaFile(L)).Debug(getLuaLogMessage(L))
+	MainLogger().WithField(fileKey, getLuaFile(L)).Debug(getLuaLogMessage(L))
 	return 0
 }
 
 func logWarn(L *lua.LState) int {
-	scriptLogger.WithField(fileKey, getLuaFile(L)).Warn(getLuaLogMessage(L))
+	MainLogger().WithField(fileKey, getLuaFile(L)).Warn(getLuaLogMessage(L))
 	return 0
 }
 
 func logError(L *lua.LState) int {
-	scriptLogger.WithField(fileKey, getLuaFile(L)).Error(getLuaLogMessage(L))
+	MainLogger().WithField(fileKey, getLuaFile(L)).Error(getLuaLogMessage(L))
 	return 0
 }
 
 func logTrace(L *lua.LState) int {
-	scriptLogger.WithField(fileKey, getLuaFile(L)).Trace(getLuaLogMessage(L))
+	MainLogger().WithField(fileKey, getLuaFile(L)).Trace(getLuaLogMessage(L))
 	return 0
 }
@@ -64,6 +64,7 @@ func initLuaMachine() error {
 	luaCmdMgr = new(luaCommandMgr)
 	luaCmdMgr.init()
 	registerApiToRegistry()
+	registerPersistTableApi()
 	registerGlobalEntry()
 	registerModuleToLua()
 
@@ -125,7 +126,7 @@ func CallLuaMethod(f *luaMethodInfo, nRet int, args ...lua.LValue) error {
 	defer scriptChecker.setCheckMethod("")
 
 	if err := luaL.CallByParam(luaFunctionWrapper(f.function, nRet), args...); err != nil {
-		log.Warnf("call lua function[%s] failed", f.name)
+		MainLogger().Warnf("call lua function[%s] failed", f.name)
 		return err
 	}
 	return nil
@@ -10,21 +10,45 @@ type mailboxType int
 const (
 	MailBoxTypeEmpty  mailboxType = 0
 	MailBoxTypeClient             = 1
+	MailBoxTypeEntity             = 2
+	MailBoxTypeStub               = 3
 )
 
 const (
-	mailboxFieldType            = "__mbType"
-	clientMailBoxFieldGateName  = "gateName"
-	clientMailBoxFieldClientId  = "clientId"
-	clientMailBoxFieldSendError = "error"
+	mailboxFieldType             = "__mbType"
+	clientMailBoxFieldGateName   = "gateName"
+	clientMailBoxFieldClientId   = "clientId"
+	clientMailBoxFieldSendError  = "error"
+	entityMailBoxFieldEntityId   = "entityId"
+	entityMailBoxFieldEntityName = "entityName"
+	entityMailBoxFieldServer     = "server"
 )
 
+var (
+	entityRpcRouter func(entityId EntityIdType, server string, data []byte) error //发送entity rpc消息, 由game进程注册
+	stubLocator     func(stubName string) EntityIdType                            //根据stub名称查找stub entity id, 由game进程注册
+)
+
+// RegisterEntityRpcRouter 注册entity rpc消息的发送函数, server为entity最后已知的所在进程, 可能为空
+func RegisterEntityRpcRouter(f func(entityId EntityIdType, server string, data []byte) error) {
+	entityRpcRouter = f
+}
+
+// RegisterStubLocator 注册根据stub名称查找stub entity id的函数
+func RegisterStubLocator(f func(stubName string) EntityIdType) {
+	stubLocator = f
+}
+
 func mailBoxTypeString(ty mailboxType) string {
 	switch ty {
 	case MailBoxTypeEmpty:
 		return "empty"
 	case MailBoxTypeClient:
 		return "client"
+	case MailBoxTypeEntity:
+		return "entity"
+	case MailBoxTypeStub:
+		return "stub"
 	default:
 		return "unknown"
 	}
@@ -53,6 +77,13 @@ func (m *ClientMailBox) Send(data []byte) {
 	}
 }
 
+// SendServerError 发送错误提示给客户端, args为错误信息的附加参数
+func (m *ClientMailBox) SendServerError(errMsg string, args ...string) {
+	if data, err := genServerErrorMessage(errMsg, m.ClientId, args...); err == nil {
+		m.Send(data)
+	}
+}
+
 func (m *ClientMailBox) Table() *lua.LTable {
 	t := luaL.NewTable()
 	t.RawSetString(mailboxFieldType, lua.LNumber(MailBoxTypeClient))
@@ -99,6 +130,161 @@ func ClientMailBoxFromLua(t *lua.LTable) *ClientMailBox {
 	return &ClientMailBox{GateName: gateName.String(), ClientId: ConnectIdType(clientId.(lua.LNumber))}
 }
 
+// EntityMailBox entity的通信地址, 脚本层可通过mb:method(args)直接调用def中的服务端函数
+type EntityMailBox struct {
+	EntityId   EntityIdType //entity id
+	EntityName string       //entity名称
+	Server     string       //最后已知的所在进程
+}
+
+func (m *EntityMailBox) String() string {
+	return fmt.Sprintf("EntityMailBox[%s:%d@%s]", m.EntityName, m.EntityId, m.Server)
+}
+
+// Send 发送entity rpc消息, data由GenEntityRpcData生成
+func (m *EntityMailBox) Send(data []byte) {
+	if err := m.send(data); err != nil {
+		log.Warnf("send data to %s error: %s", m.String(), err.Error())
+	}
+}
+
+func (m *EntityMailBox) send(data []byte) error {
+	if entityRpcRouter == nil {
+		return fmt.Errorf("entity rpc router not registered")
+	}
+	return entityRpcRouter(m.EntityId, m.Server, data)
+}
+
+func (m *EntityMailBox) Table() *lua.LTable {
+	return m.table(MailBoxTypeEntity, m)
+}
+
+func (m *EntityMailBox) table(ty mailboxType, caller entityMailBoxCaller) *lua.LTable {
+	t := luaL.NewTable()
+	t.RawSetString(mailboxFieldType, lua.LNumber(ty))
+	t.RawSetString(entityMailBoxFieldEntityId, EntityIdToLua(m.EntityId))
+	t.RawSetString(entityMailBoxFieldEntityName, lua.LString(m.EntityName))
+	t.RawSetString(entityMailBoxFieldServer, lua.LString(m.Server))
+
+	meta := luaL.NewTable()
+	meta.RawSetString("__tostring", luaL.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LString(caller.String()))
+		return 1
+	}))
+	//mb:method(args), 只能访问def中定义的服务端函数
+	meta.RawSetString("__index", luaL.NewFunction(func(L *lua.LState) int {
+		method := L.CheckString(2)
+		def := defMgr.GetEntityDef(m.EntityName)
+		if def == nil || def.getServerMethod(method, true) == nil {
+			L.Push(lua.LNil)
+			return 1
+		}
+		L.Push(L.NewFunction(func(L *lua.LState) int {
+			//1: mailbox自身
+			//2-n: 函数参数
+			args := make([]lua.LValue, 0, L.GetTop())
+			for i := 2; i <= L.GetTop(); i++ {
+				args = append(args, L.Get(i))
+			}
+			if err := caller.Call(method, args); err != nil {
+				log.Errorf("%s call method[%s] error: %s%s", caller.String(), method, err.Error(), GetLuaTraceback())
+			}
+			return 0
+		}))
+		return 1
+	}))
+	luaL.SetMetatable(t, meta)
+	return t
+}
+
+// Call 调用entity的def服务端函数, 参数按目标entity的def检查, entity在本进程时直接调用
+func (m *EntityMailBox) Call(method string, args []lua.LValue) error {
+	def := defMgr.GetEntityDef(m.EntityName)
+	if def == nil {
+		return fmt.Errorf("entity[%s] def not found", m.EntityName)
+	}
+	mi := def.getServerMethod(method, true)
+	if mi == nil {
+		return fmt.Errorf("[%s] is not def server method for entity[%s]", method, m.EntityName)
+	}
+	if err := def.checkServerMethodArgs(mi, args); err != nil {
+		return err
+	}
+	if ent := GetEntityManager().GetEntityById(m.EntityId); ent != nil && !ent.IsMigrating() {
+		return ent.CallDefServerMethod(method, args, false)
+	}
+	data, err := GenEntityRpcData(m.EntityId, method, args)
+	if err != nil {
+		return err
+	}
+	return m.send(data)
+}
+
+// StubMailBox stub的通信地址, 调用时按stub名称查找当前的stub entity
+type StubMailBox struct {
+	EntityMailBox
+}
+
+func (m *StubMailBox) String() string {
+	return fmt.Sprintf("StubMailBox[%s:%d@%s]", m.EntityName, m.EntityId, m.Server)
+}
+
+func (m *StubMailBox) Table() *lua.LTable {
+	return m.table(MailBoxTypeStub, m)
+}
+
+func (m *StubMailBox) Call(method string, args []lua.LValue) error {
+	if stubLocator != nil {
+		//stub重建后entity id会变化, 最后已知的进程也随之失效
+		if id := stubLocator(m.EntityName); id > 0 && id != m.EntityId {
+			m.EntityId = id
+			m.Server = ""
+		}
+	}
+	return m.EntityMailBox.Call(method, args)
+}
+
+type entityMailBoxCaller interface {
+	String() string
+	Call(method string, args []lua.LValue) error
+}
+
+func entityMailBoxFromLua(t *lua.LTable) *EntityMailBox {
+	entityId := t.RawGetString(entityMailBoxFieldEntityId)
+	if entityId.Type() != lua.LTNumber {
+		return nil
+	}
+	entityName := t.RawGetString(entityMailBoxFieldEntityName)
+	if entityName.Type() != lua.LTString {
+		return nil
+	}
+	server := t.RawGetString(entityMailBoxFieldServer)
+	mb := &EntityMailBox{EntityId: EntityIdType(entityId.(lua.LNumber)), EntityName: entityName.String()}
+	if server.Type() == lua.LTString {
+		mb.Server = server.String()
+	}
+	return mb
+}
+
+// GenEntityRpcData 生成调用entity服务端函数的消息内容
+func GenEntityRpcData(entityId EntityIdType, method string, args []lua.LValue) ([]byte, error) {
+	params := []interface{}{method}
+	for _, val := range args {
+		if val.Type() == lua.LTTable {
+			params = append(params, TableToMap(val.(*lua.LTable)))
+		} else if val.Type() == lua.LTNil {
+			params = append(params, LuaTableValueNilField)
+		} else {
+			params = append(params, val)
+		}
+	}
+	dataMap := map[string]interface{}{
+		ClientMsgDataFieldEntityID: entityId,
+		ClientMsgDataFieldArgs:     params,
+	}
+	return GetProtocol().Marshal(dataMap)
+}
+
 func emptyMailBoxTable() *lua.LTable {
 	return mapToMailBoxTable(nil)
 }
@@ -110,7 +296,20 @@ func TableToMailBox(t *lua.LTable) MailBox {
 	}
 	switch mailboxType(ty.(lua.LNumber)) {
 	case MailBoxTypeClient:
-		return ClientMailBoxFromLua(t)
+		if mb := ClientMailBoxFromLua(t); mb != nil {
+			return mb
+		}
+		return nil
+	case MailBoxTypeEntity:
+		if mb := entityMailBoxFromLua(t); mb != nil {
+			return mb
+		}
+		return nil
+	case MailBoxTypeStub:
+		if mb := entityMailBoxFromLua(t); mb != nil {
+			return &StubMailBox{EntityMailBox: *mb}
+		}
+		return nil
 	default:
 		return nil
 	}
@@ -120,6 +319,10 @@ func MailBoxToTable(box MailBox) *lua.LTable {
 	switch b := box.(type) {
 	case *ClientMailBox:
 		return b.Table()
+	case *EntityMailBox:
+		return b.Table()
+	case *StubMailBox:
+		return b.Table()
 	default:
 		return emptyMailBoxTable()
 	}
@@ -38,6 +38,19 @@ func genEntityRpcMessage(msgType uint8, data map[string]interface{}, clientId Co
 	}
 }
 
+//sendToClients 同一条rpc消息发给多个客户端, 消息体只序列化一次
+func sendToClients(data map[string]interface{}, mbs []*ClientMailBox) error {
+	buf, err := GetProtocol().Marshal(data)
+	if err != nil {
+		return err
+	}
+	for _, mb := range mbs {
+		header := GenMessageHeader(ServerMessageTypeEntityRpc, mb.ClientId)
+		mb.Send(GetProtocol().ConcatHeadAndBody(header, buf))
+	}
+	return nil
+}
+
 //genC2SMessage 创建客户端发给服务器的消息
 func genC2SMessage(msgType uint8, data map[string]interface{}) ([]byte, error) {
 	if buf, err := GetProtocol().Marshal(data); err != nil {
@@ -50,7 +63,7 @@ func genC2SMessage(msgType uint8, data map[string]interface{}) ([]byte, error) {
 	}
 }
 
-func genServerErrorMessage(errMsg string, clientId ConnectIdType) ([]byte, error) {
+func genServerErrorMessage(errMsg string, clientId ConnectIdType, args ...string) ([]byte, error) {
 	header := GenMessageHeader(ServerMessageTypeServerError, clientId)
-	return GetProtocol().MessageWithHead(header, &message.ServerError{ErrMsg: errMsg})
+	return GetProtocol().MessageWithHead(header, &message.ServerError{ErrMsg: errMsg, Args: args})
 }
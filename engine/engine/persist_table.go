@@ -0,0 +1,233 @@
+package engine
+
+import lua "github.com/seasondi/gopher-lua"
+
+/*
+存盘的table/map/array/struct属性交给脚本层时包装为代理table, 代理本身为空表, 实际数据仍保存在entity的属性表中
+脚本层通过代理及其嵌套table的写入经__newindex记录到属性的第一层key, 存盘时只写入变化的key
+代理支持#、pairs、ipairs、next、table.insert、table.remove, table库的其他函数需先整体赋值给属性
+*/
+
+// newPersistTable 创建代理, key为嵌套代理所在的属性第一层key, 根代理传nil
+func newPersistTable(target *lua.LTable, owner EntityIdType, propName string, key lua.LValue) *lua.LTable {
+	t := luaL.NewTable()
+	meta := luaL.NewTable()
+	meta.RawSetString(persistTableFieldTarget, target)
+	meta.RawSetString(persistTableFieldOwner, EntityIdToLua(owner))
+	meta.RawSetString(persistTableFieldName, lua.LString(propName))
+	meta.RawSetString(persistTableFieldKey, key)
+	meta.RawSetString("__index", luaL.NewFunction(persistTableIndex))
+	meta.RawSetString("__newindex", luaL.NewFunction(persistTableNewIndex))
+	meta.RawSetString("__len", luaL.NewFunction(persistTableLen))
+	luaL.SetMetatable(t, meta)
+	return t
+}
+
+// persistTableMeta 代理的元表, 不是代理时返回nil
+func persistTableMeta(v lua.LValue) *lua.LTable {
+	t, ok := v.(*lua.LTable)
+	if !ok {
+		return nil
+	}
+	meta, ok := t.Metatable.(*lua.LTable)
+	if !ok {
+		return nil
+	}
+	if _, ok = meta.RawGetString(persistTableFieldTarget).(*lua.LTable); !ok {
+		return nil
+	}
+	return meta
+}
+
+// persistTableTarget 代理对应的实际数据, 不是代理时返回nil
+func persistTableTarget(v lua.LValue) *lua.LTable {
+	if meta := persistTableMeta(v); meta != nil {
+		return meta.RawGetString(persistTableFieldTarget).(*lua.LTable)
+	}
+	return nil
+}
+
+// unwrapPersistTable 代理替换为实际数据, 其他值原样返回
+func unwrapPersistTable(v lua.LValue) lua.LValue {
+	if target := persistTableTarget(v); target != nil {
+		return target
+	}
+	return v
+}
+
+// persistTablePlain 深拷贝table, 代理拷贝其实际数据, 避免脚本层保留的引用绕过代理修改数据
+func persistTablePlain(t *lua.LTable) *lua.LTable {
+	if target := persistTableTarget(t); target != nil {
+		t = target
+	}
+	return syncTableToPlain(t)
+}
+
+// persistTableChild 从代理中取出的table同样包装为代理, 带有元表的table(如mailbox)原样返回
+func persistTableChild(meta *lua.LTable, key, val lua.LValue) lua.LValue {
+	t, ok := val.(*lua.LTable)
+	if !ok || t.Metatable != lua.LNil {
+		return val
+	}
+	rootKey := meta.RawGetString(persistTableFieldKey)
+	if rootKey == lua.LNil {
+		rootKey = key
+	}
+	owner := EntityIdType(meta.RawGetString(persistTableFieldOwner).(lua.LNumber))
+	return newPersistTable(t, owner, meta.RawGetString(persistTableFieldName).String(), rootKey)
+}
+
+// onPersistTableWritten 通知所属entity属性的第一层key发生变化, key为nil时整个属性待存盘
+func onPersistTableWritten(meta *lua.LTable, key lua.LValue) {
+	if rootKey := meta.RawGetString(persistTableFieldKey); rootKey != lua.LNil {
+		key = rootKey
+	}
+	ent := GetEntityManager().GetEntityById(EntityIdType(meta.RawGetString(persistTableFieldOwner).(lua.LNumber)))
+	if ent == nil {
+		return
+	}
+	propName := meta.RawGetString(persistTableFieldName).String()
+	if key == lua.LNil {
+		ent.onPersistPropChanged(propName)
+	} else {
+		ent.onPersistTableUpdated(propName, key)
+	}
+}
+
+func persistTableIndex(L *lua.LState) int {
+	meta := persistTableMeta(L.CheckTable(1))
+	key := L.CheckAny(2)
+	target := meta.RawGetString(persistTableFieldTarget).(*lua.LTable)
+	L.Push(persistTableChild(meta, key, target.RawGet(key)))
+	return 1
+}
+
+func persistTableNewIndex(L *lua.LState) int {
+	meta := persistTableMeta(L.CheckTable(1))
+	key := L.CheckAny(2)
+	val := L.CheckAny(3)
+	target := meta.RawGetString(persistTableFieldTarget).(*lua.LTable)
+	if t, ok := val.(*lua.LTable); ok {
+		val = persistTablePlain(t)
+	} else if target.RawGet(key) == val {
+		return 0
+	}
+	target.RawSet(key, val)
+	onPersistTableWritten(meta, key)
+	return 0
+}
+
+func persistTableLen(L *lua.LState) int {
+	target := persistTableTarget(L.CheckTable(1))
+	L.Push(lua.LNumber(target.Len()))
+	return 1
+}
+
+// registerPersistTableApi 替换lua中遍历与修改table的函数, 传入代理时操作其实际数据
+func registerPersistTableApi() {
+	luaL.SetGlobal("next", luaL.NewFunction(persistTableNext))
+	pairs := luaL.GetGlobal("pairs")
+	luaL.SetGlobal("pairs", luaL.NewFunction(func(L *lua.LState) int {
+		if persistTableMeta(L.Get(1)) == nil {
+			return callOriginLuaFunction(L, pairs)
+		}
+		L.Push(L.GetGlobal("next"))
+		L.Push(L.Get(1))
+		L.Push(lua.LNil)
+		return 3
+	}))
+	ipairs := luaL.GetGlobal("ipairs")
+	ipairsNext := luaL.NewFunction(persistTableIpairsNext)
+	luaL.SetGlobal("ipairs", luaL.NewFunction(func(L *lua.LState) int {
+		if persistTableMeta(L.Get(1)) == nil {
+			return callOriginLuaFunction(L, ipairs)
+		}
+		L.Push(ipairsNext)
+		L.Push(L.Get(1))
+		L.Push(lua.LNumber(0))
+		return 3
+	}))
+	tableLib := luaL.GetGlobal("table").(*lua.LTable)
+	for _, name := range []string{"insert", "remove"} {
+		origin := tableLib.RawGetString(name)
+		tableLib.RawSetString(name, luaL.NewFunction(func(L *lua.LState) int {
+			meta := persistTableMeta(L.Get(1))
+			if meta == nil {
+				return callOriginLuaFunction(L, origin)
+			}
+			L.Replace(1, meta.RawGetString(persistTableFieldTarget))
+			if t, ok := L.Get(L.GetTop()).(*lua.LTable); ok && L.GetTop() > 1 {
+				L.Replace(L.GetTop(), persistTablePlain(t))
+			}
+			n := callOriginLuaFunction(L, origin)
+			//插入或删除会移动后续元素, 整体存盘
+			onPersistTableWritten(meta, lua.LNil)
+			return n
+		}))
+	}
+}
+
+// callOriginLuaFunction 以当前栈上的参数调用被替换的原始函数
+func callOriginLuaFunction(L *lua.LState, origin lua.LValue) int {
+	top := L.GetTop()
+	L.Push(origin)
+	for i := 1; i <= top; i++ {
+		L.Push(L.Get(i))
+	}
+	L.Call(top, lua.MultRet)
+	return L.GetTop() - top
+}
+
+func persistTableNext(L *lua.LState) int {
+	meta := persistTableMeta(L.CheckTable(1))
+	if meta == nil {
+		key, value := L.CheckTable(1).Next(L.Get(2))
+		if key == lua.LNil {
+			L.Push(lua.LNil)
+			return 1
+		}
+		L.Push(key)
+		L.Push(value)
+		return 2
+	}
+	target := meta.RawGetString(persistTableFieldTarget).(*lua.LTable)
+	key, value := target.Next(L.Get(2))
+	if key == lua.LNil {
+		L.Push(lua.LNil)
+		return 1
+	}
+	L.Push(key)
+	L.Push(persistTableChild(meta, key, value))
+	return 2
+}
+
+func persistTableIpairsNext(L *lua.LState) int {
+	meta := persistTableMeta(L.CheckTable(1))
+	i := L.CheckInt(2) + 1
+	target := meta.RawGetString(persistTableFieldTarget).(*lua.LTable)
+	value := target.RawGetInt(i)
+	if value == lua.LNil {
+		return 0
+	}
+	L.Push(lua.LNumber(i))
+	L.Push(persistTableChild(meta, lua.LNumber(i), value))
+	return 2
+}
+
+// persistFieldValue 属性第一层key的存盘值
+func persistFieldValue(dt dataType, key string, v lua.LValue) interface{} {
+	switch d := dt.(type) {
+	case *dtMap:
+		return d.value.ParseFromLua(v)
+	case *dtArray:
+		return d.value.ParseFromLua(v)
+	case *dtStruct:
+		if prop, ok := d.props[key]; ok {
+			return prop.dt.ParseFromLua(v)
+		}
+	}
+	if t, ok := v.(*lua.LTable); ok {
+		return TableToMap(syncTableToPlain(t))
+	}
+	return v
+}
@@ -5,16 +5,19 @@ import (
 	"encoding/binary"
 	"fmt"
 	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
 	"github.com/panjf2000/gnet"
 	"github.com/vmihailenco/msgpack/v4"
 )
 
 const (
-	messageHeadLen = 4
-	messageMaxLen  = 16 * 1024 * 1024 //16M
+	messageHeadLen      = 4
+	messageMaxLen       = 16 * 1024 * 1024 //16M
+	messageCompressFlag = uint32(1 << 31)  //长度字段最高位标记包体经过压缩
 )
 
 type protocol struct {
+	compressThreshold int //包体超过该长度时压缩, 小于等于0不压缩
 }
 
 func initProtocol() error {
@@ -31,9 +34,18 @@ func GetProtocol() *protocol {
 }
 
 func (m *protocol) init() error {
+	//未支持压缩标记位的对端会把带标记的长度字段当作超长消息, 因此默认不压缩, 需要在配置中显式开启
+	if cfg != nil {
+		m.compressThreshold = cfg.CompressThreshold
+	}
 	return nil
 }
 
+// MaxMessageLen 单条消息的最大长度
+func (m *protocol) MaxMessageLen() int {
+	return messageMaxLen
+}
+
 func (m *protocol) Marshal(buf interface{}) ([]byte, error) {
 	return msgpack.Marshal(buf)
 }
@@ -87,32 +99,73 @@ func (m *protocol) ConcatHeadAndBody(head, body []byte) []byte {
 
 // Encode 消息编码
 func (m *protocol) Encode(data []byte) ([]byte, error) {
+	return m.EncodeWithSession(data, nil)
+}
+
+// Decode 消息解码
+func (m *protocol) Decode(data []byte) (int, []byte, error) {
+	return m.DecodeWithSession(data, nil)
+}
+
+// EncodeWithSession 消息编码, 包体超过压缩阈值且压缩后更短时使用snappy压缩并在长度字段中标记, session不为空时压缩后再加密
+func (m *protocol) EncodeWithSession(data []byte, session *CryptoSession) ([]byte, error) {
 	dataLen := len(data)
 	if dataLen > messageMaxLen {
 		return nil, fmt.Errorf("encode message length %d > %d", dataLen, messageMaxLen)
 	}
-	totalLen := messageHeadLen + dataLen
-	buf := make([]byte, messageHeadLen)
-	binary.BigEndian.PutUint32(buf, uint32(totalLen))
-	b := bytes.NewBuffer(buf)
-	b.Write(data)
-	return b.Bytes(), nil
+	flag := uint32(0)
+	if m.compressThreshold > 0 && dataLen >= m.compressThreshold {
+		if compressed := snappy.Encode(nil, data); len(compressed) < dataLen {
+			data = compressed
+			flag = messageCompressFlag
+		}
+	}
+	if session != nil {
+		data = session.Seal(data)
+	}
+	totalLen := messageHeadLen + len(data)
+	buf := make([]byte, messageHeadLen, totalLen)
+	binary.BigEndian.PutUint32(buf, uint32(totalLen)|flag)
+	return append(buf, data...), nil
 }
 
-// Decode 消息解码
-func (m *protocol) Decode(data []byte) (int, []byte, error) {
+// DecodeWithSession 消息解码, session不为空时先解密, 压缩的包体解压后返回
+func (m *protocol) DecodeWithSession(data []byte, session *CryptoSession) (int, []byte, error) {
 	bufLen := len(data)
 	if bufLen < messageHeadLen {
 		return 0, nil, nil
 	}
-	length := int(binary.BigEndian.Uint32(data))
+	head := binary.BigEndian.Uint32(data)
+	length := int(head &^ messageCompressFlag)
+	if length > messageMaxLen {
+		return 0, nil, fmt.Errorf("decode message length %d > %d", length, messageMaxLen)
+	}
+	if length < messageHeadLen {
+		return 0, nil, fmt.Errorf("decode message length %d < %d", length, messageHeadLen)
+	}
 	if bufLen < length {
 		return 0, nil, nil
 	}
-	if length > messageMaxLen {
-		return 0, nil, fmt.Errorf("decode message length %d > %d", length, messageMaxLen)
+	body := data[messageHeadLen:length]
+	if session != nil {
+		var err error
+		if body, err = session.Open(body); err != nil {
+			return 0, nil, fmt.Errorf("decrypt message error: %s", err.Error())
+		}
+	}
+	if head&messageCompressFlag == 0 {
+		return length, body, nil
+	}
+	if n, err := snappy.DecodedLen(body); err != nil {
+		return 0, nil, fmt.Errorf("decode compressed message error: %s", err.Error())
+	} else if n > messageMaxLen {
+		return 0, nil, fmt.Errorf("decode message length %d > %d", n, messageMaxLen)
+	}
+	body, err := snappy.Decode(nil, body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("decode compressed message error: %s", err.Error())
 	}
-	return length, data[messageHeadLen:length], nil
+	return length, body, nil
 }
 
 // GNetCodec GNet消息编解码
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestProtocolCompressFraming(t *testing.T) {
+	compressible := bytes.Repeat([]byte("rpg"), 1024)
+	random := make([]byte, 64)
+	for i := range random {
+		random[i] = byte(i * 37)
+	}
+	tests := []struct {
+		name       string
+		threshold  int
+		data       []byte
+		compressed bool
+	}{
+		{"disabled by default", 0, compressible, false},
+		{"negative threshold", -1, compressible, false},
+		{"below threshold", 1 << 20, compressible, false},
+		{"above threshold", 16, compressible, true},
+		{"not shorter after compress", 16, random, false},
+		{"empty body", 16, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &protocol{compressThreshold: tt.threshold}
+			frame, err := p.Encode(tt.data)
+			if err != nil {
+				t.Fatalf("encode error: %s", err.Error())
+			}
+			head := binary.BigEndian.Uint32(frame)
+			if got := head&messageCompressFlag != 0; got != tt.compressed {
+				t.Fatalf("compress flag = %v, want %v", got, tt.compressed)
+			}
+			if length := int(head &^ messageCompressFlag); length != len(frame) {
+				t.Fatalf("frame length = %d, want %d", length, len(frame))
+			}
+			n, body, err := p.Decode(frame)
+			if err != nil {
+				t.Fatalf("decode error: %s", err.Error())
+			}
+			if n != len(frame) || !bytes.Equal(body, tt.data) {
+				t.Fatalf("decode = (%d, %d bytes), want (%d, %d bytes)", n, len(body), len(frame), len(tt.data))
+			}
+		})
+	}
+}
+
+func TestProtocolDecodePartial(t *testing.T) {
+	p := &protocol{compressThreshold: 16}
+	frame, _ := p.Encode(bytes.Repeat([]byte("rpg"), 1024))
+	tests := []struct {
+		name    string
+		data    []byte
+		wantLen int
+		wantErr bool
+	}{
+		{"short head", frame[:2], 0, false},
+		{"partial body", frame[:len(frame)-1], 0, false},
+		{"full frame", frame, len(frame), false},
+		{"frame with next", append(append([]byte{}, frame...), 0, 0), len(frame), false},
+		{"length too small", []byte{0, 0, 0, 2}, 0, true},
+		{"length too large", []byte{0x7f, 0xff, 0xff, 0xff}, 0, true},
+		{"corrupted compressed body", []byte{0x80, 0, 0, 6, 0xff, 0xff}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, _, err := p.Decode(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decode error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if n != tt.wantLen {
+				t.Fatalf("decode length = %d, want %d", n, tt.wantLen)
+			}
+		})
+	}
+}
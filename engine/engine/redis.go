@@ -12,7 +12,8 @@ import (
 )
 
 const (
-	redisHashGameLoad = "game_load" //game负载
+	redisHashGameLoad          = "game_load"       //game负载
+	redisChannelEntityLocation = "entity_location" //entity位置变化通知
 )
 
 type GameLoadInfo struct {
@@ -25,6 +26,11 @@ func RedisGameLoadKey() string {
 	return redisHashGameLoad + "." + strconv.FormatInt(int64(GetConfig().ServerId), 10)
 }
 
+// RedisEntityLocationChannel entity注册或移除时发布位置变化的频道
+func RedisEntityLocationChannel() string {
+	return redisChannelEntityLocation + "." + strconv.FormatInt(int64(GetConfig().ServerId), 10)
+}
+
 func GetRedisMgr() *redisManager {
 	return redisMgr
 }
@@ -7,12 +7,13 @@ import (
 )
 
 type Robot struct {
-	entityId    EntityIdType //id
-	entityName  string       //名称
-	luaEntity   *lua.LTable  //脚本层entity
-	serverTable *lua.LTable  //服务端rpc函数信息
-	def         *entityDef   //def定义
-	server      *TcpClient   //服务端连接
+	entityId    EntityIdType      //id
+	entityName  string            //名称
+	luaEntity   *lua.LTable       //脚本层entity
+	serverTable *lua.LTable       //服务端rpc函数信息
+	def         *entityDef        //def定义
+	server      *TcpClient        //服务端连接
+	versions    map[string]uint32 //sync_table属性的版本号
 }
 
 func newRobot(entityId EntityIdType, entityName string, conn *TcpClient) (*Robot, error) {
@@ -20,6 +21,7 @@ func newRobot(entityId EntityIdType, entityName string, conn *TcpClient) (*Robot
 	rb.entityId = entityId
 	rb.entityName = entityName
 	rb.server = conn
+	rb.versions = make(map[string]uint32)
 	if err := rb.init(); err != nil {
 		return nil, err
 	}
@@ -207,25 +209,86 @@ func (m *Robot) OnServerSyncProp(name string, value lua.LValue) {
 	}
 }
 
-func (m *Robot) OnServerSyncPropPart(name string, key lua.LValue, value lua.LValue) {
+// SetSyncTableVersions 设置创建entity时快照中sync_table属性的版本号
+func (m *Robot) SetSyncTableVersions(versions map[string]interface{}) {
+	for name, v := range versions {
+		m.versions[name] = uint32(InterfaceToInt(v))
+	}
+}
+
+/*
+OnServerSyncPropPart sync_table属性增量同步, 返回false表示版本号不连续, 需要请求全量同步
+
+version: 变化后的版本号
+
+path: 从属性根节点到修改位置的key路径, 为空表示整体替换
+*/
+func (m *Robot) OnServerSyncPropPart(name string, version uint32, path []lua.LValue, value lua.LValue) bool {
 	prop := m.def.prop(name)
 	if prop == nil {
-		return
+		return true
 	}
+	if len(path) == 0 {
+		//整体替换(包括全量同步), 直接使用服务端的版本号
+		m.versions[name] = version
+		m.OnServerSyncProp(name, value)
+		return true
+	}
+	if version <= m.versions[name] {
+		//快照或全量同步中已包含该变化
+		return true
+	}
+	if version != m.versions[name]+1 {
+		log.Debugf("%s prop[%s] version gap, local: %d, remote: %d", m.String(), name, m.versions[name], version)
+		return false
+	}
+	m.versions[name] = version
 
 	v := luaL.GetField(m.luaEntity, name)
 	if v.Type() != lua.LTTable {
-		return
+		return true
 	}
-	t := v.(*lua.LTable)
-	if f := luaL.GetField(m.luaEntity, "on_update_"+name); f.Type() == lua.LTFunction {
-		old := luaL.NewTable()
-		for ck, cv := t.Next(lua.LNil); ck != lua.LNil; ck, cv = t.Next(ck) {
+	root := v.(*lua.LTable)
+	f := luaL.GetField(m.luaEntity, "on_update_"+name)
+	var old *lua.LTable
+	if f.Type() == lua.LTFunction {
+		old = luaL.NewTable()
+		for ck, cv := root.Next(lua.LNil); ck != lua.LNil; ck, cv = root.Next(ck) {
 			luaL.RawSet(old, ck, cv)
 		}
-		luaL.RawSet(t, key, value)
-		_ = CallLuaMethod(NewLuaMethod(f, "on_update_"+name), 0, m.luaEntity, old, key)
+	}
+	t := root
+	for _, key := range path[:len(path)-1] {
+		child, ok := t.RawGet(key).(*lua.LTable)
+		if !ok {
+			child = luaL.NewTable()
+			t.RawSet(key, child)
+		}
+		t = child
+	}
+	luaL.RawSet(t, path[len(path)-1], value)
+	if old != nil {
+		pathTable := luaL.NewTable()
+		for _, key := range path {
+			pathTable.Append(key)
+		}
+		_ = CallLuaMethod(NewLuaMethod(f, "on_update_"+name), 0, m.luaEntity, old, pathTable)
+	}
+	return true
+}
+
+// RequestResync 请求服务端全量同步属性
+func (m *Robot) RequestResync(name string) {
+	if m.server == nil {
+		return
+	}
+	buf := map[string]interface{}{
+		ClientMsgDataFieldEntityID: m.entityId,
+		ClientMsgDataFieldArgs:     []interface{}{name},
+	}
+	if data, err := genC2SMessage(uint8(ClientMsgTypePropResync), buf); err == nil {
+		_, _ = m.server.Send(data)
 	} else {
-		luaL.RawSet(t, key, value)
+		log.Errorf("%s request resync prop[%s] error: %s", m.String(), name, err.Error())
 	}
 }
@@ -43,6 +43,12 @@ func (em *robotManager) CreateEntity(entityId EntityIdType, entityName string, p
 	return rb, err
 }
 
+// DestroyEntity 服务端通知销毁entity
+func (em *robotManager) DestroyEntity(rb *Robot) {
+	_ = CallLuaMethodByName(rb.luaEntity, onEntityDestroy, 0, rb.luaEntity)
+	em.RemoveEntity(rb)
+}
+
 func (em *robotManager) RemoveEntity(rb *Robot) {
 	em.unRegisterEntity(rb)
 }
@@ -51,6 +57,14 @@ func (em *robotManager) GetEntityById(entityId EntityIdType) *Robot {
 	return em.allEntities[entityId]
 }
 
+// EntityName entity的名称, entity不存在时返回空字符串
+func (em *robotManager) EntityName(entityId EntityIdType) string {
+	if rb, ok := em.allEntities[entityId]; ok {
+		return rb.entityName
+	}
+	return ""
+}
+
 func (em *robotManager) GetEntityByLua(t *lua.LTable) *Robot {
 	return em.luaEntityToEntity[t]
 }
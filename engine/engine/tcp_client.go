@@ -60,6 +60,13 @@ func WithTcpClientContext(ctx interface{}) Option {
 	}
 }
 
+// WithTcpClientDialer 自定义建立连接的方式, 如kcp
+func WithTcpClientDialer(dialer func(addr string) (net.Conn, error)) Option {
+	return func(opts *Options) {
+		opts.Dialer = dialer
+	}
+}
+
 type defaultCodec struct {
 }
 
@@ -88,6 +95,7 @@ type Options struct {
 	Codec   ITcpClientCodec
 	Handler ITcpClientHandler
 	Context interface{}
+	Dialer  func(addr string) (net.Conn, error)
 }
 
 type Option func(opts *Options)
@@ -103,7 +111,8 @@ type TcpClient struct {
 	inBuffer       *RingBuffer.RingBuffer
 	outBufferLock  sync.Mutex
 	outBuffer      *RingBuffer.RingBuffer
-	conn           *net.TCPConn
+	conn           net.Conn
+	dialer         func(addr string) (net.Conn, error)
 	codec          ITcpClientCodec
 	handler        ITcpClientHandler
 	ctx            interface{}
@@ -136,6 +145,7 @@ func (m *TcpClient) init(opts ...Option) {
 	if options.Context != nil {
 		m.ctx = options.Context
 	}
+	m.dialer = options.Dialer
 }
 
 func (m *TcpClient) loadOptions(options ...Option) *Options {
@@ -190,17 +200,12 @@ func (m *TcpClient) connect() error {
 
 	//log.Infof("TcpClient try connect to %s", m.addr)
 	m.connectStatus.Store(connectStatusConnecting)
-	tcpAddr, err := net.ResolveTCPAddr("tcp", m.addr)
-	if err != nil {
-		m.connectStatus.Store(connectStatusDisconnected)
-		return err
-	}
-
-	m.conn, err = net.DialTCP("tcp", nil, tcpAddr)
+	conn, err := m.dial()
 	if err != nil {
 		m.connectStatus.Store(connectStatusDisconnected)
 		return err
 	}
+	m.conn = conn
 
 	m.connectStatus.Store(connectStatusConnected)
 	m.handlerAction.Store(handlerActionConnected)
@@ -209,6 +214,17 @@ func (m *TcpClient) connect() error {
 	return nil
 }
 
+func (m *TcpClient) dial() (net.Conn, error) {
+	if m.dialer != nil {
+		return m.dialer(m.addr)
+	}
+	tcpAddr, err := net.ResolveTCPAddr("tcp", m.addr)
+	if err != nil {
+		return nil, err
+	}
+	return net.DialTCP("tcp", nil, tcpAddr)
+}
+
 func (m *TcpClient) Connect(addr string, autoReconnect bool) {
 	m.addr = addr
 	m.autoReconnect = autoReconnect
@@ -224,6 +240,10 @@ func (m *TcpClient) IsDisconnected() bool {
 	return m.connectStatus.Load() == connectStatusDisconnected
 }
 
+func (m *TcpClient) IsConnected() bool {
+	return m.connectStatus.Load() == connectStatusConnected
+}
+
 func (m *TcpClient) Disconnect() {
 	m.connAction.Store(connActionClose)
 }
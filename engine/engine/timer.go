@@ -79,6 +79,12 @@ func (m *timerMgr) Cancel(timerId int64) {
 	}
 }
 
+func (m *timerMgr) getTimer(timerId int64) *timerWheel.Timer {
+	m.sl.Lock()
+	defer m.sl.UnLock()
+	return m.timerMap[timerId]
+}
+
 func (m *timerMgr) ShowTimeWheelInfo() {
 	log.Info(m.tw.String())
 }
@@ -96,18 +102,29 @@ func entityScriptTimerCallback(params ...interface{}) {
 	if ent == nil {
 		return
 	}
-	//回调触发后将entity身上记录的信息移除
-	ent.removeActiveTimerId(params[len(params)-1].(int64))
+	//非循环定时器回调触发后将entity身上记录的信息移除
+	timerId := params[len(params)-1].(int64)
+	if tm := GetTimer().getTimer(timerId); tm == nil || tm.RepeatDuration() == 0 {
+		ent.removeActiveTimerId(timerId)
+	}
+	//迁移中先暂存, 迁移失败后补发
+	if ent.migrating {
+		ent.deferredTimers = append(ent.deferredTimers, params)
+		return
+	}
+	ent.callScriptTimer(params)
+}
 
+func (e *entity) callScriptTimer(params []interface{}) {
 	methodName := params[1].(lua.LString)
 	argLen := len(params) - 2
 	args := make([]lua.LValue, argLen, argLen)
-	args[0] = ent.luaEntity
+	args[0] = e.luaEntity
 	for i := 2; i < len(params)-1; i++ {
 		args[i-1] = params[i].(lua.LValue)
 	}
-	if err := CallLuaMethodByName(ent.luaEntity, methodName.String(), 0, args...); err != nil {
-		log.Errorf("%s timer callback, error: %s", ent.String(), err.Error())
+	if err := CallLuaMethodByName(e.luaEntity, methodName.String(), 0, args...); err != nil {
+		log.Errorf("%s timer callback, error: %s", e.String(), err.Error())
 	}
 }
 
@@ -48,6 +48,16 @@ func (t *Timer) RepeatDuration() time.Duration {
 	return t.info.RepeatDuration
 }
 
+// Params 回调参数, 最后一个为定时器id
+func (t *Timer) Params() []interface{} {
+	return t.info.Params
+}
+
+// IsActive 定时器是否还在等待触发
+func (t *Timer) IsActive() bool {
+	return t.getBucket() != nil
+}
+
 func (t *Timer) Stop() {
 	for b := t.getBucket(); b != nil; b = t.getBucket() {
 		b.Remove(t)
@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/sirupsen/logrus"
+)
+
+// LogFieldTraceId 日志中trace id的字段名
+const LogFieldTraceId = "trace"
+
+var (
+	currentTraceId string        //主线程当前处理的消息的trace id
+	mainLog        *logrus.Entry //主线程的日志, 带有当前的trace id
+)
+
+// NewTraceId gate收到客户端消息时生成trace id
+func NewTraceId() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// SetTraceId 设置主线程当前处理的消息的trace id, 同时替换主线程的日志, 消息处理完成后设置为空; 只能在主线程调用
+func SetTraceId(id string) {
+	if id == currentTraceId && mainLog != nil {
+		return
+	}
+	currentTraceId = id
+	mainLog = TraceLogger(id)
+}
+
+// TraceId 主线程当前处理的消息的trace id, 只能在主线程调用
+func TraceId() string {
+	return currentTraceId
+}
+
+// MainLogger 主线程处理消息时使用的日志, 带有当前的trace id; 其他goroutine需通过TraceLogger指定trace id或直接使用基础日志
+func MainLogger() *logrus.Entry {
+	if mainLog == nil {
+		return log
+	}
+	return mainLog
+}
+
+// TraceLogger 带有指定trace id的日志, 用于多个消息并发处理的场景
+func TraceLogger(id string) *logrus.Entry {
+	if id == "" {
+		return log
+	}
+	return log.WithField(LogFieldTraceId, id)
+}
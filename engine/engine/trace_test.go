@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"github.com/sirupsen/logrus"
+	"testing"
+)
+
+func TestSetTraceId(t *testing.T) {
+	tests := []struct {
+		name      string
+		traceIds  []string //依次设置的trace id
+		wantTrace string   //主线程日志的trace id
+	}{
+		{"no trace", nil, ""},
+		{"set trace", []string{"t1"}, "t1"},
+		{"trace replaced", []string{"t1", "t2"}, "t2"},
+		{"trace cleared", []string{"t1", ""}, ""},
+	}
+	oldLog := log
+	log = logrus.NewEntry(logrus.New())
+	defer func() {
+		SetTraceId("")
+		log = oldLog
+		mainLog = nil
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetTraceId("")
+			for _, id := range tt.traceIds {
+				SetTraceId(id)
+			}
+			if TraceId() != tt.wantTrace {
+				t.Fatalf("TraceId() = %q, want %q", TraceId(), tt.wantTrace)
+			}
+			got, _ := MainLogger().Data[LogFieldTraceId].(string)
+			if got != tt.wantTrace {
+				t.Fatalf("main logger trace = %q, want %q", got, tt.wantTrace)
+			}
+			//基础日志不受主线程trace id影响, 其他goroutine使用时不会带上错误的trace id
+			if _, ok := log.Data[LogFieldTraceId]; ok {
+				t.Fatalf("base logger has trace field")
+			}
+			if got, _ = TraceLogger("t3").Data[LogFieldTraceId].(string); got != "t3" {
+				t.Fatalf("TraceLogger trace = %q, want %q", got, "t3")
+			}
+		})
+	}
+}
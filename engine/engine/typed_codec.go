@@ -0,0 +1,485 @@
+package engine
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	lua "github.com/seasondi/gopher-lua"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+const (
+	ClientCodecMsgpack = "msgpack" //msgpack字典, 默认编码
+	ClientCodecTyped   = "typed"   //按def中函数参数列表的位置编码
+)
+
+// IsValidClientCodec 是否是支持的客户端消息编码
+func IsValidClientCodec(name string) bool {
+	return name == ClientCodecMsgpack || name == ClientCodecTyped
+}
+
+type typedMethod struct {
+	maskName string
+	def      *methodDef
+}
+
+// typedMethods entity的rpc函数按函数名排序, 下标加1即typed编码中的函数序号
+type typedMethods struct {
+	list  []typedMethod
+	index map[string]int //mask name -> 下标
+}
+
+var typedMethodsOnce sync.Once
+var typedServerMethods map[string]*typedMethods //entity名称 -> 暴露给客户端的服务端函数
+var typedClientMethods map[string]*typedMethods //entity名称 -> 客户端函数
+
+func initTypedMethods() {
+	typedServerMethods = make(map[string]*typedMethods)
+	typedClientMethods = make(map[string]*typedMethods)
+	if defMgr == nil {
+		return
+	}
+	for _, name := range defMgr.GetAllEntityNames() {
+		def := defMgr.GetEntityDef(name)
+		typedServerMethods[name] = newTypedMethods(def.serverMethods, true)
+		typedClientMethods[name] = newTypedMethods(def.clientMethods, false)
+	}
+}
+
+func newTypedMethods(methods map[string]*methodDef, exposedOnly bool) *typedMethods {
+	r := &typedMethods{index: make(map[string]int)}
+	for maskName, method := range methods {
+		if exposedOnly && !method.exposed {
+			continue
+		}
+		r.list = append(r.list, typedMethod{maskName: maskName, def: method})
+	}
+	sort.Slice(r.list, func(i, j int) bool { return r.list[i].def.methodName < r.list[j].def.methodName })
+	for i, method := range r.list {
+		r.index[method.maskName] = i
+	}
+	return r
+}
+
+func getTypedMethods(entityName string, toClient bool) *typedMethods {
+	typedMethodsOnce.Do(initTypedMethods)
+	if toClient {
+		return typedClientMethods[entityName]
+	}
+	return typedServerMethods[entityName]
+}
+
+/*
+MarshalTyped 将消息字典按typed格式编码: 消息类型(1字节) + entityId + 消息序号 + 消息内容, 整数均为varint
+
+entity rpc消息的内容为函数序号与按def参数类型依次编码的参数, 参数不带类型信息;
+entityName为空、函数或参数与def不符时函数序号为0, 之后为msgpack编码的参数数组(第一个元素为函数名);
+登录消息的内容为def版本号(长度+内容)与msgpack编码的参数数组; 其他消息的内容为msgpack编码的参数数组, 没有参数时为空
+
+toClient: 是否是发往客户端的消息, 是则按客户端函数编码, 否则按暴露给客户端的服务端函数编码
+*/
+func MarshalTyped(msgType uint8, data map[string]interface{}, entityName string, toClient bool) ([]byte, error) {
+	buf := []byte{msgType}
+	buf = binary.AppendUvarint(buf, uint64(InterfaceToInt(data[ClientMsgDataFieldEntityID])))
+	buf = binary.AppendUvarint(buf, uint64(InterfaceToInt(data[ClientMsgDataFieldSeq])))
+	args, hasArgs := data[ClientMsgDataFieldArgs].([]interface{})
+	if msgType == ClientMsgTypeEntityRpc {
+		if r, err := appendTypedRpc(buf, args, entityName, toClient); err == nil {
+			return r, nil
+		}
+		buf = append(buf, 0)
+	}
+	if msgType == ClientMsgTypeLogin {
+		version, _ := data[ClientMsgDataFieldVersion].(string)
+		buf = binary.AppendUvarint(buf, uint64(len(version)))
+		buf = append(buf, version...)
+	}
+	if !hasArgs {
+		return buf, nil
+	}
+	packed, err := GetProtocol().Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, packed...), nil
+}
+
+/*
+UnMarshalTyped 解析typed格式的消息, 还原为与msgpack编码一致的消息字典
+
+entityName: 根据entityId查询entity名称, 用于确定rpc函数所属的def
+
+toClient: 是否是发往客户端的消息, 是则结果中包含消息类型字段
+*/
+func UnMarshalTyped(frame []byte, entityName func(EntityIdType) string, toClient bool) (uint8, map[string]interface{}, error) {
+	if len(frame) == 0 {
+		return 0, nil, errors.New("empty message")
+	}
+	msgType := frame[0]
+	entityId, buf, err := readTypedUvarint(frame[1:])
+	if err != nil {
+		return 0, nil, err
+	}
+	seq, buf, err := readTypedUvarint(buf)
+	if err != nil {
+		return 0, nil, err
+	}
+	r := make(map[string]interface{})
+	if toClient {
+		r[ClientMsgDataFieldType] = msgType
+	}
+	if entityId != 0 {
+		r[ClientMsgDataFieldEntityID] = EntityIdType(entityId)
+	}
+	if seq != 0 {
+		r[ClientMsgDataFieldSeq] = seq
+	}
+	if msgType == ClientMsgTypeEntityRpc {
+		var idx uint64
+		if idx, buf, err = readTypedUvarint(buf); err != nil {
+			return 0, nil, err
+		}
+		if idx > 0 {
+			args, err := readTypedRpc(buf, int(idx-1), entityName(EntityIdType(entityId)), toClient)
+			if err != nil {
+				return 0, nil, err
+			}
+			r[ClientMsgDataFieldArgs] = args
+			return msgType, r, nil
+		}
+	}
+	if msgType == ClientMsgTypeLogin {
+		var version []byte
+		if version, buf, err = readTypedBytes(buf); err != nil {
+			return 0, nil, err
+		}
+		r[ClientMsgDataFieldVersion] = string(version)
+	}
+	if len(buf) > 0 {
+		var args []interface{}
+		if err = GetProtocol().UnMarshalTo(buf, &args); err != nil {
+			return 0, nil, err
+		}
+		r[ClientMsgDataFieldArgs] = args
+	}
+	return msgType, r, nil
+}
+
+func appendTypedRpc(buf []byte, args []interface{}, entityName string, toClient bool) ([]byte, error) {
+	if len(args) == 0 {
+		return nil, errors.New("method name not found")
+	}
+	maskName, _ := args[0].(string)
+	methods := getTypedMethods(entityName, toClient)
+	if methods == nil {
+		return nil, fmt.Errorf("entity[%s] def not found", entityName)
+	}
+	idx, ok := methods.index[maskName]
+	if !ok {
+		return nil, fmt.Errorf("entity[%s] method[%s] not found", entityName, maskName)
+	}
+	method := methods.list[idx].def
+	if len(args)-1 != len(method.args) {
+		return nil, fmt.Errorf("method[%s] need %d arg(s) but got %d", method.methodName, len(method.args), len(args)-1)
+	}
+	r := binary.AppendUvarint(buf, uint64(idx+1))
+	var err error
+	for i, arg := range method.args {
+		if r, err = appendTypedValue(r, arg.dt, args[i+1]); err != nil {
+			return nil, fmt.Errorf("method[%s] arg[%d] %s", method.methodName, i+1, err.Error())
+		}
+	}
+	return r, nil
+}
+
+func readTypedRpc(buf []byte, idx int, entityName string, toClient bool) ([]interface{}, error) {
+	methods := getTypedMethods(entityName, toClient)
+	if methods == nil {
+		return nil, fmt.Errorf("entity[%s] def not found", entityName)
+	}
+	if idx >= len(methods.list) {
+		return nil, fmt.Errorf("entity[%s] method index %d out of range", entityName, idx+1)
+	}
+	method := methods.list[idx]
+	args := []interface{}{method.maskName}
+	for i, arg := range method.def.args {
+		var v interface{}
+		var err error
+		if v, buf, err = readTypedValue(buf, arg.dt); err != nil {
+			return nil, fmt.Errorf("method[%s] arg[%d] %s", method.def.methodName, i+1, err.Error())
+		}
+		args = append(args, v)
+	}
+	if len(buf) > 0 {
+		return nil, fmt.Errorf("method[%s] has %d unexpected trailing byte(s)", method.def.methodName, len(buf))
+	}
+	return args, nil
+}
+
+// appendTypedValue 按def类型编码单个值, 值为nil时按该类型的零值编码
+func appendTypedValue(buf []byte, dt dataType, v interface{}) ([]byte, error) {
+	switch t := dt.(type) {
+	case *dtInt8, *dtInt16, *dtInt32, *dtInt64:
+		if !isTypedNumber(v) {
+			return nil, fmt.Errorf("expect %s but got %T", dt.Type(), v)
+		}
+		return binary.AppendVarint(buf, InterfaceToInt(v)), nil
+	case *dtUint8, *dtUint16, *dtUint32, *dtUint64:
+		if !isTypedNumber(v) {
+			return nil, fmt.Errorf("expect %s but got %T", dt.Type(), v)
+		}
+		return binary.AppendUvarint(buf, uint64(InterfaceToInt(v))), nil
+	case *dtFloat:
+		if !isTypedNumber(v) {
+			return nil, fmt.Errorf("expect %s but got %T", dt.Type(), v)
+		}
+		//按def中保留的小数位数转为整数
+		f := float64(0)
+		if v != nil {
+			f = reflect.ValueOf(v).Convert(reflect.TypeOf(f)).Float()
+		}
+		return binary.AppendVarint(buf, int64(math.Round(f*float64(t.decimal)))), nil
+	case *dtBool:
+		b, ok := v.(bool)
+		if !ok && v != nil {
+			return nil, fmt.Errorf("expect %s but got %T", dt.Type(), v)
+		}
+		if b {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case *dtString:
+		var s string
+		switch val := v.(type) {
+		case string:
+			s = val
+		case []byte:
+			s = string(val)
+		case nil:
+		default:
+			return nil, fmt.Errorf("expect %s but got %T", dt.Type(), v)
+		}
+		buf = binary.AppendUvarint(buf, uint64(len(s)))
+		return append(buf, s...), nil
+	case *dtArray:
+		items, err := typedArrayItems(v)
+		if err != nil {
+			return nil, err
+		}
+		buf = binary.AppendUvarint(buf, uint64(len(items)))
+		for _, item := range items {
+			if buf, err = appendTypedValue(buf, t.value, item); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case *dtMap:
+		if v == nil {
+			return append(buf, 0), nil
+		}
+		val := reflect.ValueOf(v)
+		if val.Kind() != reflect.Map {
+			return nil, fmt.Errorf("expect %s but got %T", dt.Type(), v)
+		}
+		buf = binary.AppendUvarint(buf, uint64(val.Len()))
+		var err error
+		iter := val.MapRange()
+		for iter.Next() {
+			key := iter.Key().Interface()
+			//数字key在服务器内部以带标记的字符串表示
+			if s, ok := key.(string); ok {
+				if n, err := mapKeyToNumber(s); err == nil {
+					key = float64(n)
+				}
+			}
+			if buf, err = appendTypedValue(buf, t.key, key); err != nil {
+				return nil, err
+			}
+			if buf, err = appendTypedValue(buf, t.value, iter.Value().Interface()); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case *dtStruct:
+		fields, ok := v.(map[string]interface{})
+		if !ok && v != nil {
+			return nil, fmt.Errorf("expect %s but got %T", dt.Type(), v)
+		}
+		var err error
+		for _, name := range typedStructFields(t) {
+			if buf, err = appendTypedValue(buf, t.props[name].dt, fields[name]); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+	//mailbox、table等没有固定结构的类型按msgpack编码
+	packed, err := GetProtocol().Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(packed)))
+	return append(buf, packed...), nil
+}
+
+// readTypedValue 按def类型解析单个值, 返回值与msgpack解析服务器内部消息的结果一致
+func readTypedValue(buf []byte, dt dataType) (interface{}, []byte, error) {
+	switch t := dt.(type) {
+	case *dtInt8, *dtInt16, *dtInt32, *dtInt64:
+		return readTypedVarint(buf)
+	case *dtUint8, *dtUint16, *dtUint32, *dtUint64:
+		return readTypedUvarint(buf)
+	case *dtFloat:
+		n, buf, err := readTypedVarint(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+		return float64(n) / float64(t.decimal), buf, nil
+	case *dtBool:
+		if len(buf) < 1 {
+			return nil, nil, errors.New("unexpected end of message")
+		}
+		return buf[0] != 0, buf[1:], nil
+	case *dtString:
+		b, buf, err := readTypedBytes(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+		return string(b), buf, nil
+	case *dtArray:
+		n, buf, err := readTypedUvarint(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n > uint64(len(buf)) {
+			return nil, nil, errors.New("array length out of range")
+		}
+		r := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			var item interface{}
+			if item, buf, err = readTypedValue(buf, t.value); err != nil {
+				return nil, nil, err
+			}
+			r = append(r, item)
+		}
+		return r, buf, nil
+	case *dtMap:
+		n, buf, err := readTypedUvarint(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n > uint64(len(buf)) {
+			return nil, nil, errors.New("map length out of range")
+		}
+		r := make(map[string]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			var key, value interface{}
+			if key, buf, err = readTypedValue(buf, t.key); err != nil {
+				return nil, nil, err
+			}
+			if value, buf, err = readTypedValue(buf, t.value); err != nil {
+				return nil, nil, err
+			}
+			if s, ok := key.(string); ok {
+				r[s] = value
+			} else {
+				r[numberToMapKey(lua.LNumber(InterfaceToInt(key)))] = value
+			}
+		}
+		return r, buf, nil
+	case *dtStruct:
+		r := make(map[string]interface{}, len(t.props))
+		for _, name := range typedStructFields(t) {
+			var value interface{}
+			var err error
+			if value, buf, err = readTypedValue(buf, t.props[name].dt); err != nil {
+				return nil, nil, err
+			}
+			r[name] = value
+		}
+		return r, buf, nil
+	}
+	b, buf, err := readTypedBytes(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	var r interface{}
+	if err = GetProtocol().UnMarshalTo(b, &r); err != nil {
+		return nil, nil, err
+	}
+	return r, buf, nil
+}
+
+func readTypedUvarint(buf []byte) (uint64, []byte, error) {
+	v, n := binary.Uvarint(buf)
+	if n <= 0 {
+		return 0, nil, errors.New("invalid varint")
+	}
+	return v, buf[n:], nil
+}
+
+func readTypedVarint(buf []byte) (int64, []byte, error) {
+	v, n := binary.Varint(buf)
+	if n <= 0 {
+		return 0, nil, errors.New("invalid varint")
+	}
+	return v, buf[n:], nil
+}
+
+func readTypedBytes(buf []byte) ([]byte, []byte, error) {
+	n, buf, err := readTypedUvarint(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n > uint64(len(buf)) {
+		return nil, nil, errors.New("unexpected end of message")
+	}
+	return buf[:n], buf[n:], nil
+}
+
+func isTypedNumber(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// typedArrayItems 数组的元素, 服务器内部数组可能以带数字标记key的字典表示
+func typedArrayItems(v interface{}) ([]interface{}, error) {
+	switch val := v.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		return val, nil
+	case map[string]interface{}:
+		r := make([]interface{}, len(val))
+		for key, item := range val {
+			n, err := mapKeyToNumber(key)
+			if err != nil || int(n) < 1 || int(n) > len(val) {
+				return nil, fmt.Errorf("invalid array index %s", key)
+			}
+			r[int(n)-1] = item
+		}
+		return r, nil
+	}
+	return nil, fmt.Errorf("expect array but got %T", v)
+}
+
+// typedStructFields struct的字段按名称排序, 与生成的客户端代码一致
+func typedStructFields(t *dtStruct) []string {
+	names := make([]string, 0, len(t.props))
+	for name := range t.props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
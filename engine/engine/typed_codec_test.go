@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTypedCodecRoundTrip(t *testing.T) {
+	if err := loadDefsForTool("../../scripts", STRobot); err != nil {
+		t.Fatalf("load defs error: %s", err.Error())
+	}
+	tests := []struct {
+		name       string
+		msgType    uint8
+		entityName string
+		toClient   bool
+		data       map[string]interface{}
+	}{
+		{"heartbeat", ClientMsgTypeHeartBeat, "", false, map[string]interface{}{}},
+		{"login with version", ClientMsgTypeLogin, "", false, map[string]interface{}{
+			ClientMsgDataFieldVersion: "abc",
+			ClientMsgDataFieldArgs:    []interface{}{"user", "password"},
+		}},
+		{"create entity to client", ClientMsgTypeCreateEntity, "", true, map[string]interface{}{
+			ClientMsgDataFieldEntityID: uint64(100), //与msgpack解码结果一致
+			ClientMsgDataFieldSeq:      uint64(3),
+			ClientMsgDataFieldArgs:     []interface{}{"Avatar", true},
+		}},
+		{"rpc of unknown entity", ClientMsgTypeEntityRpc, "", false, map[string]interface{}{
+			ClientMsgDataFieldEntityID: uint64(100), //与msgpack解码结果一致
+			ClientMsgDataFieldArgs:     []interface{}{"unknown", int64(1), "x"},
+		}},
+		{"rpc of unknown method", ClientMsgTypeEntityRpc, "Avatar", false, map[string]interface{}{
+			ClientMsgDataFieldEntityID: uint64(100), //与msgpack解码结果一致
+			ClientMsgDataFieldArgs:     []interface{}{"not_exist_method"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data
+			if tt.toClient {
+				data[ClientMsgDataFieldType] = tt.msgType
+			}
+			frame, err := MarshalTyped(tt.msgType, data, tt.entityName, tt.toClient)
+			if err != nil {
+				t.Fatalf("marshal error: %s", err.Error())
+			}
+			msgType, got, err := UnMarshalTyped(frame, func(EntityIdType) string { return tt.entityName }, tt.toClient)
+			if err != nil {
+				t.Fatalf("unmarshal error: %s", err.Error())
+			}
+			if msgType != tt.msgType {
+				t.Fatalf("message type = %d, want %d", msgType, tt.msgType)
+			}
+			if !reflect.DeepEqual(normalizeTyped(got), normalizeTyped(data)) {
+				t.Fatalf("round trip = %v, want %v", got, data)
+			}
+		})
+	}
+}
+
+func TestTypedCodecDefMethods(t *testing.T) {
+	if err := loadDefsForTool("../../scripts", STRobot); err != nil {
+		t.Fatalf("load defs error: %s", err.Error())
+	}
+	for _, toClient := range []bool{false, true} {
+		for _, name := range defMgr.GetAllEntityNames() {
+			methods := getTypedMethods(name, toClient)
+			for i, method := range methods.list {
+				//参数为nil时按参数类型的零值编码
+				args := make([]interface{}, len(method.def.args)+1)
+				args[0] = method.maskName
+				data := map[string]interface{}{
+					ClientMsgDataFieldEntityID: uint64(1),
+					ClientMsgDataFieldArgs:     args,
+				}
+				frame, err := MarshalTyped(ClientMsgTypeEntityRpc, data, name, toClient)
+				if err != nil {
+					t.Fatalf("%s.%s marshal error: %s", name, method.def.methodName, err.Error())
+				}
+				//entityId与序号各占1字节, 之后为函数序号
+				if i+1 < 0x80 && frame[3] != byte(i+1) {
+					t.Fatalf("%s.%s index = %d, want %d", name, method.def.methodName, frame[3], i+1)
+				}
+				_, got, err := UnMarshalTyped(frame, func(EntityIdType) string { return name }, toClient)
+				if err != nil {
+					t.Fatalf("%s.%s unmarshal error: %s", name, method.def.methodName, err.Error())
+				}
+				gotArgs, _ := got[ClientMsgDataFieldArgs].([]interface{})
+				if len(gotArgs) != len(args) || gotArgs[0] != method.maskName {
+					t.Fatalf("%s.%s round trip args = %v", name, method.def.methodName, gotArgs)
+				}
+			}
+		}
+	}
+}
+
+// normalizeTyped 数值统一为int64后比较, 解码出的整数类型与编码前不一定相同
+func normalizeTyped(v interface{}) interface{} {
+	switch r := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(r))
+		for k, item := range r {
+			out[k] = normalizeTyped(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, 0, len(r))
+		for _, item := range r {
+			out = append(out, normalizeTyped(item))
+		}
+		return out
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	}
+	return v
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	lua "github.com/seasondi/gopher-lua"
 	clientV3 "go.etcd.io/etcd/client/v3"
 	"rpg/engine/engine"
@@ -11,6 +12,10 @@ import (
 
 func registerApi() {
 	engine.RegisterEntryApi(gameAPI)
+	engine.RegisterEntityRpcRouter(func(entityId engine.EntityIdType, server string, data []byte) error {
+		return routeEntityRpc(entityId, server, data, nil)
+	})
+	engine.RegisterStubLocator(getStubProxy().GetStubId)
 }
 
 var gameAPI = map[string]lua.LGFunction{
@@ -55,6 +60,17 @@ var gameAPI = map[string]lua.LGFunction{
 		返回值: 无
 	*/
 	"callEntity": callEntity,
+	/*
+		callEntityAsync: 调用entity的方法并获取返回值, 被调用的函数需要在def中声明<Returns>
+		目标entity在本进程时回调立即执行
+		参数1: 被调用的entity id
+		参数2: 被调用的函数名(需要定义在def中)
+		参数3-n: 函数参数
+		参数n+1: 回调函数, function(errMsg, ...) end, 成功时errMsg为nil, 之后依次为函数的返回值
+		参数n+2: 超时时间,秒(可选, 默认5秒), 超时后回调的errMsg为"callback timeout"
+		返回值: 无
+	*/
+	"callEntityAsync": callEntityAsync,
 	/*
 		callStub: 调用stub的方法
 		参数1: 被调用的stub名称
@@ -63,6 +79,28 @@ var gameAPI = map[string]lua.LGFunction{
 		返回值: 无
 	*/
 	"callStub": callStub,
+	/*
+		hasStub: stub是否已存在(可能在其他game上), 用于避免重复创建已被故障转移重建的stub
+		参数1: stub名称
+		返回值: true: 已存在, false: 不存在
+	*/
+	"hasStub": hasStub,
+	/*
+		callStubByKey: 调用分片stub的方法, 按key的一致性哈希选取分片, 相同key总是落在同一分片上
+		参数1: 被调用的stub名称(需要在entities.xml中配置shards)
+		参数2: 分片key, number或string
+		参数3: 被调用的函数名(需要定义在def中)
+		参数4-n: 函数参数
+		返回值: 无
+	*/
+	"callStubByKey": callStubByKey,
+	/*
+		getStubMailBox: 获取stub的mailbox, 通过mb:method(args)调用stub的def服务端函数
+		stub重建后mailbox会自动找到新的stub
+		参数1: stub名称
+		返回值: mailbox, stub不存在时返回nil
+	*/
+	"getStubMailBox": getStubMailBox,
 	/*
 		createEntityLocally: 在本进程创建一个entity
 		参数1：entity名称
@@ -83,6 +121,17 @@ var gameAPI = map[string]lua.LGFunction{
 		返回值: true/false
 	*/
 	"setTimeOffset": setTimeOffset,
+	/*
+		migrateEntity: 将本进程的entity迁移到其他game, 属性与未触发的脚本定时器随entity迁移, 客户端连接重新绑定到目标game
+		迁移期间发给该entity的rpc会先缓存, 迁移成功后转发给目标game, 失败则在本进程继续处理
+		等待目标game超时后会通知其取消迁移, 目标game回复最终结果(或目标game失效)前entity保持冻结, 回调可能晚于超时时间
+		目标game上会调用entity的on_migrated_in(timerIds), timerIds为旧定时器id->新定时器id; 本进程的entity移除前调用on_migrated_out(targetServer)
+		参数1: entityId
+		参数2: 目标game的服务名
+		参数3: 回调函数(可选), function(entityId, errMsg) end
+		返回值: 是否开始迁移
+	*/
+	"migrateEntity": migrateEntity,
 }
 
 func loadEntityFromDB(L *lua.LState) int {
@@ -156,62 +205,155 @@ func callEntity(L *lua.LState) int {
 	top := L.GetTop()
 	entityId := L.CheckNumber(1)
 	funcName := L.CheckString(2)
+	args := make([]lua.LValue, 0, top)
+	for i := 3; i <= top; i++ {
+		args = append(args, L.CheckAny(i))
+	}
 
 	ent := engine.GetEntityManager().GetEntityById(engine.EntityIdType(entityId))
-	if ent != nil {
-		args := make([]lua.LValue, 0, 0)
-		for i := 3; i <= top; i++ {
-			args = append(args, L.CheckAny(i))
-		}
+	if ent != nil && !ent.IsMigrating() {
 		if err := ent.CallDefServerMethod(funcName, args, false); err != nil {
 			log.Errorf("call %s function[%s] error: %s", ent.String(), funcName, err.Error())
 			return 0
 		}
-	} else {
-		ctx, cancel := context.WithTimeout(context.TODO(), 500*time.Millisecond)
-		defer cancel()
+	} else if err := sendEntityRpc(engine.EntityIdType(entityId), funcName, args, nil); err != nil {
+		log.Warnf("call entity[%d] function[%s] error: %s", entityId, funcName, err.Error())
+	}
+	return 0
+}
 
-		result := engine.EtcdValue{}
-		if err := engine.GetRedisMgr().Get(ctx, engine.GetRedisEntityKey(engine.EntityIdType(entityId)), &result); err != nil {
-			log.Warnf("call entity[%d] function[%s], error: %s", entityId, funcName, err.Error())
-			return 0
+func callEntityAsync(L *lua.LState) int {
+	//1: entityId
+	//2: def server method name
+	//3-n: args, 回调函数, 超时时间(可选)
+	top := L.GetTop()
+	entityId := engine.EntityIdType(L.CheckNumber(1))
+	funcName := L.CheckString(2)
+	cbIndex := top
+	timeout := 5 * time.Second
+	if top > 3 && L.Get(top).Type() == lua.LTNumber && L.Get(top-1).Type() == lua.LTFunction {
+		cbIndex = top - 1
+		if t := L.CheckNumber(top); t > 0 {
+			timeout = time.Duration(float64(t) * float64(time.Second))
 		}
-		targetServer, ok := result[engine.EtcdValueServer].(string)
-		if !ok {
-			log.Warnf("call entity[%d] function[%s] but target server not found, etcd info: %+v", entityId, funcName, result)
-			return 0
+	}
+	cb := L.Get(cbIndex)
+	if cbIndex < 3 || cb.Type() != lua.LTFunction {
+		log.Errorf("callEntityAsync entity[%d] function[%s] callback must be function%s", entityId, funcName, engine.GetLuaTraceback())
+		return 0
+	}
+	args := make([]lua.LValue, 0, top)
+	for i := 3; i < cbIndex; i++ {
+		args = append(args, L.CheckAny(i))
+	}
+
+	ex := getCallbackMgr().NewExtraInfo()
+	uuid := ex.Uuid
+	getCallbackMgr().setCallbackWithTimeout(uuid, &entityRpcCallback{entityId: entityId, method: funcName, luaFunc: cb}, timeout)
+	ent := engine.GetEntityManager().GetEntityById(entityId)
+	if ent != nil && !ent.IsMigrating() {
+		rets, err := ent.CallDefServerMethodWithReturns(funcName, args)
+		getCallbackMgr().Call(uuid, err, rets...)
+	} else if err := sendEntityRpc(entityId, funcName, args, ex); err != nil {
+		getCallbackMgr().Call(uuid, err)
+	}
+	return 0
+}
+
+// sendEntityRpc 调用不在本进程或迁移中的entity, ex不为nil时需要对方返回结果
+func sendEntityRpc(entityId engine.EntityIdType, funcName string, args []lua.LValue, ex *message.ExtraInfo) error {
+	data, err := engine.GenEntityRpcData(entityId, funcName, args)
+	if err != nil {
+		return fmt.Errorf("message marshal error: %s", err.Error())
+	}
+	return routeEntityRpc(entityId, "", data, ex)
+}
+
+// routeEntityRpc 将entity rpc消息发往entity所在的game, server为entity最后已知的所在进程, 为空时查询位置缓存
+func routeEntityRpc(entityId engine.EntityIdType, server string, data []byte, ex *message.ExtraInfo) error {
+	traceId := engine.TraceId()
+	if ent := engine.GetEntityManager().GetEntityById(entityId); ent != nil {
+		rpc := &message.GameEntityRpc{Data: data, Source: engine.ServiceName(), FromServer: true, Ex: ex, ReplyTo: engine.ServiceName(), TraceId: traceId}
+		buf, err := rpc.Marshal()
+		if err != nil {
+			return err
 		}
-		args := []interface{}{funcName}
-		for i := 3; i <= top; i++ {
-			val := L.CheckAny(i)
-			if val.Type() == lua.LTTable {
-				r := engine.TableToMap(val.(*lua.LTable))
-				args = append(args, r)
-			} else if val.Type() == lua.LTNil {
-				args = append(args, engine.LuaTableValueNilField)
-			} else {
-				args = append(args, L.CheckAny(i))
-			}
+		//entity迁移中, 先缓存, 迁移结束后转发给目标game或本地处理
+		if ent.IsMigrating() {
+			ent.BufferMessage(engine.ServerMessageTypeEntityRpc, 0, buf)
+			return nil
 		}
-		dataMap := map[string]interface{}{
-			engine.ClientMsgDataFieldEntityID: entityId,
-			engine.ClientMsgDataFieldArgs:     args,
+		return processEntityRpc(buf)
+	}
+
+	//entity已不在本进程, 最后已知的位置失效
+	if server == engine.ServiceName() {
+		server = ""
+	}
+	if cached := getLocationCache().cached(entityId); cached != "" {
+		server = cached
+	}
+	if server != "" {
+		return sendRouterRpc(server, data, ex, traceId)
+	}
+	//位置未知, 异步查询, 查询期间的调用排队等待
+	getLocationCache().resolve(entityId, func(server string, err error) {
+		if err == nil {
+			err = sendRouterRpc(server, data, ex, traceId)
 		}
-		data, err := engine.GetProtocol().Marshal(dataMap)
 		if err != nil {
-			log.Warnf("call entity[%d] function[%s] but message marshal error: %s", entityId, funcName, err.Error())
-			return 0
-		}
-		msg := &message.GameRouterRpc{
-			Target: targetServer,
-			Data:   data,
-		}
-		if err = getGateProxy().SendToGate(engine.GenMessageHeader(engine.ServerMessageTypeEntityRouter, 0), msg, nil); err != nil {
-			log.Warnf("call entity[%d] function[%s] msg send error: %s", entityId, funcName, err.Error())
-			return 0
+			engine.TraceLogger(traceId).Warnf("route rpc to entity[%d] error: %s", entityId, err.Error())
+			if ex != nil {
+				getCallbackMgr().Call(ex.Uuid, err)
+			}
 		}
+	})
+	return nil
+}
+
+// sendRouterRpc 将entity rpc消息发给server, 优先使用game之间的直连, 直连不可用时经gate转发
+func sendRouterRpc(server string, data []byte, ex *message.ExtraInfo, traceId string) error {
+	rpc := &message.GameEntityRpc{
+		Data:       data,
+		Source:     engine.ServiceName(),
+		FromServer: true,
+		Ex:         ex,
+		ReplyTo:    engine.ServiceName(),
+		TraceId:    traceId,
 	}
-	return 0
+	if err := getPeerProxy().Send(server, engine.ServerMessageTypeEntityRpc, rpc); err == nil {
+		return nil
+	}
+	msg := &message.GameRouterRpc{
+		Target:  server,
+		Data:    data,
+		Ex:      ex,
+		Source:  engine.ServiceName(),
+		TraceId: traceId,
+	}
+	if err := getGateProxy().SendToGate(engine.GenMessageHeader(engine.ServerMessageTypeEntityRouter, 0), msg, nil); err != nil {
+		return fmt.Errorf("msg send error: %s", err.Error())
+	}
+	return nil
+}
+
+func getStubMailBox(L *lua.LState) int {
+	//1: stub名称
+	stubName := L.CheckString(1)
+	entityId := getStubProxy().GetStubId(stubName)
+	if entityId <= 0 {
+		log.Warnf("get stub[%s] mailbox but not found", stubName)
+		L.Push(lua.LNil)
+		return 1
+	}
+	mb := &engine.StubMailBox{EntityMailBox: engine.EntityMailBox{EntityId: entityId, EntityName: stubName}}
+	if engine.GetEntityManager().GetEntityById(entityId) != nil {
+		mb.Server = engine.ServiceName()
+	} else {
+		mb.Server = getLocationCache().cached(entityId)
+	}
+	L.Push(engine.MailBoxToTable(mb))
+	return 1
 }
 
 func callStub(L *lua.LState) int {
@@ -236,49 +378,53 @@ func callStub(L *lua.LState) int {
 			return 0
 		}
 	} else {
-		ctx, cancel := context.WithTimeout(context.TODO(), 500*time.Millisecond)
-		defer cancel()
-		result := engine.EtcdValue{}
-		if err := engine.GetRedisMgr().Get(ctx, engine.GetRedisEntityKey(entityId), &result); err != nil {
-			log.Warnf("call entity[%d] function[%s] but not found entity", entityId, funcName)
-			return 0
-		}
-		targetServer, ok := result[engine.EtcdValueServer].(string)
-		if !ok {
-			log.Warnf("call entity[%d] function[%s] but target server not found, etcd info: %+v", entityId, funcName, result)
-			return 0
-		}
-		args := []interface{}{funcName}
+		args := make([]lua.LValue, 0, top)
 		for i := 3; i <= top; i++ {
-			val := L.CheckAny(i)
-			if val.Type() == lua.LTTable {
-				r := engine.TableToMap(val.(*lua.LTable))
-				args = append(args, r)
-			} else if val.Type() == lua.LTNil {
-				args = append(args, engine.LuaTableValueNilField)
-			} else {
-				args = append(args, L.CheckAny(i))
-			}
-		}
-		dataMap := map[string]interface{}{
-			engine.ClientMsgDataFieldEntityID: entityId,
-			engine.ClientMsgDataFieldArgs:     args,
-		}
-		data, err := engine.GetProtocol().Marshal(dataMap)
-		if err != nil {
-			log.Warnf("call entity[%d] function[%s] but message marshal error: %s", entityId, funcName, err.Error())
-			return 0
+			args = append(args, L.CheckAny(i))
 		}
-		msg := &message.GameRouterRpc{
-			Target: targetServer,
-			Data:   data,
+		if err := sendEntityRpc(entityId, funcName, args, nil); err != nil {
+			log.Warnf("call entity[%d] function[%s] error: %s", entityId, funcName, err.Error())
 		}
-		if err = getGateProxy().SendToGate(engine.GenMessageHeader(engine.ServerMessageTypeEntityRouter, 0), msg, nil); err != nil {
-			log.Warnf("call entity[%d] function[%s] msg send error: %s", entityId, funcName, err.Error())
-			return 0
-		} else {
-			log.Tracef("call entity[%d] function[%s], target server[%s] send success", entityId, funcName, targetServer)
+	}
+	return 0
+}
+
+func hasStub(L *lua.LState) int {
+	//1: stub名称
+	stubName := L.CheckString(1)
+	L.Push(lua.LBool(getStubProxy().GetStubId(stubName) > 0))
+	return 1
+}
+
+func callStubByKey(L *lua.LState) int {
+	//1: stub名称
+	//2: 分片key
+	//3: 函数名
+	//4-n: 函数参数
+	top := L.GetTop()
+	stubName := L.CheckString(1)
+	key := L.CheckAny(2)
+	funcName := L.CheckString(3)
+	if key.Type() != lua.LTNumber && key.Type() != lua.LTString {
+		log.Errorf("call stub[%s] function[%s] key must be number or string%s", stubName, funcName, engine.GetLuaTraceback())
+		return 0
+	}
+
+	entityId := getStubProxy().GetShardStubId(stubName, key.String())
+	if entityId <= 0 {
+		log.Warnf("call stub[%s] by key[%s] but no shard found", stubName, key.String())
+		return 0
+	}
+	args := make([]lua.LValue, 0, top)
+	for i := 4; i <= top; i++ {
+		args = append(args, L.CheckAny(i))
+	}
+	if ent := engine.GetEntityManager().GetEntityById(entityId); ent != nil {
+		if err := ent.CallDefServerMethod(funcName, args, false); err != nil {
+			log.Errorf("call %s function[%s] error: %s", ent.String(), funcName, err.Error())
 		}
+	} else if err := sendEntityRpc(entityId, funcName, args, nil); err != nil {
+		log.Warnf("call entity[%d] function[%s] error: %s", entityId, funcName, err.Error())
 	}
 	return 0
 }
@@ -400,3 +546,28 @@ func setTimeOffset(L *lua.LState) int {
 	L.Push(lua.LBool(ret))
 	return 1
 }
+
+func migrateEntity(L *lua.LState) int {
+	//1: entityId
+	//2: 目标game
+	//3: 回调函数(可选)
+
+	entityId := L.CheckNumber(1)
+	target := L.CheckString(2)
+	var cb lua.LValue = lua.LNil
+	if L.GetTop() >= 3 {
+		cb = L.CheckAny(3)
+		if cb.Type() != lua.LTFunction && cb.Type() != lua.LTTable {
+			log.Errorf("migrateEntity args 3 must be function or callable table")
+			L.Push(lua.LBool(false))
+			return 1
+		}
+	}
+	if err := getMigrateProxy().migrateEntity(engine.EntityIdType(entityId), target, cb); err != nil {
+		log.Errorf("migrate entity[%d] to %s error: %s", int64(entityId), target, err.Error())
+		L.Push(lua.LBool(false))
+		return 1
+	}
+	L.Push(lua.LBool(true))
+	return 1
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"rpg/engine/engine"
+	"rpg/engine/message"
 	"errors"
 	"fmt"
 	"time"
@@ -69,3 +70,8 @@ func (m *callback) NextUniqueID() string {
 	callbackUniqueID += 1
 	return fmt.Sprintf("%d_%d_%d", engine.GetConfig().ServerId, engine.GetCmdLine().Tag, callbackUniqueID)
 }
+
+// NewExtraInfo 生成需要回调的请求信息, 带上当前的trace id, 回调时恢复
+func (m *callback) NewExtraInfo() *message.ExtraInfo {
+	return &message.ExtraInfo{Uuid: m.NextUniqueID(), TraceId: engine.TraceId()}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	lua "github.com/seasondi/gopher-lua"
 	"rpg/engine/engine"
 )
@@ -89,38 +90,30 @@ func (m *createEntityAnywhereCallback) Process(err error, params ...interface{})
 	_ = engine.CallLuaMethod(engine.NewLuaMethod(m.luaFunc, "createEntityAnywhereCallback"), 0, args...)
 }
 
-//==================================entity销毁时存盘回调==================================
+//==================================entity存盘回调==================================
 
-type saveEntityOnDestroyCallback struct {
+// saveEntityCallback entity存盘结果, 超时按失败处理
+type saveEntityCallback struct {
 	timerId int64
+	info    *engine.EntitySaveInfo
 }
 
-func (m *saveEntityOnDestroyCallback) setTimerId(id int64) {
+func (m *saveEntityCallback) setTimerId(id int64) {
 	m.timerId = id
 }
 
-func (m *saveEntityOnDestroyCallback) cancelTimer() {
+func (m *saveEntityCallback) cancelTimer() {
 	if m.timerId > 0 {
 		engine.GetTimer().Cancel(m.timerId)
 		m.timerId = 0
 	}
 }
 
-func (m *saveEntityOnDestroyCallback) Process(err error, params ...interface{}) {
-	id := engine.EntityIdType(0)
-	if err != nil {
-		log.Warnf("saveEntityOnDestroyCallback return error: %s, do nothing", err.Error())
-		return
-	} else if len(params) > 0 {
-		id = engine.EntityIdType(engine.InterfaceToInt(params[0]))
+func (m *saveEntityCallback) Process(err error, _ ...interface{}) {
+	if ent := engine.GetEntityManager().GetEntityById(m.info.EntityId); ent != nil {
+		ent.OnSaveResult(m.info, err)
 	} else {
-		log.Warnf("saveEntityOnDestroyCallback invalid params length: %+v, do nothing", params)
-		return
-	}
-	if ent := engine.GetEntityManager().GetEntityById(id); ent != nil {
-		ent.SavedOnDestroyCallback()
-	} else {
-		log.Errorf("saveEntityOnDestroyCallback but not found entity, id: %d", id)
+		log.Warnf("saveEntityCallback but not found entity, id: %d", m.info.EntityId)
 	}
 }
 
@@ -161,3 +154,131 @@ func (m *dbRawCommandCallback) Process(err error, params ...interface{}) {
 	}
 	_ = engine.CallLuaMethod(engine.NewLuaMethod(m.luaFunc, "dbRawCommandCallback"), 0, args...)
 }
+
+//==================================entity迁移回调==================================
+
+type migrateEntityCallback struct {
+	timerId  int64
+	uuid     string //迁移请求的uuid
+	entityId engine.EntityIdType
+	target   string
+	luaFunc  lua.LValue
+}
+
+func (m *migrateEntityCallback) setTimerId(id int64) {
+	m.timerId = id
+}
+
+func (m *migrateEntityCallback) cancelTimer() {
+	if m.timerId > 0 {
+		engine.GetTimer().Cancel(m.timerId)
+		m.timerId = 0
+	}
+}
+
+func (m *migrateEntityCallback) Process(err error, _ ...interface{}) {
+	if err == timeoutErr {
+		//目标game可能仍会创建entity, 取消并等待其回复最终结果后再恢复
+		log.Warnf("migrate entity[%d] to %s timeout, abort it", m.entityId, m.target)
+		getMigrateProxy().abortMigrate(m.entityId, m.target, m.uuid, m.luaFunc)
+		return
+	}
+	getMigrateProxy().finishMigrate(m.entityId, m.target, m.luaFunc, err)
+}
+
+//==================================取消entity迁移回调==================================
+
+type migrateAbortCallback struct {
+	timerId     int64
+	migrateUuid string //被取消的迁移请求的uuid
+	entityId    engine.EntityIdType
+	target      string
+	luaFunc     lua.LValue
+}
+
+func (m *migrateAbortCallback) setTimerId(id int64) {
+	m.timerId = id
+}
+
+func (m *migrateAbortCallback) cancelTimer() {
+	if m.timerId > 0 {
+		engine.GetTimer().Cancel(m.timerId)
+		m.timerId = 0
+	}
+}
+
+// Process 目标game回复成功表示已创建entity, 以迁移成功处理; 失败表示不会再创建, 本地恢复
+func (m *migrateAbortCallback) Process(err error, _ ...interface{}) {
+	if err == timeoutErr {
+		if _, alive := getPeerProxy().peers[m.target]; alive {
+			getMigrateProxy().abortMigrate(m.entityId, m.target, m.migrateUuid, m.luaFunc)
+			return
+		}
+		//目标game已失效, 其创建的entity随之消失, 可以本地恢复
+		err = fmt.Errorf("target game %s lost", m.target)
+	}
+	getMigrateProxy().finishMigrate(m.entityId, m.target, m.luaFunc, err)
+}
+
+//==================================entity rpc返回值回调==================================
+
+type entityRpcCallback struct {
+	timerId  int64
+	entityId engine.EntityIdType
+	method   string
+	luaFunc  lua.LValue
+}
+
+func (m *entityRpcCallback) setTimerId(id int64) {
+	m.timerId = id
+}
+
+func (m *entityRpcCallback) cancelTimer() {
+	if m.timerId > 0 {
+		engine.GetTimer().Cancel(m.timerId)
+		m.timerId = 0
+	}
+}
+
+func (m *entityRpcCallback) Process(err error, params ...interface{}) {
+	args := []lua.LValue{lua.LNil}
+	if err != nil {
+		log.Warnf("call entity[%d] method[%s] with returns error: %s", m.entityId, m.method, err.Error())
+		args[0] = lua.LString(err.Error())
+	} else {
+		args = append(args, engine.InterfaceToLValues(params)...)
+	}
+	_ = engine.CallLuaMethod(engine.NewLuaMethod(m.luaFunc, "entityRpcCallback"), 0, args...)
+}
+
+//==================================重建stub时加载存盘数据回调==================================
+
+type recreateStubCallback struct {
+	timerId  int64
+	name     string
+	entityId engine.EntityIdType
+	release  func()
+}
+
+func (m *recreateStubCallback) setTimerId(id int64) {
+	m.timerId = id
+}
+
+func (m *recreateStubCallback) cancelTimer() {
+	if m.timerId > 0 {
+		engine.GetTimer().Cancel(m.timerId)
+		m.timerId = 0
+	}
+}
+
+func (m *recreateStubCallback) Process(err error, params ...interface{}) {
+	var data map[string]interface{}
+	if err == nil {
+		if len(params) >= 2 {
+			data, _ = params[1].(map[string]interface{})
+		} else {
+			err = errors.New("invalid params length")
+		}
+	}
+	getStubSupervisor().onStubDataLoaded(m.name, m.entityId, data, m.release, err)
+}
@@ -73,6 +73,8 @@ func processDBCommandResponse(buf []byte) error {
 	}
 
 	if msg.Ex != nil {
+		engine.SetTraceId(msg.Ex.TraceId)
+		defer engine.SetTraceId("")
 		var e error
 		if len(msg.ErrMsg) > 0 {
 			e = errors.New(string(msg.ErrMsg))
@@ -142,19 +144,19 @@ func (m *dbProxy) collection(entityId engine.EntityIdType) string {
 
 func (m *dbProxy) saveEntity(data *engine.EntitySaveInfo) error {
 	msg := &message.DBCommandRequest{
-		TaskType:   uint32(engine.DBTaskTypeUpdateOne),
+		TaskType:   uint32(engine.DBTaskTypeUpdateFields),
 		EntityId:   int64(data.EntityId),
 		Database:   m.database(),
 		Collection: m.collection(data.EntityId),
 		Filter:     m.entityIdFilter(data.EntityId),
 		Data:       data.Data,
 		DbType:     uint32(engine.DBTypeProject),
+		TraceId:    engine.TraceId(),
 	}
 
-	if data.NeedResponse {
-		msg.Ex = &message.ExtraInfo{Uuid: getCallbackMgr().NextUniqueID()}
-		getCallbackMgr().setCallbackWithTimeout(msg.Ex.Uuid, &saveEntityOnDestroyCallback{}, 5*time.Second)
-	}
+	//存盘结果确认后entity才清除待存盘字段
+	msg.Ex = getCallbackMgr().NewExtraInfo()
+	getCallbackMgr().setCallbackWithTimeout(msg.Ex.Uuid, &saveEntityCallback{info: data}, 5*time.Second)
 
 	if buf, err := engine.GetProtocol().MessageWithHead([]byte{engine.ServerMessageTypeDBCommand}, msg); err != nil {
 		log.Errorf("saveEntity[%d] generate message error: %s", msg.EntityId, err.Error())
@@ -169,6 +171,15 @@ func (m *dbProxy) saveEntity(data *engine.EntitySaveInfo) error {
 }
 
 func (m *dbProxy) loadEntityFromDB(entityId engine.EntityIdType, luaCb lua.LValue, timeout time.Duration) {
+	var cb callbackInterface
+	if luaCb != nil {
+		cb = &queryDBEntityCallback{luaFunc: luaCb}
+	}
+	m.queryEntity(entityId, cb, timeout)
+}
+
+// queryEntity 查询entity存盘数据, cb不为nil时以(entityId, data)回调
+func (m *dbProxy) queryEntity(entityId engine.EntityIdType, cb callbackInterface, timeout time.Duration) {
 	msg := &message.DBCommandRequest{
 		TaskType:   uint32(engine.DBTaskTypeQueryOne),
 		EntityId:   int64(entityId),
@@ -176,18 +187,19 @@ func (m *dbProxy) loadEntityFromDB(entityId engine.EntityIdType, luaCb lua.LValu
 		Collection: m.collection(entityId),
 		Filter:     m.entityIdFilter(entityId),
 		DbType:     uint32(engine.DBTypeProject),
+		TraceId:    engine.TraceId(),
 	}
 
-	if luaCb != nil {
-		msg.Ex = &message.ExtraInfo{Uuid: getCallbackMgr().NextUniqueID()}
-		getCallbackMgr().setCallbackWithTimeout(msg.Ex.Uuid, &queryDBEntityCallback{luaFunc: luaCb}, timeout)
+	if cb != nil {
+		msg.Ex = getCallbackMgr().NewExtraInfo()
+		getCallbackMgr().setCallbackWithTimeout(msg.Ex.Uuid, cb, timeout)
 	}
 
 	if buf, err := engine.GetProtocol().MessageWithHead([]byte{engine.ServerMessageTypeDBCommand}, msg); err != nil {
-		log.Errorf("loadEntityFromDB generate message error: %s", err.Error())
+		log.Errorf("queryEntity generate message error: %s", err.Error())
 	} else {
 		if _, err = m.conn.Send(buf); err != nil {
-			log.Warnf("loadEntityFromDB send message error: %s, entityId: %d", err.Error(), entityId)
+			log.Warnf("queryEntity send message error: %s, entityId: %d", err.Error(), entityId)
 		}
 	}
 }
@@ -211,9 +223,10 @@ func (m *dbProxy) executeDBRawCommand(dbType engine.DBType, taskType engine.DBTa
 		Filter:     filterBytes,
 		Data:       dataBytes,
 		DbType:     uint32(dbType),
+		TraceId:    engine.TraceId(),
 	}
 	if luaCb != nil {
-		msg.Ex = &message.ExtraInfo{Uuid: getCallbackMgr().NextUniqueID()}
+		msg.Ex = getCallbackMgr().NewExtraInfo()
 		getCallbackMgr().setCallbackWithTimeout(msg.Ex.Uuid, &dbRawCommandCallback{luaFunc: luaCb}, timeout)
 	}
 
@@ -18,6 +18,8 @@ func (m *etcdWatcher) OnUpdated(kv *engine.EtcdKV) {
 	key := kv.Key()
 	if strings.HasPrefix(key, engine.StubPrefix) {
 		getStubProxy().HandleUpdate(kv.Key(), kv.Value())
+	} else if strings.HasPrefix(key, engine.ServiceGamePrefix) {
+		getPeerProxy().HandleUpdate(kv.Key(), kv.Value())
 	}
 }
 
@@ -26,5 +28,7 @@ func (m *etcdWatcher) OnDelete(kv *engine.EtcdKV) {
 	key := kv.Key()
 	if strings.HasPrefix(key, engine.StubPrefix) {
 		getStubProxy().HandleDelete(kv.Key())
+	} else if strings.HasPrefix(key, engine.ServiceGamePrefix) {
+		getPeerProxy().HandleDelete(kv.Key())
 	}
 }
@@ -26,6 +26,10 @@ func (m *eventLoop) tick() {
 	}()
 
 	engine.GetTimer().AddTimer(0, time.Second, m.reportLoad)
+	if engine.GetConfig().ServerConfig().IsStub {
+		engine.GetTimer().AddTimer(stubShardCheckTime, stubShardCheckTime, checkStubShards)
+		engine.GetTimer().AddTimer(stubFailoverCheckTime, stubFailoverCheckTime, getStubSupervisor().check)
+	}
 
 	for {
 		if quit.Load() == quitStatusQuited {
@@ -121,8 +125,10 @@ func (m *eventLoop) React(frame []byte, c gnet.Conn) (out []byte, action gnet.Ac
 
 func (m *eventLoop) serverTick() time.Duration {
 	engine.Tick()
+	engine.SetTraceId("")
 	getTaskManager().Tick()
 	getDBProxy().Tick()
+	getPeerProxy().Tick()
 	return engine.ServerTick
 }
 
@@ -130,4 +136,5 @@ func (m *eventLoop) disconnectServer() {
 	if getDBProxy().conn != nil {
 		getDBProxy().conn.Disconnect()
 	}
+	getPeerProxy().Disconnect()
 }
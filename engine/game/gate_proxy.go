@@ -123,7 +123,7 @@ func (m *gateProxy) CreateEntityAnywhere(entityName string, luaCb lua.LValue) {
 	msg := &message.CreateEntityRequest{
 		EntityName: entityName,
 		ServerName: engine.ServiceName(),
-		Ex:         &message.ExtraInfo{Uuid: getCallbackMgr().NextUniqueID()},
+		Ex:         getCallbackMgr().NewExtraInfo(),
 	}
 	getCallbackMgr().setCallbackWithTimeout(msg.Ex.Uuid, &createEntityAnywhereCallback{luaFunc: luaCb}, 3*time.Second)
 	if err := m.SendToGate(engine.GenMessageHeader(engine.ServerMessageTypeCreateGameEntity, 0), msg, nil); err != nil {
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"rpg/engine/engine"
+	"time"
+)
+
+const (
+	locationCacheTTL     = 5 * time.Minute        //缓存有效期, 防止丢失位置变化通知后一直使用错误的位置
+	locationQueryTimeout = 500 * time.Millisecond //查询redis超时时间
+)
+
+var (
+	locationMgr               *locationCache
+	errEntityLocationNotFound = errors.New("entity location not found")
+)
+
+type locationCallback func(server string, err error)
+
+type locationEntry struct {
+	server string    //entity所在的game
+	expire time.Time //过期时间
+}
+
+type locationLookup struct {
+	callbacks []locationCallback //等待查询结果的调用
+	updated   bool               //查询期间收到了位置变化通知
+	server    string             //查询期间收到的最新位置
+}
+
+// locationCache entity位置缓存, 缓存未命中时异步查询redis, 查询期间的调用排队等待结果
+type locationCache struct {
+	entries map[engine.EntityIdType]*locationEntry
+	pending map[engine.EntityIdType]*locationLookup
+}
+
+func getLocationCache() *locationCache {
+	if locationMgr == nil {
+		locationMgr = new(locationCache)
+		locationMgr.init()
+	}
+	return locationMgr
+}
+
+func (m *locationCache) init() {
+	m.entries = make(map[engine.EntityIdType]*locationEntry)
+	m.pending = make(map[engine.EntityIdType]*locationLookup)
+}
+
+// cached 缓存中entity所在的game, 未命中返回空
+func (m *locationCache) cached(entityId engine.EntityIdType) string {
+	if entry, ok := m.entries[entityId]; ok {
+		if time.Now().Before(entry.expire) {
+			return entry.server
+		}
+		delete(m.entries, entityId)
+	}
+	return ""
+}
+
+// resolve 查询entity所在的game, 缓存命中时cb立即执行, 否则在查询结束后执行
+func (m *locationCache) resolve(entityId engine.EntityIdType, cb locationCallback) {
+	if server := m.cached(entityId); server != "" {
+		cb(server, nil)
+		return
+	}
+	if lookup, ok := m.pending[entityId]; ok {
+		lookup.callbacks = append(lookup.callbacks, cb)
+		return
+	}
+	m.pending[entityId] = &locationLookup{callbacks: []locationCallback{cb}}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.TODO(), locationQueryTimeout)
+		defer cancel()
+		task := &LocationResolvedTask{entityId: entityId}
+		result := engine.EtcdValue{}
+		if task.err = engine.GetRedisMgr().Get(ctx, engine.GetRedisEntityKey(entityId), &result); task.err == nil {
+			task.server, _ = result[engine.EtcdValueServer].(string)
+		}
+		getTaskManager().Push(task)
+	}()
+}
+
+func (m *locationCache) onResolved(entityId engine.EntityIdType, server string, err error) {
+	lookup, ok := m.pending[entityId]
+	if !ok {
+		return
+	}
+	delete(m.pending, entityId)
+	if lookup.updated {
+		//查询期间位置发生过变化, 以通知为准
+		server, err = lookup.server, nil
+	}
+	if err == nil && server == "" {
+		err = errEntityLocationNotFound
+	}
+	if err == nil {
+		m.entries[entityId] = &locationEntry{server: server, expire: time.Now().Add(locationCacheTTL)}
+	}
+	for _, cb := range lookup.callbacks {
+		cb(server, err)
+	}
+}
+
+// update 收到entity位置变化通知, server为空表示entity已移除
+func (m *locationCache) update(entityId engine.EntityIdType, server string) {
+	if lookup, ok := m.pending[entityId]; ok {
+		lookup.updated = true
+		lookup.server = server
+	}
+	if server == "" {
+		delete(m.entries, entityId)
+	} else if _, ok := m.entries[entityId]; ok {
+		m.entries[entityId] = &locationEntry{server: server, expire: time.Now().Add(locationCacheTTL)}
+	}
+}
+
+// watchEntityLocation 订阅entity位置变化通知
+func watchEntityLocation() {
+	pubSub, err := engine.GetRedisMgr().Subscribe(context.Background(), engine.RedisEntityLocationChannel())
+	if err != nil {
+		log.Errorf("subscribe entity location error: %s", err.Error())
+		return
+	}
+	go func() {
+		defer func() { _ = pubSub.Close() }()
+		for msg := range pubSub.Channel() {
+			info := engine.EtcdValue{}
+			if err := json.Unmarshal([]byte(msg.Payload), &info); err != nil {
+				log.Warnf("invalid entity location message: %s, error: %s", msg.Payload, err.Error())
+				continue
+			}
+			server, _ := info[engine.EtcdValueServer].(string)
+			getTaskManager().Push(&LocationUpdateTask{
+				entityId: engine.EntityIdType(engine.InterfaceToInt(info[engine.EtcdValueEntityId])),
+				server:   server,
+			})
+		}
+	}()
+}
@@ -23,6 +23,8 @@ func main() {
 	registerApi()
 	initServer()
 	syncStubFromEtcd()
+	syncPeerFromEtcd()
+	watchEntityLocation()
 	initSysSignalMgr()
 
 	defer getDBProxy().Close()
@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"github.com/panjf2000/gnet"
 	lua "github.com/seasondi/gopher-lua"
 	"rpg/engine/engine"
@@ -35,6 +36,10 @@ func processSyncGate(buf []byte, c gnet.Conn) error {
 		return err
 	}
 	setCtxServiceName(c, msg.ServiceName)
+	if msg.IsGame {
+		log.Infof("game[%s -> %s] connected", msg.ServiceName, c.RemoteAddr())
+		return nil
+	}
 	getGateProxy().AddGate(c, msg.ServiceName, msg.Inner)
 	return nil
 }
@@ -45,6 +50,7 @@ func processEntityRpc(buf []byte) error {
 	if err := msg.Unmarshal(buf); err != nil {
 		return err
 	}
+	engine.SetTraceId(msg.TraceId)
 	r, err := engine.GetProtocol().UnMarshal(msg.Data)
 	if err != nil {
 		return err
@@ -53,9 +59,17 @@ func processEntityRpc(buf []byte) error {
 	if entityId == 0 {
 		return errors.New("not found entity field")
 	}
+	if getMigrateProxy().bufferOrForward(engine.EntityIdType(entityId), engine.ServerMessageTypeEntityRpc, 0, buf) {
+		return nil
+	}
+	//调用方需要返回值
+	needReturns := msg.Ex != nil && msg.Ex.Uuid != ""
 	ent := engine.GetEntityManager().GetEntityById(engine.EntityIdType(entityId))
 	if ent == nil {
-		log.Warnf("gate call entity[%v] method but entity not found", entityId)
+		engine.MainLogger().Warnf("gate call entity[%v] method but entity not found", entityId)
+		if needReturns {
+			replyEntityRpc(msg.ReplyTo, msg.Ex, nil, fmt.Errorf("entity[%d] not found", entityId))
+		}
 		return nil
 	}
 
@@ -69,13 +83,21 @@ func processEntityRpc(buf []byte) error {
 	if method, ok := params[0].(string); !ok {
 		return errors.New("invalid method name")
 	} else {
-		log.Tracef("call %s server method: %s, is from server: %v", ent.String(), method, msg.FromServer)
+		engine.MainLogger().Tracef("call %s server method: %s, is from server: %v", ent.String(), method, msg.FromServer)
 		args := engine.InterfaceToLValues(params[1:])
+		if needReturns {
+			rets, err := ent.CallDefServerMethodWithReturns(method, args)
+			if err != nil {
+				engine.MainLogger().Warnf("call %s method[%s] with returns error: %s", ent.String(), method, err.Error())
+			}
+			replyEntityRpc(msg.ReplyTo, msg.Ex, rets, err)
+			return nil
+		}
 		if !msg.FromServer {
 			args = append([]lua.LValue{lua.LNumber(entityId)}, args...)
 		}
 		if err = ent.CallDefServerMethod(method, args, !msg.FromServer); err != nil {
-			log.Warnf("call %s method[%s] error: %s", ent.String(), method, err.Error())
+			engine.MainLogger().Warnf("call %s method[%s] error: %s", ent.String(), method, err.Error())
 			return nil
 		}
 	}
@@ -83,12 +105,62 @@ func processEntityRpc(buf []byte) error {
 	return nil
 }
 
+// replyEntityRpc 将entity rpc的返回值发给调用方所在的game
+func replyEntityRpc(target string, ex *message.ExtraInfo, rets []interface{}, err error) {
+	if target == engine.ServiceName() {
+		getCallbackMgr().Call(ex.Uuid, err, rets...)
+		return
+	}
+	rsp := &message.EntityRpcResponse{Target: target, Ex: ex}
+	if err != nil {
+		rsp.ErrMsg = err.Error()
+	} else {
+		data, err := engine.GetProtocol().Marshal(map[string]interface{}{engine.ClientMsgDataFieldArgs: rets})
+		if err != nil {
+			rsp.ErrMsg = err.Error()
+		} else {
+			rsp.Data = data
+		}
+	}
+	if err = getPeerProxy().Send(target, engine.ServerMessageTypeEntityRpcRsp, rsp); err == nil {
+		return
+	}
+	if err = getGateProxy().SendToGate(engine.GenMessageHeader(engine.ServerMessageTypeEntityRpcRsp, 0), rsp, nil); err != nil {
+		log.Warnf("reply entity rpc to %s error: %s", target, err.Error())
+	}
+}
+
+// processEntityRpcResponse entity rpc返回值
+func processEntityRpcResponse(buf []byte, _ gnet.Conn) error {
+	msg := message.EntityRpcResponse{}
+	if err := msg.Unmarshal(buf); err != nil {
+		return err
+	}
+	if msg.Ex == nil {
+		return errors.New("entity rpc response without uuid")
+	}
+	engine.SetTraceId(msg.Ex.TraceId)
+	if msg.ErrMsg != "" {
+		getCallbackMgr().Call(msg.Ex.Uuid, errors.New(msg.ErrMsg))
+		return nil
+	}
+	r, err := engine.GetProtocol().UnMarshal(msg.Data)
+	if err != nil {
+		getCallbackMgr().Call(msg.Ex.Uuid, err)
+		return nil
+	}
+	rets, _ := r[engine.ClientMsgDataFieldArgs].([]interface{})
+	getCallbackMgr().Call(msg.Ex.Uuid, nil, rets...)
+	return nil
+}
+
 // processEntityLogin entity登录
 func processEntityLogin(buf []byte, clientId engine.ConnectIdType) error {
 	msg := message.GameEntityRpc{}
 	if err := msg.Unmarshal(buf); err != nil {
 		return err
 	}
+	engine.SetTraceId(msg.TraceId)
 	r, err := engine.GetProtocol().UnMarshal(msg.Data)
 	if err != nil {
 		return err
@@ -96,7 +168,7 @@ func processEntityLogin(buf []byte, clientId engine.ConnectIdType) error {
 
 	params, ok := r[engine.ClientMsgDataFieldArgs].([]interface{})
 	if ok == false {
-		log.Errorf("entity login, invalid args data, clientId: %d", clientId)
+		engine.MainLogger().Errorf("entity login, invalid args data, clientId: %d", clientId)
 		return errors.New("invalid args data")
 	}
 
@@ -106,15 +178,56 @@ func processEntityLogin(buf []byte, clientId engine.ConnectIdType) error {
 	}
 
 	client := &engine.ClientMailBox{GateName: msg.Source, ClientId: clientId}
+	//def版本不一致的客户端可能按旧的参数类型调用rpc, 登录时直接拒绝
+	if !engine.CheckDefVersion(r) {
+		engine.MainLogger().Infof("client[%s:%d] login with def version[%v], required: %s", msg.Source, clientId, r[engine.ClientMsgDataFieldVersion], engine.DefHash())
+		client.SendServerError(engine.ErrMsgDefVersionMismatch, engine.DefHash())
+		return nil
+	}
 	args := append([]interface{}{client}, params...)
 	if err = ent.CallDefServerMethod(engine.StubEntryMethod, engine.InterfaceToLValues(args), false); err != nil {
-		log.Infof("call %s method[%s] error: %s", ent.String(), engine.StubEntryMethod, err.Error())
+		engine.MainLogger().Infof("call %s method[%s] error: %s", ent.String(), engine.StubEntryMethod, err.Error())
 		return nil
 	}
 
 	return nil
 }
 
+// processPropResync 客户端请求全量同步属性
+func processPropResync(buf []byte, clientId engine.ConnectIdType) error {
+	msg := message.GameEntityRpc{}
+	if err := msg.Unmarshal(buf); err != nil {
+		return err
+	}
+	engine.SetTraceId(msg.TraceId)
+	r, err := engine.GetProtocol().UnMarshal(msg.Data)
+	if err != nil {
+		return err
+	}
+	entityId := engine.InterfaceToInt(r[engine.ClientMsgDataFieldEntityID])
+	if getMigrateProxy().bufferOrForward(engine.EntityIdType(entityId), engine.ServerMessageTypePropResync, clientId, buf) {
+		return nil
+	}
+	ent := engine.GetEntityManager().GetEntityById(engine.EntityIdType(entityId))
+	if ent == nil {
+		engine.MainLogger().Debugf("client[%d] resync prop but entity[%d] not found", clientId, entityId)
+		return nil
+	}
+	params, ok := r[engine.ClientMsgDataFieldArgs].([]interface{})
+	if !ok || len(params) < 1 {
+		return errors.New("invalid args data")
+	}
+	propName, ok := params[0].(string)
+	if !ok {
+		return errors.New("invalid prop name")
+	}
+	client := &engine.ClientMailBox{GateName: msg.Source, ClientId: clientId}
+	if err = ent.ResyncProp(propName, client); err != nil {
+		engine.MainLogger().Warnf("resync prop error: %s", err.Error())
+	}
+	return nil
+}
+
 // processCreateEntity 创建entity
 func processCreateEntity(buf []byte, c gnet.Conn) error {
 	msg := message.CreateEntityRequest{}
@@ -162,3 +275,77 @@ func processSetServerTime(buf []byte, _ gnet.Conn) error {
 	engine.SetTimeOffset(msg.Offset)
 	return nil
 }
+
+// processMigrateEntity 根据迁移数据在本进程创建entity
+func processMigrateEntity(buf []byte, c gnet.Conn) error {
+	msg := message.MigrateEntityRequest{}
+	if err := msg.Unmarshal(buf); err != nil {
+		return err
+	}
+	rsp := message.MigrateEntityResponse{EntityId: msg.EntityId, Source: msg.Source, Target: msg.Target, Ex: msg.Ex}
+	rsp.ErrMsg = getMigrateProxy().records.request(msg.Ex.Uuid, func() error {
+		_, err := engine.GetEntityManager().CreateEntityFromMigrateData(engine.EntityIdType(msg.EntityId), msg.Data)
+		if err != nil {
+			log.Warnf("migrate entity[%d] from %s error: %s", msg.EntityId, msg.Source, err.Error())
+		}
+		return err
+	})
+	if rsp.ErrMsg == errMigrateAborted.Error() {
+		//源game已取消该迁移, 不再创建
+		log.Infof("migrate entity[%d] from %s already aborted", msg.EntityId, msg.Source)
+	}
+	return getGateProxy().SendToGate(engine.GenMessageHeader(engine.ServerMessageTypeMigrateEntityRsp, 0), &rsp, c)
+}
+
+// processMigrateEntityAbort 源game取消迁移, 回复迁移请求的最终结果: 已创建entity时为成功, 否则之后不再创建
+func processMigrateEntityAbort(buf []byte, c gnet.Conn) error {
+	msg := message.MigrateEntityAbort{}
+	if err := msg.Unmarshal(buf); err != nil {
+		return err
+	}
+	rsp := message.MigrateEntityResponse{EntityId: msg.EntityId, Source: msg.Source, Target: msg.Target, Ex: msg.Ex}
+	rsp.ErrMsg = getMigrateProxy().records.abort(msg.MigrateUuid)
+	log.Infof("abort migrate entity[%d] from %s, result: %s", msg.EntityId, msg.Source, rsp.ErrMsg)
+	return getGateProxy().SendToGate(engine.GenMessageHeader(engine.ServerMessageTypeMigrateEntityRsp, 0), &rsp, c)
+}
+
+// processMigrateEntityResponse entity迁移结果通知
+func processMigrateEntityResponse(buf []byte, _ gnet.Conn) error {
+	msg := message.MigrateEntityResponse{}
+	if err := msg.Unmarshal(buf); err != nil {
+		return err
+	}
+	var err error
+	if msg.ErrMsg != "" {
+		err = errors.New(msg.ErrMsg)
+	}
+	getCallbackMgr().Call(msg.Ex.Uuid, err, msg.EntityId)
+	return nil
+}
+
+// dispatchEntityMessage 处理迁移失败后缓存的entity消息
+func dispatchEntityMessage(msgType uint8, clientId engine.ConnectIdType, data []byte) error {
+	switch msgType {
+	case engine.ServerMessageTypeEntityRpc:
+		return processEntityRpc(data)
+	case engine.ServerMessageTypePropResync:
+		return processPropResync(data, clientId)
+	}
+	return fmt.Errorf("unknown buffered message type %d", msgType)
+}
+
+// processResumeClient 客户端断线重连, 原连接绑定的entity转移到新连接
+func processResumeClient(buf []byte, gateName string, clientId engine.ConnectIdType) error {
+	msg := message.ResumeClient{}
+	if err := msg.Unmarshal(buf); err != nil {
+		return err
+	}
+	oldClientId := engine.ConnectIdType(msg.OldClientId)
+	if err := engine.GetEntityManager().ResumeEntityConnInfo(gateName, oldClientId, clientId, msg.Resync); err != nil {
+		log.Infof("resume client[%s:%d -> %d] failed: %s", gateName, oldClientId, clientId, err.Error())
+		engine.GetEntityManager().RemoveEntityConnInfo(gateName, oldClientId)
+		mb := &engine.ClientMailBox{GateName: gateName, ClientId: clientId}
+		mb.SendServerError(engine.ErrMsgResumeFailed)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	lua "github.com/seasondi/gopher-lua"
+	"rpg/engine/engine"
+	"rpg/engine/message"
+	"time"
+)
+
+const (
+	migrateEntityTimeout  = 10 * time.Second //等待目标game创建entity的超时时间, 超时后取消迁移
+	migrateAbortTimeout   = 3 * time.Second  //等待目标game回复取消结果的超时时间, 超时后重新发送
+	migrateRecordKeepTime = 5 * time.Minute  //目标game保留迁移请求结果的时间
+)
+
+var (
+	migrateMgr        *migrateProxy
+	errMigrateAborted = errors.New("migrate aborted")
+)
+
+func getMigrateProxy() *migrateProxy {
+	if migrateMgr == nil {
+		migrateMgr = new(migrateProxy)
+		migrateMgr.init()
+	}
+	return migrateMgr
+}
+
+type migrateRecord struct {
+	errMsg string    //迁移请求的结果, 为空表示已创建entity
+	expire time.Time //记录过期时间
+}
+
+// migrateRecords 目标game处理过或已被取消的迁移请求, 迁移请求与取消请求无论到达顺序如何, 目标game只创建一次且回复取消请求的结果与实际一致
+type migrateRecords map[string]*migrateRecord
+
+// begin 开始处理迁移请求, 已被取消或处理过时返回false
+func (m migrateRecords) begin(uuid string) bool {
+	_, ok := m[uuid]
+	return !ok
+}
+
+// finish 记录迁移请求的处理结果
+func (m migrateRecords) finish(uuid string, errMsg string) {
+	if _, ok := m[uuid]; !ok {
+		m.set(uuid, errMsg)
+	}
+}
+
+// request 处理迁移请求, 未被取消且未处理过时调用create创建entity, 返回迁移请求的结果
+func (m migrateRecords) request(uuid string, create func() error) string {
+	errMsg := errMigrateAborted.Error()
+	if m.begin(uuid) {
+		errMsg = ""
+		if err := create(); err != nil {
+			errMsg = err.Error()
+		}
+	}
+	m.finish(uuid, errMsg)
+	return errMsg
+}
+
+// abort 取消迁移请求, 返回迁移请求的最终结果; 尚未收到迁移请求时记录为已取消, 之后收到时不再创建
+func (m migrateRecords) abort(uuid string) string {
+	if r, ok := m[uuid]; ok {
+		return r.errMsg
+	}
+	m.set(uuid, errMigrateAborted.Error())
+	return errMigrateAborted.Error()
+}
+
+func (m migrateRecords) set(uuid string, errMsg string) {
+	now := time.Now()
+	for k, r := range m {
+		if now.After(r.expire) {
+			delete(m, k)
+		}
+	}
+	m[uuid] = &migrateRecord{errMsg: errMsg, expire: now.Add(migrateRecordKeepTime)}
+}
+
+type migrateProxy struct {
+	records migrateRecords //作为目标game处理过的迁移请求
+}
+
+func (m *migrateProxy) init() {
+	m.records = make(migrateRecords)
+}
+
+// migrateEntity 将本进程的entity迁移到目标game
+func (m *migrateProxy) migrateEntity(entityId engine.EntityIdType, target string, luaCb lua.LValue) error {
+	if target == engine.ServiceName() {
+		return errors.New("target is current server")
+	}
+	ent := engine.GetEntityManager().GetEntityById(entityId)
+	if ent == nil {
+		return fmt.Errorf("entity[%d] not found", entityId)
+	}
+	data, err := ent.BeginMigrate()
+	if err != nil {
+		return err
+	}
+	msg := &message.MigrateEntityRequest{
+		EntityId: int64(entityId),
+		Source:   engine.ServiceName(),
+		Target:   target,
+		Data:     data,
+		Ex:       getCallbackMgr().NewExtraInfo(),
+	}
+	getCallbackMgr().setCallbackWithTimeout(msg.Ex.Uuid, &migrateEntityCallback{uuid: msg.Ex.Uuid, entityId: entityId, target: target, luaFunc: luaCb}, migrateEntityTimeout)
+	if err = getGateProxy().SendToGate(engine.GenMessageHeader(engine.ServerMessageTypeMigrateEntity, 0), msg, nil); err != nil {
+		getCallbackMgr().Call(msg.Ex.Uuid, err)
+	}
+	return nil
+}
+
+// abortMigrate 等待迁移结果超时, 通知目标game取消迁移, entity保持冻结直到目标game回复最终结果, 避免两个进程同时存在该entity
+func (m *migrateProxy) abortMigrate(entityId engine.EntityIdType, target string, migrateUuid string, luaCb lua.LValue) {
+	msg := &message.MigrateEntityAbort{
+		EntityId:    int64(entityId),
+		Source:      engine.ServiceName(),
+		Target:      target,
+		MigrateUuid: migrateUuid,
+		Ex:          getCallbackMgr().NewExtraInfo(),
+	}
+	cb := &migrateAbortCallback{migrateUuid: migrateUuid, entityId: entityId, target: target, luaFunc: luaCb}
+	getCallbackMgr().setCallbackWithTimeout(msg.Ex.Uuid, cb, migrateAbortTimeout)
+	if err := getGateProxy().SendToGate(engine.GenMessageHeader(engine.ServerMessageTypeMigrateEntityAbort, 0), msg, nil); err != nil {
+		//超时后重新发送
+		log.Warnf("abort migrate entity[%d] to %s error: %s", entityId, target, err.Error())
+	}
+}
+
+// finishMigrate 迁移得到最终结果, 处理缓存的消息并回调脚本
+func (m *migrateProxy) finishMigrate(entityId engine.EntityIdType, target string, luaCb lua.LValue, err error) {
+	if err != nil {
+		log.Errorf("migrate entity[%d] to %s error: %s", entityId, target, err.Error())
+	}
+	m.onMigrateFinished(entityId, target, err == nil)
+	if luaCb == nil || luaCb == lua.LNil {
+		return
+	}
+	args := []lua.LValue{engine.EntityIdToLua(entityId)}
+	if err != nil {
+		args = append(args, lua.LString(err.Error()))
+	}
+	_ = engine.CallLuaMethod(engine.NewLuaMethod(luaCb, "migrateEntityCallback"), 0, args...)
+}
+
+// onMigrateFinished 迁移成功时将缓存的消息转发给目标game, 失败时本地处理
+func (m *migrateProxy) onMigrateFinished(entityId engine.EntityIdType, target string, success bool) {
+	ent := engine.GetEntityManager().GetEntityById(entityId)
+	if ent == nil {
+		log.Warnf("entity[%d] migrate finished but not found", entityId)
+		return
+	}
+	for _, msg := range ent.EndMigrate(success, target) {
+		if success {
+			m.forwardMessage(target, msg.MsgType, msg.ClientId, msg.Data)
+		} else if err := dispatchEntityMessage(msg.MsgType, msg.ClientId, msg.Data); err != nil {
+			log.Warnf("entity[%d] process buffered message type %d error: %s", entityId, msg.MsgType, err.Error())
+		}
+	}
+}
+
+// forwardMessage 通过gate将消息原样转发给目标game
+func (m *migrateProxy) forwardMessage(target string, msgType uint8, clientId engine.ConnectIdType, data []byte) {
+	msg := &message.ForwardGameMessage{
+		Target:   target,
+		MsgType:  uint32(msgType),
+		ClientId: uint32(clientId),
+		Data:     data,
+	}
+	if err := getGateProxy().SendToGate(engine.GenMessageHeader(engine.ServerMessageTypeForwardMessage, 0), msg, nil); err != nil {
+		log.Warnf("forward message type %d to %s error: %s", msgType, target, err.Error())
+	}
+}
+
+// bufferOrForward 目标entity迁移中时缓存消息, 刚迁出本进程时转发给目标game. 返回消息是否已被处理
+func (m *migrateProxy) bufferOrForward(entityId engine.EntityIdType, msgType uint8, clientId engine.ConnectIdType, data []byte) bool {
+	if ent := engine.GetEntityManager().GetEntityById(entityId); ent != nil {
+		if ent.IsMigrating() {
+			ent.BufferMessage(msgType, clientId, data)
+			return true
+		}
+		return false
+	}
+	if target := engine.GetEntityManager().MigratedEntityTarget(entityId); target != "" {
+		m.forwardMessage(target, msgType, clientId, data)
+		return true
+	}
+	return false
+}
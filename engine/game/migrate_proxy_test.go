@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMigrateRecords(t *testing.T) {
+	const created, failed = "", "create failed"
+	aborted := errMigrateAborted.Error()
+	tests := []struct {
+		name       string
+		events     []string //目标game依次收到的消息: request/abort
+		createErr  string   //处理迁移请求时创建entity的结果
+		wantCreate bool     //目标game是否创建了entity
+		wantAbort  string   //回复取消请求的结果, 源game据此决定迁移是否成功
+	}{
+		{"timeout then late success", []string{"request", "abort"}, created, true, created},
+		{"timeout then late failure", []string{"request", "abort"}, failed, false, failed},
+		{"abort before request", []string{"abort", "request"}, created, false, aborted},
+		{"abort resent after late success", []string{"request", "abort", "abort"}, created, true, created},
+		{"abort resent before request", []string{"abort", "abort", "request"}, created, false, aborted},
+		{"duplicated request", []string{"request", "request", "abort"}, created, true, created},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := make(migrateRecords)
+			creates := 0
+			var abortResults []string
+			for _, ev := range tt.events {
+				switch ev {
+				case "request":
+					records.request("uuid", func() error {
+						if tt.createErr != created {
+							return errors.New(tt.createErr)
+						}
+						creates++
+						return nil
+					})
+				case "abort":
+					abortResults = append(abortResults, records.abort("uuid"))
+				}
+			}
+			if (creates > 0) != tt.wantCreate || creates > 1 {
+				t.Fatalf("entity created %d time(s), want created: %v", creates, tt.wantCreate)
+			}
+			//源game以最后一次收到的取消回复为准, 多次回复的结果必须一致
+			for i, r := range abortResults {
+				if r != tt.wantAbort {
+					t.Fatalf("abort[%d] result = %q, want %q", i, r, tt.wantAbort)
+				}
+			}
+			//源game认为迁移成功当且仅当目标game创建了entity, 不会出现两个进程同时存在entity
+			if (tt.wantAbort == created) != tt.wantCreate {
+				t.Fatalf("abort result %q inconsistent with created %v", tt.wantAbort, tt.wantCreate)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gogo/protobuf/proto"
+	clientV3 "go.etcd.io/etcd/client/v3"
+	"rpg/engine/engine"
+	"rpg/engine/message"
+	"sort"
+	"time"
+)
+
+var peerMgr *peerProxy
+
+// peerHandler 主动连接其他game的连接, 只用于发送消息, 对端的消息经由对端主动建立的连接到达
+type peerHandler struct {
+}
+
+func (m *peerHandler) Encode(data []byte) ([]byte, error) {
+	return engine.GetProtocol().Encode(data)
+}
+
+func (m *peerHandler) Decode(data []byte) (int, []byte, error) {
+	return engine.GetProtocol().Decode(data)
+}
+
+func (m *peerHandler) OnConnect(conn *engine.TcpClient) {
+	log.Infof("connected to game[%v:%s]", conn.Context(), conn.RemoteAddr())
+	getPeerProxy().sayHello(conn)
+}
+
+func (m *peerHandler) OnDisconnect(conn *engine.TcpClient) {
+	log.Infof("disconnect from game[%v:%s]", conn.Context(), conn.RemoteAddr())
+}
+
+func (m *peerHandler) OnMessage(_ *engine.TcpClient, _ []byte) error {
+	return nil
+}
+
+func getPeerProxy() *peerProxy {
+	if peerMgr == nil {
+		peerMgr = new(peerProxy)
+		peerMgr.init()
+	}
+	return peerMgr
+}
+
+type peerInfo struct {
+	conn   *engine.TcpClient
+	isStub bool //是否是stub进程
+}
+
+// peerProxy game之间的直连, 没有可用的直连时经由gate转发
+type peerProxy struct {
+	peers map[string]*peerInfo //game server name -> peer info
+}
+
+func (m *peerProxy) init() {
+	m.peers = make(map[string]*peerInfo)
+}
+
+func (m *peerProxy) HandleUpdate(key string, value engine.EtcdValue) {
+	prefix, serverId, _, err := engine.ParseEtcdServerKey(key)
+	if err != nil {
+		log.Debugf("parse game server key failed: %s, key: %s", err.Error(), key)
+		return
+	}
+	if prefix != engine.ServiceGamePrefix || serverId != engine.GetConfig().ServerId || key == engine.ServiceName() {
+		return
+	}
+	addr, ok := value[engine.EtcdValueAddr].(string)
+	if !ok {
+		log.Warn("invalid game addr, value is: ", value)
+		return
+	}
+	isStub, _ := value[engine.EtcdValueIsStub].(bool)
+	getTaskManager().Push(&AddPeerTask{name: key, addr: addr, isStub: isStub})
+}
+
+func (m *peerProxy) HandleDelete(key string) {
+	prefix, serverId, _, err := engine.ParseEtcdServerKey(key)
+	if err != nil {
+		log.Debugf("parse game server key failed: %s, key: %s", err.Error(), key)
+		return
+	}
+	if prefix != engine.ServiceGamePrefix || serverId != engine.GetConfig().ServerId {
+		return
+	}
+	getTaskManager().Push(&RemovePeerTask{name: key})
+}
+
+func (m *peerProxy) AddPeer(name string, addr string, isStub bool) {
+	if peer, ok := m.peers[name]; ok {
+		peer.isStub = isStub
+		if !peer.conn.IsDisconnected() {
+			return
+		}
+		peer.conn.Disconnect()
+	}
+	handler := &peerHandler{}
+	conn := engine.NewTcpClient(engine.WithTcpClientCodec(handler), engine.WithTcpClientHandle(handler), engine.WithTcpClientContext(name))
+	m.peers[name] = &peerInfo{conn: conn, isStub: isStub}
+	conn.Connect(addr, true)
+	log.Infof("add peer game[%s -> %s]", name, addr)
+}
+
+func (m *peerProxy) RemovePeer(name string) {
+	if peer, ok := m.peers[name]; ok {
+		peer.conn.Disconnect()
+		delete(m.peers, name)
+		log.Infof("remove peer game: %s", name)
+	}
+}
+
+func (m *peerProxy) Tick() {
+	for _, peer := range m.peers {
+		peer.conn.Tick()
+	}
+}
+
+func (m *peerProxy) Disconnect() {
+	for _, peer := range m.peers {
+		peer.conn.Disconnect()
+	}
+}
+
+// StubGames 所有stub进程的名称, 包括自己, 按名称排序
+func (m *peerProxy) StubGames() []string {
+	names := make([]string, 0, len(m.peers)+1)
+	if engine.GetConfig().ServerConfig().IsStub {
+		names = append(names, engine.ServiceName())
+	}
+	for name, peer := range m.peers {
+		if peer.isStub {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sayHello 连接到其他game后通知对端自己的服务名
+func (m *peerProxy) sayHello(conn *engine.TcpClient) {
+	msg := &message.SayHello{ServiceName: engine.ServiceName(), IsGame: true}
+	if err := m.sendProto(conn, engine.ServerMessageTypeSayHello, msg); err != nil {
+		log.Warnf("say hello to game[%v] error: %s", conn.Context(), err.Error())
+	}
+}
+
+// Send 通过直连发送消息给指定game, 直连不可用时返回错误
+func (m *peerProxy) Send(name string, ty uint8, msg proto.Message) error {
+	peer, ok := m.peers[name]
+	if !ok || !peer.conn.IsConnected() {
+		return fmt.Errorf("no direct link to game[%s]", name)
+	}
+	return m.sendProto(peer.conn, ty, msg)
+}
+
+func (m *peerProxy) sendProto(conn *engine.TcpClient, ty uint8, msg proto.Message) error {
+	if conn == nil {
+		return errors.New("peer conn nil")
+	}
+	buf, err := engine.GetProtocol().MessageWithHead(engine.GenMessageHeader(ty, 0), msg)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Send(buf)
+	return err
+}
+
+func syncPeerFromEtcd() {
+	ctx, cancel := context.WithTimeout(context.TODO(), 3*time.Second)
+	defer cancel()
+	prefix := engine.GetEtcdPrefixWithServer(engine.ServiceGamePrefix)
+	for _, kv := range engine.GetEtcd().Get(ctx, prefix, clientV3.WithPrefix()) {
+		getPeerProxy().HandleUpdate(kv.Key(), kv.Value())
+	}
+	go engine.GetEtcd().Watch(&etcdWatcher{watcherKey: prefix}, clientV3.WithPrefix())
+}
@@ -18,11 +18,15 @@ func getStubProxy() *StubProxy {
 }
 
 type StubProxy struct {
-	stubs map[string]engine.EntityIdType //name -> id
+	stubs  map[string]engine.EntityIdType //name -> id
+	owners map[engine.EntityIdType]string //id -> 所在game
+	shards map[string]*stubShards         //分片stub name -> 分片信息
 }
 
 func (m *StubProxy) init() {
 	m.stubs = make(map[string]engine.EntityIdType)
+	m.owners = make(map[engine.EntityIdType]string)
+	m.shards = make(map[string]*stubShards)
 }
 
 func (m *StubProxy) GetStubId(name string) engine.EntityIdType {
@@ -32,14 +36,40 @@ func (m *StubProxy) GetStubId(name string) engine.EntityIdType {
 	return engine.EntityIdType(0)
 }
 
-func (m *StubProxy) AddStub(name string, entityId engine.EntityIdType) {
-	m.stubs[name] = entityId
+// GetShardStubId 按key的一致性哈希选取分片stub
+func (m *StubProxy) GetShardStubId(name string, key string) engine.EntityIdType {
+	if shards, find := m.shards[name]; find {
+		return shards.locate(key)
+	}
+	return engine.EntityIdType(0)
+}
+
+func (m *StubProxy) AddStub(name string, entityId engine.EntityIdType, server string, shard int) {
+	if shard < 0 {
+		m.stubs[name] = entityId
+		m.owners[entityId] = server
+		getStubSupervisor().onStubAdded(name)
+		return
+	}
+	shards, find := m.shards[name]
+	if !find {
+		shards = newStubShards(name)
+		m.shards[name] = shards
+	}
+	shards.add(shard, entityId)
 }
 
 func (m *StubProxy) RemoveStub(entityId engine.EntityIdType) {
 	for name, stubEntityId := range m.stubs {
 		if stubEntityId == entityId {
 			delete(m.stubs, name)
+			getStubSupervisor().onStubRemoved(name, entityId, m.owners[entityId])
+			delete(m.owners, entityId)
+			return
+		}
+	}
+	for _, shards := range m.shards {
+		if shards.remove(entityId) {
 			return
 		}
 	}
@@ -59,7 +89,12 @@ func (m *StubProxy) HandleUpdate(key string, value engine.EtcdValue) {
 		log.Warn("invalid stub name, value is: ", value)
 		return
 	}
-	getTaskManager().Push(&AddStubTask{name: name, entityId: entityId})
+	server, _ := value[engine.EtcdValueServer].(string)
+	shard := -1
+	if v, ok := value[engine.EtcdValueShard]; ok {
+		shard = int(engine.InterfaceToInt(v))
+	}
+	getTaskManager().Push(&AddStubTask{name: name, entityId: entityId, server: server, shard: shard})
 }
 
 func (m *StubProxy) HandleDelete(key string) {
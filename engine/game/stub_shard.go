@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"hash/fnv"
+	"rpg/engine/engine"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	stubShardVirtualNodes = 64               //每个分片在哈希环上的虚拟节点数
+	stubShardCheckTime    = 3 * time.Second  //检查缺失分片的间隔
+	stubShardClaimTimeout = 10 * time.Second //分片缺失超过该时间后任意stub进程都可创建, 否则只由首选进程创建
+)
+
+type shardNode struct {
+	hash  uint32
+	index int
+}
+
+// stubShards 分片stub的一致性哈希环, 以分片序号而不是entity id计算哈希, 分片在其他进程重建后key的归属不变
+type stubShards struct {
+	name         string
+	ids          map[int]engine.EntityIdType //分片序号 -> entity id
+	ring         []shardNode                 //按hash排序的虚拟节点
+	missingSince map[int]time.Time           //分片首次发现缺失的时间
+}
+
+func newStubShards(name string) *stubShards {
+	return &stubShards{
+		name:         name,
+		ids:          make(map[int]engine.EntityIdType),
+		missingSince: make(map[int]time.Time),
+	}
+}
+
+func shardHash(s string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum32()
+}
+
+func (m *stubShards) add(index int, entityId engine.EntityIdType) {
+	m.ids[index] = entityId
+	delete(m.missingSince, index)
+	m.rebuild()
+	log.Infof("add stub[%s] shard[%d], entityId: %d", m.name, index, entityId)
+}
+
+func (m *stubShards) remove(entityId engine.EntityIdType) bool {
+	for index, id := range m.ids {
+		if id == entityId {
+			delete(m.ids, index)
+			m.rebuild()
+			log.Infof("remove stub[%s] shard[%d], entityId: %d", m.name, index, entityId)
+			return true
+		}
+	}
+	return false
+}
+
+func (m *stubShards) rebuild() {
+	m.ring = make([]shardNode, 0, len(m.ids)*stubShardVirtualNodes)
+	for index := range m.ids {
+		prefix := m.name + "#" + strconv.Itoa(index) + "#"
+		for i := 0; i < stubShardVirtualNodes; i++ {
+			m.ring = append(m.ring, shardNode{hash: shardHash(prefix + strconv.Itoa(i)), index: index})
+		}
+	}
+	sort.Slice(m.ring, func(i, j int) bool {
+		if m.ring[i].hash != m.ring[j].hash {
+			return m.ring[i].hash < m.ring[j].hash
+		}
+		return m.ring[i].index < m.ring[j].index
+	})
+}
+
+// locate key所属分片的entity id, 没有可用分片时返回0
+func (m *stubShards) locate(key string) engine.EntityIdType {
+	if len(m.ring) == 0 {
+		return engine.EntityIdType(0)
+	}
+	h := shardHash(key)
+	i := sort.Search(len(m.ring), func(i int) bool { return m.ring[i].hash >= h })
+	if i == len(m.ring) {
+		i = 0
+	}
+	return m.ids[m.ring[i].index]
+}
+
+// checkStubShards stub进程定时检查缺失的分片并在本进程创建
+func checkStubShards(_ ...interface{}) {
+	stubGames := getPeerProxy().StubGames()
+	if len(stubGames) == 0 {
+		return
+	}
+	now := time.Now()
+	for name, count := range engine.ShardedStubs() {
+		shards, find := getStubProxy().shards[name]
+		if !find {
+			shards = newStubShards(name)
+			getStubProxy().shards[name] = shards
+		}
+		for index := 0; index < count; index++ {
+			if _, ok := shards.ids[index]; ok {
+				continue
+			}
+			since, ok := shards.missingSince[index]
+			if !ok {
+				since = now
+				shards.missingSince[index] = now
+			}
+			//分片按序号均匀分配给stub进程, 首选进程长时间未创建时由其他进程接管
+			if stubGames[index%len(stubGames)] != engine.ServiceName() && now.Sub(since) < stubShardClaimTimeout {
+				continue
+			}
+			//首选进程只是减少竞争, 分片由创建时的etcd事务保证只被一个进程占用
+			ent, err := engine.GetEntityManager().CreateStubShard(name, index)
+			if errors.Is(err, engine.ErrEtcdKeyExist) {
+				log.Debugf("stub[%s] shard[%d] already claimed by other game", name, index)
+				continue
+			} else if err != nil {
+				log.Warnf("create stub[%s] shard[%d] failed: %s", name, index, err.Error())
+				continue
+			}
+			shards.add(index, ent.GetEntityId())
+		}
+	}
+}
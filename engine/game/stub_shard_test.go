@@ -0,0 +1,111 @@
+package main
+
+import (
+	"github.com/sirupsen/logrus"
+	"io"
+	"rpg/engine/engine"
+	"strconv"
+	"testing"
+)
+
+func newTestShards(count int) *stubShards {
+	if log == nil {
+		l := logrus.New()
+		l.SetOutput(io.Discard)
+		log = logrus.NewEntry(l)
+	}
+	m := newStubShards("TestStub")
+	for i := 0; i < count; i++ {
+		m.add(i, engine.EntityIdType(100+i))
+	}
+	return m
+}
+
+func TestStubShardsLocate(t *testing.T) {
+	tests := []struct {
+		name   string
+		shards int
+		keys   int
+	}{
+		{"no shard", 0, 10},
+		{"single shard", 1, 100},
+		{"four shards", 4, 1000},
+		{"sixteen shards", 16, 4000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestShards(tt.shards)
+			if len(m.ring) != tt.shards*stubShardVirtualNodes {
+				t.Fatalf("ring size = %d, want %d", len(m.ring), tt.shards*stubShardVirtualNodes)
+			}
+			counts := make(map[engine.EntityIdType]int)
+			for i := 0; i < tt.keys; i++ {
+				key := "key" + strconv.Itoa(i)
+				id := m.locate(key)
+				if tt.shards == 0 {
+					if id != 0 {
+						t.Fatalf("locate(%s) = %d without shard", key, id)
+					}
+					continue
+				}
+				if id != m.locate(key) {
+					t.Fatalf("locate(%s) not stable", key)
+				}
+				counts[id]++
+			}
+			//虚拟节点使key大致均匀分布, 每个分片不少于平均值的三分之一
+			for _, id := range m.ids {
+				if tt.shards > 0 && counts[id] < tt.keys/tt.shards/3 {
+					t.Fatalf("shard entity[%d] got %d of %d keys", id, counts[id], tt.keys)
+				}
+			}
+		})
+	}
+}
+
+func TestStubShardsRemap(t *testing.T) {
+	tests := []struct {
+		name    string
+		shards  int
+		removed int
+	}{
+		{"remove first", 4, 0},
+		{"remove last", 4, 3},
+		{"remove middle of many", 16, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestShards(tt.shards)
+			before := make(map[string]engine.EntityIdType)
+			for i := 0; i < 1000; i++ {
+				key := "key" + strconv.Itoa(i)
+				before[key] = m.locate(key)
+			}
+			removedId := m.ids[tt.removed]
+			if !m.remove(removedId) {
+				t.Fatalf("remove shard[%d] failed", tt.removed)
+			}
+			//只有被移除分片的key重新分配, 其他key的归属不变
+			for key, id := range before {
+				got := m.locate(key)
+				if id != removedId && got != id {
+					t.Fatalf("key %s moved from %d to %d", key, id, got)
+				}
+				if got == removedId {
+					t.Fatalf("key %s still located to removed shard", key)
+				}
+			}
+			//分片重建后key的归属与移除前相同
+			m.add(tt.removed, engine.EntityIdType(200))
+			for key, id := range before {
+				want := id
+				if id == removedId {
+					want = engine.EntityIdType(200)
+				}
+				if got := m.locate(key); got != want {
+					t.Fatalf("key %s located to %d after rebuild, want %d", key, got, want)
+				}
+			}
+		})
+	}
+}
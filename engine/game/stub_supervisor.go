@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"rpg/engine/engine"
+	"time"
+)
+
+const (
+	stubFailoverCheckTime = time.Second                                 //检查失效stub的间隔
+	stubFailoverWait      = 2 * engine.EtcdServerLeaseTTL * time.Second //stub所在game存活时等待其退出的时间, 超时视为主动销毁
+	stubFailoverLoadTime  = 5 * time.Second                             //加载stub存盘数据的超时时间
+	stubFailoverRetryTime = engine.EtcdStubLeaseTTL * time.Second       //竞选失败后重新竞选的间隔, 竞选者失效时其key在租约到期后删除
+)
+
+var stubSupervisorMgr *stubSupervisor
+
+// stubOrphan 所在game已失效, 等待重建的stub
+type stubOrphan struct {
+	entityId engine.EntityIdType //原entity id, 重建后保持不变
+	server   string              //原所在game
+	since    time.Time           //stub被移除的时间
+	electing bool                //正在竞选或重建中, 同一个stub同时只有一个竞选goroutine
+	retryAt  time.Time           //竞选失败后下次竞选的时间
+}
+
+// stubSupervisor stub进程监控stub的移除, stub所在game失效后通过etcd租约竞选一个存活的stub进程重建该stub
+type stubSupervisor struct {
+	orphans map[string]*stubOrphan //stub name -> 等待重建的stub
+}
+
+func getStubSupervisor() *stubSupervisor {
+	if stubSupervisorMgr == nil {
+		stubSupervisorMgr = new(stubSupervisor)
+		stubSupervisorMgr.init()
+	}
+	return stubSupervisorMgr
+}
+
+func (m *stubSupervisor) init() {
+	m.orphans = make(map[string]*stubOrphan)
+}
+
+func (m *stubSupervisor) onStubAdded(name string) {
+	delete(m.orphans, name)
+}
+
+func (m *stubSupervisor) onStubRemoved(name string, entityId engine.EntityIdType, server string) {
+	//非stub进程与本进程主动销毁的stub不参与重建
+	if !engine.GetConfig().ServerConfig().IsStub || server == "" || server == engine.ServiceName() {
+		return
+	}
+	m.orphans[name] = &stubOrphan{entityId: entityId, server: server, since: time.Now()}
+	log.Infof("stub[%s:%d] on game[%s] removed, wait for failover", name, entityId, server)
+}
+
+// check 定时检查等待重建的stub, 所在game已失效时发起竞选
+func (m *stubSupervisor) check(_ ...interface{}) {
+	if quit.Load() != quitStatusNone {
+		return
+	}
+	now := time.Now()
+	for name, orphan := range m.orphans {
+		if orphan.electing || now.Before(orphan.retryAt) {
+			continue
+		}
+		if getStubProxy().GetStubId(name) > 0 {
+			delete(m.orphans, name)
+			continue
+		}
+		if _, alive := getPeerProxy().peers[orphan.server]; alive {
+			if now.Sub(orphan.since) > stubFailoverWait {
+				log.Infof("stub[%s:%d] removed by alive game[%s], skip failover", name, orphan.entityId, orphan.server)
+				delete(m.orphans, name)
+			}
+			continue
+		}
+		orphan.electing = true
+		go func(name string) {
+			ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+			defer cancel()
+			release, err := engine.GetEtcd().Elect(ctx, engine.GetEtcdStubFailoverKey(name), engine.EtcdStubLeaseTTL)
+			getTaskManager().Push(&StubElectedTask{name: name, release: release, err: err})
+		}(name)
+	}
+}
+
+func (m *stubSupervisor) onElected(name string, release func(), err error) {
+	orphan, ok := m.orphans[name]
+	if err != nil || !ok || getStubProxy().GetStubId(name) > 0 {
+		//竞选失败时由其他进程重建, 重建完成前保留记录以便其失败后重新竞选
+		if err != nil {
+			log.Debugf("elect for stub[%s] failover failed: %s", name, err.Error())
+		}
+		if release != nil {
+			release()
+		}
+		if ok {
+			orphan.electing = false
+			orphan.retryAt = time.Now().Add(stubFailoverRetryTime)
+		}
+		return
+	}
+	log.Infof("elected to recreate stub[%s:%d], previous game: %s", name, orphan.entityId, orphan.server)
+	if engine.IsPersistentEntity(name) {
+		cb := &recreateStubCallback{name: name, entityId: orphan.entityId, release: release}
+		getDBProxy().queryEntity(orphan.entityId, cb, stubFailoverLoadTime)
+		return
+	}
+	m.onStubDataLoaded(name, orphan.entityId, nil, release, nil)
+}
+
+// onStubDataLoaded 重建stub, data为存盘数据, 为空时创建新的stub
+func (m *stubSupervisor) onStubDataLoaded(name string, entityId engine.EntityIdType, data map[string]interface{}, release func(), err error) {
+	//保持竞选的key直到新的stub注册完成, 释放后key在租约到期后删除
+	defer release()
+	orphan, ok := m.orphans[name]
+	if !ok {
+		return
+	}
+	orphan.electing = false
+	if err != nil {
+		log.Warnf("load stub[%s:%d] data for failover error: %s", name, entityId, err.Error())
+		return
+	}
+	if engine.GetEntityManager().GetEntityById(entityId) != nil || getStubProxy().GetStubId(name) > 0 {
+		delete(m.orphans, name)
+		return
+	}
+	if len(data) > 0 {
+		if engine.GetEntityManager().CreateEntityFromData(entityId, data) == nil {
+			log.Warnf("recreate stub[%s:%d] from data failed", name, entityId)
+			return
+		}
+	} else if _, err = engine.GetEntityManager().CreateEntityWithId(entityId, name); err != nil {
+		log.Warnf("recreate stub[%s:%d] failed: %s", name, entityId, err.Error())
+		return
+	}
+	log.Infof("stub[%s:%d] failover to game[%s]", name, entityId, engine.ServiceName())
+	getStubProxy().AddStub(name, entityId, engine.ServiceName(), -1)
+}
@@ -19,10 +19,12 @@ func (m *RemoveGateTask) HandleTask() error {
 type AddStubTask struct {
 	name     string
 	entityId engine.EntityIdType
+	server   string
+	shard    int //分片序号, -1表示不分片
 }
 
 func (m *AddStubTask) HandleTask() error {
-	getStubProxy().AddStub(m.name, m.entityId)
+	getStubProxy().AddStub(m.name, m.entityId, m.server, m.shard)
 	return nil
 }
 
@@ -35,6 +37,58 @@ func (m *RemoveStubTask) HandleTask() error {
 	return nil
 }
 
+type AddPeerTask struct {
+	name   string
+	addr   string
+	isStub bool
+}
+
+func (m *AddPeerTask) HandleTask() error {
+	getPeerProxy().AddPeer(m.name, m.addr, m.isStub)
+	return nil
+}
+
+type RemovePeerTask struct {
+	name string
+}
+
+func (m *RemovePeerTask) HandleTask() error {
+	getPeerProxy().RemovePeer(m.name)
+	return nil
+}
+
+type StubElectedTask struct {
+	name    string
+	release func() //释放竞选的key
+	err     error
+}
+
+func (m *StubElectedTask) HandleTask() error {
+	getStubSupervisor().onElected(m.name, m.release, m.err)
+	return nil
+}
+
+type LocationResolvedTask struct {
+	entityId engine.EntityIdType
+	server   string
+	err      error
+}
+
+func (m *LocationResolvedTask) HandleTask() error {
+	getLocationCache().onResolved(m.entityId, m.server, m.err)
+	return nil
+}
+
+type LocationUpdateTask struct {
+	entityId engine.EntityIdType
+	server   string
+}
+
+func (m *LocationUpdateTask) HandleTask() error {
+	getLocationCache().update(m.entityId, m.server)
+	return nil
+}
+
 type NetMessageTask struct {
 	conn gnet.Conn
 	buf  []byte
@@ -71,6 +125,18 @@ func (m *NetMessageTask) HandleTask() error {
 		err = processCreateEntityResponse(data, m.conn)
 	case engine.ServerMessageTypeSetServerTime:
 		err = processSetServerTime(data, m.conn)
+	case engine.ServerMessageTypePropResync:
+		err = processPropResync(data, clientId)
+	case engine.ServerMessageTypeMigrateEntity:
+		err = processMigrateEntity(data, m.conn)
+	case engine.ServerMessageTypeMigrateEntityRsp:
+		err = processMigrateEntityResponse(data, m.conn)
+	case engine.ServerMessageTypeMigrateEntityAbort:
+		err = processMigrateEntityAbort(data, m.conn)
+	case engine.ServerMessageTypeEntityRpcRsp:
+		err = processEntityRpcResponse(data, m.conn)
+	case engine.ServerMessageTypeResumeClient:
+		err = processResumeClient(data, gateName, clientId)
 	default:
 		err = fmt.Errorf("unknown message type %d", ty)
 	}
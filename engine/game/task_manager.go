@@ -1,6 +1,9 @@
 package main
 
-import "rpg/engine/engine/LockFree"
+import (
+	"rpg/engine/engine"
+	"rpg/engine/engine/LockFree"
+)
 
 var taskMgr *TaskManager
 
@@ -43,5 +46,7 @@ func (m *TaskManager) Tick() {
 		if err := t.HandleTask(); err != nil {
 			log.Warnf("handle task err: %v", err)
 		}
+		//trace id只在处理单个消息期间有效
+		engine.SetTraceId("")
 	}
 }
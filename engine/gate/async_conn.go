@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+const asyncConnOutChanSize = 1024 //发送队列长度, 队列满时认为客户端过慢并断开连接
+
+// asyncConn 非gnet客户端连接(websocket、kcp)的公共部分, 收发各在一个goroutine中执行, 主线程通过发送队列写入
+type asyncConn struct {
+	sync.Mutex
+	addr      net.Addr
+	ctx       interface{}
+	outChan   chan []byte
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+func newAsyncConn(addr net.Addr) asyncConn {
+	return asyncConn{
+		addr:      addr,
+		outChan:   make(chan []byte, asyncConnOutChanSize),
+		closeChan: make(chan struct{}),
+	}
+}
+
+func (m *asyncConn) RemoteAddr() net.Addr {
+	return m.addr
+}
+
+func (m *asyncConn) Context() interface{} {
+	m.Lock()
+	defer m.Unlock()
+	return m.ctx
+}
+
+func (m *asyncConn) SetContext(ctx interface{}) {
+	m.Lock()
+	defer m.Unlock()
+	m.ctx = ctx
+}
+
+func (m *asyncConn) AsyncWrite(buf []byte) error {
+	select {
+	case <-m.closeChan:
+		return errors.New("conn closed")
+	default:
+	}
+	select {
+	case m.outChan <- buf:
+		return nil
+	default:
+		log.Warnf("client[%s] send queue full, close it", m.addr)
+		_ = m.Close()
+		return errors.New("send queue full")
+	}
+}
+
+// Close 关闭连接, 已进入发送队列的消息发送完后才断开
+func (m *asyncConn) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.closeChan)
+	})
+	return nil
+}
+
+// loopWrite 发送队列中的消息, 连接关闭时发送完剩余消息后调用closeConn断开底层连接
+func (m *asyncConn) loopWrite(write func([]byte) error, closeConn func()) {
+	defer closeConn()
+	for {
+		select {
+		case buf := <-m.outChan:
+			if err := write(buf); err != nil {
+				log.Infof("write to client[%s] error: %s", m.addr, err.Error())
+				_ = m.Close()
+				return
+			}
+		case <-m.closeChan:
+			for {
+				select {
+				case buf := <-m.outChan:
+					if err := write(buf); err != nil {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
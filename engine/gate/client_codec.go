@@ -0,0 +1,159 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"github.com/panjf2000/gnet"
+	"rpg/engine/engine"
+	"sync"
+)
+
+var clientCodecMgr *clientCodec
+
+type clientSession struct {
+	crypto *engine.CryptoSession
+	reply  []byte //待发送的握手回包, 只有该消息明文发送, 发送后置空
+}
+
+// isReply 是否是握手回包, 按切片本身判断而非发送顺序, 其他消息即使先于回包发送也会加密
+func (m *clientSession) isReply(buf []byte) bool {
+	return len(m.reply) > 0 && len(buf) == len(m.reply) && &buf[0] == &m.reply[0]
+}
+
+// clientCodec 客户端连接的编解码, 配置了加密算法时连接建立后需要先完成握手, 之后的消息均加密传输
+// tcp连接的编解码与React都在连接所属的gnet事件循环中执行, websocket连接的收发各在一个goroutine中执行, 会话只需保证map并发安全
+type clientCodec struct {
+	cipher   string             //加密算法, 为空不加密
+	identity ed25519.PrivateKey //身份私钥, 签名握手回包供客户端校验
+	sessions sync.Map           //clientConn -> *clientSession
+}
+
+func getClientCodec() *clientCodec {
+	if clientCodecMgr == nil {
+		clientCodecMgr = new(clientCodec)
+		clientCodecMgr.init()
+	}
+	return clientCodecMgr
+}
+
+func (m *clientCodec) init() {
+	m.cipher = engine.GetConfig().ServerConfig().Encrypt
+	if m.enabled() {
+		//配置加载时已校验
+		m.identity, _ = engine.ParseCryptoIdentityKey(engine.GetConfig().ServerConfig().EncryptKey)
+		log.Infof("client encrypt: %s, identity public key: %x", m.cipher, m.identity.Public())
+	}
+}
+
+func (m *clientCodec) enabled() bool {
+	return m.cipher != ""
+}
+
+func (m *clientCodec) session(c clientConn) *clientSession {
+	if s, ok := m.sessions.Load(c); ok {
+		return s.(*clientSession)
+	}
+	return nil
+}
+
+// established 连接是否可以收发业务消息
+func (m *clientCodec) established(c clientConn) bool {
+	return !m.enabled() || m.session(c) != nil
+}
+
+func (m *clientCodec) remove(c clientConn) {
+	m.sessions.Delete(c)
+}
+
+// sendCrypto 发送buf使用的加密会话, 未加密或buf是握手回包时返回nil, 只能在连接的发送线程调用
+func (m *clientCodec) sendCrypto(c clientConn, buf []byte) *engine.CryptoSession {
+	s := m.session(c)
+	if s == nil {
+		return nil
+	}
+	if s.isReply(buf) {
+		s.reply = nil
+		return nil
+	}
+	return s.crypto
+}
+
+// recvCrypto 接收消息使用的加密会话, 未加密时返回nil, 只能在连接的接收线程调用
+func (m *clientCodec) recvCrypto(c clientConn) *engine.CryptoSession {
+	if s := m.session(c); s != nil {
+		return s.crypto
+	}
+	return nil
+}
+
+func (m *clientCodec) Encode(c gnet.Conn, buf []byte) ([]byte, error) {
+	data, err := engine.GetProtocol().EncodeWithSession(buf, m.sendCrypto(c, buf))
+	log.Tracef("encode %d bytes to [%s]", len(data), c.RemoteAddr())
+	return data, err
+}
+
+func (m *clientCodec) Decode(c gnet.Conn) ([]byte, error) {
+	length, data, err := engine.GetProtocol().DecodeWithSession(c.Read(), m.recvCrypto(c))
+	if err != nil {
+		//gnet忽略Decode返回的错误, 这里主动断开连接
+		log.Warnf("decode message from [%s] error: %s", c.RemoteAddr(), err.Error())
+		_ = c.Close()
+		return nil, err
+	}
+	if length > 0 {
+		c.ShiftN(length)
+		log.Tracef("decode %d bytes from [%s]", length, c.RemoteAddr())
+	}
+	return data, nil
+}
+
+// handshake 处理客户端的握手消息, 参数为[客户端期望的加密算法, 客户端公钥], 回包为[实际使用的加密算法, 服务器临时公钥, 身份私钥的签名]
+func (m *clientCodec) handshake(c clientConn, frame []byte) ([]byte, gnet.Action) {
+	rsp, err := m.doHandshake(c, frame)
+	if err != nil {
+		log.Warnf("client[%s] handshake failed: %s", c.RemoteAddr(), err.Error())
+		return genServerErrorMessage(engine.ErrMsgHandshakeFailed), gnet.Close
+	}
+	//回包由React直接写回, 发送时按切片识别为握手回包而明文发送
+	return rsp, gnet.None
+}
+
+func (m *clientCodec) doHandshake(c clientConn, frame []byte) ([]byte, error) {
+	if len(frame) == 0 || frame[0] != engine.ClientMsgTypeHandshake {
+		return nil, errors.New("message before handshake")
+	}
+	data, err := engine.GetProtocol().UnMarshal(frame[1:])
+	if err != nil {
+		return nil, err
+	}
+	args, _ := data[engine.ClientMsgDataFieldArgs].([]interface{})
+	if len(args) < 2 {
+		return nil, errors.New("invalid handshake args")
+	}
+	var peerPub []byte
+	switch v := args[1].(type) {
+	case []byte:
+		peerPub = v
+	case string:
+		peerPub = []byte(v)
+	}
+	key, err := engine.NewCryptoKey()
+	if err != nil {
+		return nil, err
+	}
+	crypto, err := engine.NewCryptoSession(m.cipher, key, peerPub, true)
+	if err != nil {
+		return nil, err
+	}
+	serverPub := key.PublicKey().Bytes()
+	rsp, err := engine.GetProtocol().Marshal(map[string]interface{}{
+		engine.ClientMsgDataFieldType: engine.ClientMsgTypeHandshake,
+		engine.ClientMsgDataFieldArgs: []interface{}{m.cipher, serverPub, engine.SignHandshake(m.identity, m.cipher, peerPub, serverPub)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.sessions.Store(c, &clientSession{crypto: crypto, reply: rsp})
+	log.Debugf("client[%s] handshake success, client cipher: %v, cipher: %s", c.RemoteAddr(), args[0], m.cipher)
+	return rsp, nil
+}
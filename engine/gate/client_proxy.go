@@ -2,7 +2,7 @@ package main
 
 import (
 	"fmt"
-	"github.com/panjf2000/gnet"
+	"net"
 	"rpg/engine/engine"
 	"strconv"
 	"time"
@@ -14,31 +14,44 @@ const (
 
 type connCtxType map[string]engine.ConnectIdType
 
+// clientConn 客户端连接, gnet的tcp连接与websocket连接都实现该接口
+type clientConn interface {
+	RemoteAddr() net.Addr
+	Context() interface{}
+	SetContext(ctx interface{})
+	AsyncWrite(buf []byte) error
+	Close() error
+}
+
 var clientConnectId engine.ConnectIdType = 0 //客户端连接ID
 var clientProxy *ClientProxy
 
 type ClientMetricsActive struct {
-	createTime     time.Time
-	lastActiveTime time.Time
-	activeTimerId  int64
-	bindEntityId   engine.EntityIdType
-	bindEntityTime time.Time
+	createTime       time.Time
+	lastActiveTime   time.Time
+	activeTimerId    int64
+	bindEntityId     engine.EntityIdType
+	bindEntityTime   time.Time
+	limitWindowStart time.Time //超限次数统计窗口的开始时间
+	limitCount       int       //统计窗口内的超限次数
+	limitTotal       int       //总超限次数
 }
 
 func (m *ClientMetricsActive) toString() string {
-	return fmt.Sprintf("createTime: %s, lastActiveTime: %s, activeTimerId:%d, bindEntityId: %d, bindEntityTime: %s",
-		m.createTime.Format(time.RFC3339), m.lastActiveTime.Format(time.RFC3339), m.activeTimerId, m.bindEntityId, m.bindEntityTime.Format(time.RFC3339))
+	return fmt.Sprintf("createTime: %s, lastActiveTime: %s, activeTimerId:%d, bindEntityId: %d, bindEntityTime: %s, limitTotal: %d",
+		m.createTime.Format(time.RFC3339), m.lastActiveTime.Format(time.RFC3339), m.activeTimerId, m.bindEntityId, m.bindEntityTime.Format(time.RFC3339), m.limitTotal)
 }
 
 type ClientMetricsRpc struct {
-	rpcName       string
-	callCount     int       //总调用次数
-	lastCallTime  time.Time //上次调用时间
-	busyCallCount int       //连续频繁调用次数
+	rpcName      string
+	callCount    int         //总调用次数
+	lastCallTime time.Time   //上次调用时间
+	limitCount   int         //超限次数
+	bucket       tokenBucket //限流令牌桶
 }
 
 func (m *ClientMetricsRpc) toString() string {
-	return fmt.Sprintf("rpcName: %s, callCount: %d, lastCallTime: %s", m.rpcName, m.callCount, m.lastCallTime.Format(time.RFC3339))
+	return fmt.Sprintf("rpcName: %s, callCount: %d, limitCount: %d, lastCallTime: %s", m.rpcName, m.callCount, m.limitCount, m.lastCallTime.Format(time.RFC3339))
 }
 
 var messageIdToName = map[uint8]string{
@@ -51,6 +64,8 @@ type ClientMetricMsg struct {
 	msgId        uint8
 	callCount    int
 	lastCallTime time.Time
+	limitCount   int         //超限次数
+	bucket       tokenBucket //限流令牌桶
 }
 
 func (m *ClientMetricMsg) toString() string {
@@ -60,7 +75,7 @@ func (m *ClientMetricMsg) toString() string {
 	} else {
 		name = strconv.FormatInt(int64(m.msgId), 10)
 	}
-	return fmt.Sprintf("msg: %s, callCount: %d, lastCallTime: %s", name, m.callCount, m.lastCallTime.Format(time.RFC3339))
+	return fmt.Sprintf("msg: %s, callCount: %d, limitCount: %d, lastCallTime: %s", name, m.callCount, m.limitCount, m.lastCallTime.Format(time.RFC3339))
 }
 
 type ClientMetrics struct {
@@ -98,16 +113,16 @@ func getClientProxy() *ClientProxy {
 }
 
 type ClientProxy struct {
-	clientMap map[engine.ConnectIdType]gnet.Conn //clientConnectId -> conn
+	clientMap map[engine.ConnectIdType]clientConn //clientConnectId -> conn
 	metricMap map[engine.ConnectIdType]*Metrics
 }
 
 func (m *ClientProxy) init() {
-	m.clientMap = make(map[engine.ConnectIdType]gnet.Conn)
+	m.clientMap = make(map[engine.ConnectIdType]clientConn)
 	m.metricMap = make(map[engine.ConnectIdType]*Metrics)
 }
 
-func (m *ClientProxy) addConn(c gnet.Conn) {
+func (m *ClientProxy) addConn(c clientConn) {
 	clientConnectId += 1
 	ctxMap := connCtxType{
 		ctxKeyConnId: clientConnectId,
@@ -131,7 +146,7 @@ func (m *ClientProxy) removeConn(clientId engine.ConnectIdType) {
 	}
 }
 
-func (m *ClientProxy) client(clientId engine.ConnectIdType) gnet.Conn {
+func (m *ClientProxy) client(clientId engine.ConnectIdType) clientConn {
 	if c, ok := m.clientMap[clientId]; ok {
 		return c
 	} else {
@@ -139,8 +154,9 @@ func (m *ClientProxy) client(clientId engine.ConnectIdType) gnet.Conn {
 	}
 }
 
-func (m *ClientProxy) updateActive(clientId engine.ConnectIdType, msgType uint8) bool {
-	busy := false
+// updateActive 更新连接的活跃时间并按消息类型限流, 返回超限后的处理方式
+func (m *ClientProxy) updateActive(clientId engine.ConnectIdType, msgType uint8) limitAction {
+	action := limitPass
 	if c, ok := m.metricMap[clientId]; ok {
 		now := time.Now()
 		c.metrics.active.lastActiveTime = now
@@ -148,21 +164,19 @@ func (m *ClientProxy) updateActive(clientId engine.ConnectIdType, msgType uint8)
 		var metricMsg *ClientMetricMsg
 		if msg, find := c.metrics.msg[msgType]; find {
 			metricMsg = msg
-			if msgType != engine.ClientMsgTypeEntityRpc && now.Sub(metricMsg.lastCallTime).Milliseconds() <= 100 {
-				busy = true
-			}
 		} else {
 			metricMsg = &ClientMetricMsg{
 				msgId: msgType,
 			}
 			c.metrics.msg[msgType] = metricMsg
 		}
+		action = getRateLimiter().checkMessage(metricMsg, c.metrics.active, now)
 
 		metricMsg.callCount += 1
 		metricMsg.lastCallTime = now
 	}
 
-	return busy
+	return action
 }
 
 func (m *ClientProxy) checkActive(clientId engine.ConnectIdType) bool {
@@ -225,30 +239,25 @@ func (m *ClientProxy) stopActiveCheck(clientId engine.ConnectIdType) {
 	}
 }
 
-func (m *ClientProxy) rpcMetrics(clientId engine.ConnectIdType, name string) int {
+// rpcMetrics 记录rpc调用并按函数限流, 返回超限后的处理方式
+func (m *ClientProxy) rpcMetrics(clientId engine.ConnectIdType, name string) limitAction {
+	action := limitPass
 	if c, ok := m.metricMap[clientId]; ok {
 		now := time.Now()
 		var rpc *ClientMetricsRpc
 		if info, find := c.metrics.rpc[name]; find {
 			rpc = info
-			//0.1秒内多次调用
-			diff := now.Sub(rpc.lastCallTime).Milliseconds()
-			if diff <= 100 {
-				rpc.busyCallCount += 1
-			} else {
-				rpc.busyCallCount = 0
-			}
 		} else {
 			rpc = &ClientMetricsRpc{
 				rpcName: name,
 			}
 			c.metrics.rpc[name] = rpc
 		}
+		action = getRateLimiter().checkMethod(rpc, c.metrics.active, now)
 		rpc.lastCallTime = now
 		rpc.callCount += 1
-		return rpc.busyCallCount
 	}
-	return 0
+	return action
 }
 
 func (m *ClientProxy) getMetricsInfo(clientId engine.ConnectIdType) string {
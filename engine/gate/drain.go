@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	clientV3 "go.etcd.io/etcd/client/v3"
+	"math/rand"
+	"rpg/engine/engine"
+	"time"
+)
+
+const defaultDrainTime = 60 //排空时等待客户端断开的默认最长时间, 单位: 秒
+
+var gateDrainer *drainer
+
+// drainer gate排空, 用于滚动重启: 从etcd注销并拒绝新的连接与登录, 通知已有客户端连接其他gate, 客户端全部断开或超时后退出
+type drainer struct {
+	addrs    map[string]string //通知客户端连接的其他gate地址, 连接方式 -> 地址
+	deadline time.Time         //超时时间, 到达后不再等待客户端断开
+	timerId  int64             //检查客户端是否全部断开的定时器
+}
+
+func getDrainer() *drainer {
+	if gateDrainer == nil {
+		gateDrainer = new(drainer)
+	}
+	return gateDrainer
+}
+
+// draining 是否正在排空或退出
+func (m *drainer) draining() bool {
+	return quit.Load() != quitStatusNone
+}
+
+// start 开始排空, 只能在主线程调用
+func (m *drainer) start() {
+	if m.draining() {
+		return
+	}
+	quit.Store(quitStatusBeginQuit)
+	drainTime := engine.GetConfig().ServerConfig().DrainTime
+	if drainTime <= 0 {
+		drainTime = defaultDrainTime
+	}
+	m.deadline = time.Now().Add(time.Duration(drainTime) * time.Second)
+	if err := engine.GetEtcd().UnregisterServer(); err != nil {
+		log.Errorf("unregister from etcd error: %s", err.Error())
+	}
+	m.clearCommand()
+	m.addrs = m.chooseGate()
+	log.Infof("gate start drain, clients: %d, reconnect to: %v, deadline: %s", len(getClientProxy().clientMap), m.addrs, m.deadline.Format(time.RFC3339))
+	//websocket关闭监听, 已升级的连接不受影响; tcp(gnet)与kcp的监听与已有连接共用, 无法单独关闭, 新连接在AddClientTask中拒绝
+	go stopWebSocketListener()
+
+	//会话凭证只在本gate有效, 保留中的会话直接过期
+	getSessionManager().expireAll()
+	for _, c := range getClientProxy().clientMap {
+		_ = sendToClient(c, m.reconnectMessage(c))
+	}
+	m.timerId = engine.GetTimer().AddTimer(time.Second, time.Second, m.checkTimerCb)
+}
+
+func (m *drainer) checkTimerCb(_ ...interface{}) {
+	clients := len(getClientProxy().clientMap)
+	if clients > 0 && time.Now().Before(m.deadline) {
+		return
+	}
+	if clients > 0 {
+		log.Warnf("gate drain timeout, %d client(s) left", clients)
+	} else {
+		log.Info("gate drain finished")
+	}
+	engine.GetTimer().Cancel(m.timerId)
+	m.timerId = 0
+	getTaskManager().Push(&ServerStopTask{quitStatus: quitStatusQuited})
+}
+
+// reject 排空期间拒绝新连接
+func (m *drainer) reject(c clientConn) {
+	log.Infof("gate draining, reject client conn[%s]", c.RemoteAddr())
+	_ = c.AsyncWrite(m.reconnectMessage(c))
+	_ = c.Close()
+}
+
+// reconnectMessage 通知客户端连接其他gate, 地址与客户端当前的连接方式一致
+func (m *drainer) reconnectMessage(c clientConn) []byte {
+	return genServerErrorMessage(engine.ErrMsgReconnectGate, m.addrs[clientTransport(c)])
+}
+
+// chooseGate 每种连接方式从etcd中随机选择一个其他的非内部通信gate对外公布的地址, 没有时不包含该连接方式
+func (m *drainer) chooseGate() map[string]string {
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+
+	candidates := make(map[string][]string)
+	for _, kv := range engine.GetEtcd().Get(ctx, engine.GetEtcdPrefixWithServer(engine.ServiceGatePrefix), clientV3.WithPrefix()) {
+		if kv.Key() == engine.ServiceName() {
+			continue
+		}
+		value := kv.Value()
+		if inner, _ := value[engine.EtcdValueInner].(bool); inner {
+			continue
+		}
+		public, _ := value[engine.EtcdValuePublic].(map[string]interface{})
+		for transport, v := range public {
+			if addr, ok := v.(string); ok && addr != "" {
+				candidates[transport] = append(candidates[transport], addr)
+			}
+		}
+	}
+	r := make(map[string]string)
+	for transport, addrs := range candidates {
+		r[transport] = addrs[rand.Intn(len(addrs))]
+	}
+	return r
+}
+
+// clientTransport 客户端的连接方式
+func clientTransport(c clientConn) string {
+	switch c.(type) {
+	case *wsConn:
+		return engine.ClientTransportWebSocket
+	case *kcpConn:
+		return engine.ClientTransportKcp
+	}
+	return engine.ClientTransportTcp
+}
+
+// watchCommand 监听admin写入的排空命令, 启动时先清除上次遗留的命令
+func (m *drainer) watchCommand() {
+	m.clearCommand()
+	go engine.GetEtcd().Watch(&drainWatcher{})
+}
+
+func (m *drainer) clearCommand() {
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	if err := engine.GetEtcd().Delete(ctx, engine.GetEtcdGateDrainKey(engine.ServiceName())); err != nil {
+		log.Warnf("clear drain command error: %s", err.Error())
+	}
+}
+
+type drainWatcher struct{}
+
+func (m *drainWatcher) Key() string {
+	return engine.GetEtcdGateDrainKey(engine.ServiceName())
+}
+
+func (m *drainWatcher) OnUpdated(kv *engine.EtcdKV) {
+	log.Info("received drain command: ", kv)
+	getTaskManager().Push(&GateDrainTask{})
+}
+
+func (m *drainWatcher) OnDelete(_ *engine.EtcdKV) {
+}
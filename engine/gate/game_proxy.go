@@ -61,6 +61,16 @@ func (m *gameHandler) OnMessage(conn *engine.TcpClient, buf []byte) error {
 			err = processEntityBindClient(conn, data)
 		case engine.ServerMessageTypeSetServerTime:
 			err = processSetServerTime(conn, data)
+		case engine.ServerMessageTypeMigrateEntity:
+			err = processMigrateEntity(conn, data)
+		case engine.ServerMessageTypeMigrateEntityRsp:
+			err = processMigrateEntityResponse(conn, data)
+		case engine.ServerMessageTypeMigrateEntityAbort:
+			err = processMigrateEntityAbort(conn, data)
+		case engine.ServerMessageTypeForwardMessage:
+			err = processForwardMessage(conn, data)
+		case engine.ServerMessageTypeEntityRpcRsp:
+			err = processEntityRpcResponse(conn, data)
 		}
 	}
 	if err != nil {
@@ -230,21 +240,23 @@ func (m *gameProxy) Disconnect() {
 }
 
 // sendRpcToGame 发送entity rpc消息到指定game
-func (m *gameProxy) sendRpcToGame(game *engine.TcpClient, msgTy uint8, clientId engine.ConnectIdType, data []byte) ([]byte, gnet.Action) {
+func (m *gameProxy) sendRpcToGame(game *engine.TcpClient, msgTy uint8, clientId engine.ConnectIdType, data []byte, traceId string) ([]byte, gnet.Action) {
+	tlog := engine.TraceLogger(traceId)
 	pb := &message.GameEntityRpc{
-		Data:   data,
-		Source: engine.ServiceName(),
+		Data:    data,
+		Source:  engine.ServiceName(),
+		TraceId: traceId,
 	}
 	svrMsgType := toServerMessageType(msgTy)
 	head := engine.GenMessageHeader(svrMsgType, clientId)
 	if buf, err := engine.GetProtocol().MessageWithHead(head, pb); err == nil {
 		if n, gErr := game.Send(buf); gErr != nil {
-			log.Warnf("send %d bytes to %s, bufLen: %d failed: %s", n, game.Context(), len(buf), gErr.Error())
+			tlog.Warnf("send %d bytes to %s, bufLen: %d failed: %s", n, game.Context(), len(buf), gErr.Error())
 		} else {
-			log.Tracef("send %d bytes to %s, bufLen: %d", n, game.Context(), len(buf))
+			tlog.Tracef("send %d bytes to %s, bufLen: %d", n, game.Context(), len(buf))
 		}
 	} else {
-		log.Warnf("send rpc to %s encode error: %s", game.Context(), err.Error())
+		tlog.Warnf("send rpc to %s encode error: %s", game.Context(), err.Error())
 	}
 
 	return nil, gnet.None
@@ -274,23 +286,42 @@ func (m *gameProxy) sendProtoToGame(gameConn *engine.TcpClient, ty uint8, msg pr
 }
 
 // ClientSendToGame 客户端消息发往game
-func (m *gameProxy) ClientSendToGame(client gnet.Conn, msgTy uint8, data []byte) ([]byte, gnet.Action) {
-	log.Tracef("received message from client %s type: %d, data %+v", client.RemoteAddr(), msgTy, data)
+func (m *gameProxy) ClientSendToGame(client clientConn, msgTy uint8, data []byte) ([]byte, gnet.Action) {
+	//每条客户端消息分配trace id, 随消息传递到game与db
+	traceId := engine.NewTraceId()
+	tlog := engine.TraceLogger(traceId)
+	tlog.Tracef("received message from client %s type: %d, data %+v", client.RemoteAddr(), msgTy, data)
 	clientId := getClientId(client)
 	if clientId == 0 {
-		log.Warn("gate send to game invalid client connection")
+		tlog.Warn("gate send to game invalid client connection")
 		return genServerErrorMessage(engine.ErrMsgClientConnectionInvalid), gnet.None
 	}
 
-	//这里只检查非rpc消息
-	if busy := getClientProxy().updateActive(clientId, msgTy); busy {
-		log.Warnf("ignore too busy message, clientId: %d, msgType: %d", clientId, msgTy)
-		return nil, gnet.None
+	//按消息类型限流, rpc消息在下面按函数限流
+	if action := getClientProxy().updateActive(clientId, msgTy); action != limitPass {
+		tlog.Warnf("client message exceeds rate limit, clientId: %d, msgType: %d, action: %s", clientId, msgTy, action)
+		return action.reply()
+	}
+
+	//排空期间不再接受登录与恢复会话, 已登录的客户端可继续通信直到主动断开
+	if getDrainer().draining() && (msgTy == engine.ClientMsgTypeLogin || msgTy == engine.ClientMsgTypeResume) {
+		return getDrainer().reconnectMessage(client), gnet.None
 	}
 
 	var gameConn *engine.TcpClient
 	switch msgTy {
+	case engine.ClientMsgTypeResume:
+		return getSessionManager().resume(clientId, data)
+	case engine.ClientMsgTypeCodec:
+		return getMsgCodec().negotiate(client, clientId, data)
 	case engine.ClientMsgTypeLogin:
+		//加载了def时由gate直接校验def版本, 否则由entry stub校验
+		if hash := engine.DefHash(); hash != "" {
+			if r, err := engine.GetProtocol().UnMarshal(data); err == nil && !engine.CheckDefVersion(r) {
+				tlog.Infof("client[%d] login with def version[%v], required: %s", clientId, r[engine.ClientMsgDataFieldVersion], hash)
+				return genServerErrorMessage(engine.ErrMsgDefVersionMismatch, hash), gnet.None
+			}
+		}
 		gameConn = m.getEntryGame()
 	case engine.ClientMsgTypeHeartBeat:
 		if entityId := m.getBindEntity(clientId); entityId == 0 {
@@ -310,29 +341,30 @@ func (m *gameProxy) ClientSendToGame(client gnet.Conn, msgTy uint8, data []byte)
 				//rpc metrics
 				if args, ok := clientData[engine.ClientMsgDataFieldArgs].([]interface{}); ok {
 					if name, ok := args[0].(string); ok {
-						if busyCount := getClientProxy().rpcMetrics(clientId, name); busyCount >= 3 {
-							return genServerErrorMessage(engine.ErrMsgTooBusy), gnet.None
+						if action := getClientProxy().rpcMetrics(clientId, name); action != limitPass {
+							tlog.Warnf("client rpc exceeds rate limit, clientId: %d, method: %s, action: %s", clientId, name, action)
+							return action.reply()
 						}
 					}
 				}
 			} else {
-				log.Warnf("unmarshal client message error: %s, msgType: %d, cleintId: %d, data: %x", err.Error(), msgTy, clientId, data)
+				tlog.Warnf("unmarshal client message error: %s, msgType: %d, cleintId: %d, data: %x", err.Error(), msgTy, clientId, data)
 				return genServerErrorMessage(engine.ErrMsgInvalidMessage), gnet.Close
 			}
 		}
 	}
 
 	if gameConn == nil || gameConn.IsDisconnected() {
-		log.Warnf("gate send to game, client id[%d] has no game connection[%v], message type: %d", clientId, gameConn, msgTy)
+		tlog.Warnf("gate send to game, client id[%d] has no game connection[%v], message type: %d", clientId, gameConn, msgTy)
 		return genServerErrorMessage(engine.ErrMsgServerNotReady), gnet.None
 	}
-	return m.sendRpcToGame(gameConn, msgTy, clientId, data)
+	return m.sendRpcToGame(gameConn, msgTy, clientId, data, traceId)
 }
 
 func (m *gameProxy) onClientClosed(clientId engine.ConnectIdType) {
 	if server, ok := m.clientToGame[clientId]; ok {
 		if conn := m.getGameServer(server.serverName); conn != nil {
-			m.sendRpcToGame(conn, engine.ClientMsgTypeClose, clientId, nil)
+			m.sendRpcToGame(conn, engine.ClientMsgTypeClose, clientId, nil, engine.NewTraceId())
 		}
 	}
 }
@@ -468,16 +500,41 @@ func (m *gameProxy) bindEntity(clientId engine.ConnectIdType, entityId engine.En
 	log.Infof("client conn[%s:%d] bind entityId[%d] from game[%v] ", engine.ServiceName(), clientId, entityId, conn.Context())
 	getClientProxy().setBindEntity(clientId, entityId)
 	getClientProxy().stopActiveCheck(clientId)
+	getSessionManager().issue(clientId)
 }
 
 // unBindEntity 连接解绑entity
 func (m *gameProxy) unBindEntity(clientId engine.ConnectIdType, entityId engine.EntityIdType) {
 	if _, find := m.clientToGame[clientId]; find {
 		delete(m.clientToGame, clientId)
+		getSessionManager().revoke(clientId)
 		log.Infof("client conn[%s:%d] unbind entityId[%d]", engine.ServiceName(), clientId, entityId)
 	}
 }
 
+// resumeClient 断线重连, 通知entity所在game将原连接绑定的entity转移到新连接
+func (m *gameProxy) resumeClient(oldClientId, clientId engine.ConnectIdType, resync bool) error {
+	info, ok := m.clientToGame[oldClientId]
+	if !ok {
+		return fmt.Errorf("client[%d] not bound", oldClientId)
+	}
+	gameConn := m.getGameServer(info.serverName)
+	if gameConn == nil || gameConn.IsDisconnected() {
+		return fmt.Errorf("game[%s] not connected", info.serverName)
+	}
+	head := engine.GenMessageHeader(engine.ServerMessageTypeResumeClient, clientId)
+	buf, err := engine.GetProtocol().MessageWithHead(head, &message.ResumeClient{OldClientId: uint32(oldClientId), Resync: resync})
+	if err != nil {
+		return err
+	}
+	if _, err = gameConn.Send(buf); err != nil {
+		return err
+	}
+	//原连接的绑定关系由gate直接删除, 新连接的绑定由game重新通知
+	delete(m.clientToGame, oldClientId)
+	return nil
+}
+
 func (m *gameProxy) getGameServer(name string) *engine.TcpClient {
 	if c, find := m.gameServers[name]; find {
 		return c.conn
@@ -485,18 +542,22 @@ func (m *gameProxy) getGameServer(name string) *engine.TcpClient {
 	return nil
 }
 
-// genServerErrorMessage 生成提示客户端的错误信息
-func genServerErrorMessage(msg string) []byte {
+// genServerErrorMessage 生成提示客户端的错误信息, args为错误信息的附加参数
+func genServerErrorMessage(msg string, args ...string) []byte {
+	tips := []interface{}{msg}
+	for _, arg := range args {
+		tips = append(tips, arg)
+	}
 	r := map[string]interface{}{
 		engine.ClientMsgDataFieldType: engine.ClientMsgTypeTips,
-		engine.ClientMsgDataFieldArgs: []interface{}{msg},
+		engine.ClientMsgDataFieldArgs: tips,
 	}
 	buf, _ := engine.GetProtocol().Marshal(r)
 	return buf
 }
 
 // getClientId 获取客户端连接的clientId
-func getClientId(c gnet.Conn) engine.ConnectIdType {
+func getClientId(c clientConn) engine.ConnectIdType {
 	if c == nil {
 		return 0
 	}
@@ -515,6 +576,8 @@ func toServerMessageType(ty uint8) uint8 {
 		return engine.ServerMessageTypeDisconnectClient
 	case engine.ClientMsgTypeHeartBeat:
 		return engine.ServerMessageTypeHeartBeat
+	case engine.ClientMsgTypePropResync:
+		return engine.ServerMessageTypePropResync
 	default:
 		return engine.ServerMessageTypeEntityRpc
 	}
@@ -72,6 +72,9 @@ func (m *eventLoop) OnInitComplete(server gnet.Server) (action gnet.Action) {
 	if err := engine.GetEtcd().RegisterServer(); err != nil {
 		log.Fatalf("register to etcd failed: %s", err.Error())
 	}
+	getDrainer().watchCommand()
+	startWebSocket()
+	startKcp()
 	go m.tick()
 	return gnet.None
 }
@@ -87,11 +90,15 @@ func (m *eventLoop) OnOpened(c gnet.Conn) (out []byte, action gnet.Action) {
 
 func (m *eventLoop) OnClosed(c gnet.Conn, err error) (action gnet.Action) {
 	log.Infof("conn[%s] closed, msg: %v", c.RemoteAddr(), err)
-	getTaskManager().Push(&RemoveClientTask{clientId: getClientId(c)})
+	getClientCodec().remove(c)
+	getTaskManager().Push(&RemoveClientTask{conn: c})
 	return gnet.None
 }
 
 func (m *eventLoop) React(frame []byte, c gnet.Conn) (out []byte, action gnet.Action) {
+	if !getClientCodec().established(c) {
+		return getClientCodec().handshake(c, frame)
+	}
 	getTaskManager().Push(&ClientMessageTask{conn: c, buf: append([]byte{}, frame...)})
 	return nil, gnet.None
 }
@@ -104,5 +111,7 @@ func (m *eventLoop) serverTick() time.Duration {
 }
 
 func (m *eventLoop) disconnectServer() {
+	stopWebSocket()
+	stopKcp()
 	getGameProxy().Disconnect()
 }
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"github.com/panjf2000/gnet"
+	"github.com/xtaci/kcp-go/v5"
+	"rpg/engine/engine"
+	"time"
+)
+
+const kcpWriteTimeout = 10 * time.Second //单条消息写超时
+
+var kcpListener *kcp.Listener
+
+// kcpConn kcp客户端连接, 流模式传输, 消息格式与tcp连接完全相同
+type kcpConn struct {
+	asyncConn
+	sess *kcp.UDPSession
+}
+
+func newKcpConn(sess *kcp.UDPSession) *kcpConn {
+	return &kcpConn{
+		asyncConn: newAsyncConn(sess.RemoteAddr()),
+		sess:      sess,
+	}
+}
+
+func (m *kcpConn) write(buf []byte) error {
+	data, err := engine.GetProtocol().EncodeWithSession(buf, getClientCodec().sendCrypto(m, buf))
+	if err != nil {
+		return err
+	}
+	_ = m.sess.SetWriteDeadline(time.Now().Add(kcpWriteTimeout))
+	_, err = m.sess.Write(data)
+	return err
+}
+
+func (m *kcpConn) closeConn() {
+	_ = m.sess.Close()
+}
+
+// readFrames 解析缓冲区中所有完整的消息, 返回已处理的长度, 返回false时需要断开连接
+func (m *kcpConn) readFrames(buf []byte) (int, bool) {
+	total := 0
+	for total < len(buf) {
+		length, data, err := engine.GetProtocol().DecodeWithSession(buf[total:], getClientCodec().recvCrypto(m))
+		if err != nil {
+			log.Warnf("decode message from kcp client[%s] error: %s", m.RemoteAddr(), err.Error())
+			return total, false
+		}
+		if length == 0 {
+			break
+		}
+		total += length
+		if !getClientCodec().established(m) {
+			out, action := getClientCodec().handshake(m, data)
+			if out != nil {
+				_ = m.AsyncWrite(out)
+			}
+			if action == gnet.Close {
+				return total, false
+			}
+			continue
+		}
+		if len(data) > 0 {
+			getTaskManager().Push(&ClientMessageTask{conn: m, buf: append([]byte{}, data...)})
+		}
+	}
+	return total, true
+}
+
+func (m *kcpConn) loopRead() {
+	var pending []byte
+	buf := make([]byte, 4096)
+	for {
+		n, err := m.sess.Read(buf)
+		if err != nil {
+			log.Infof("read from kcp client[%s] error: %s", m.RemoteAddr(), err.Error())
+			break
+		}
+		pending = append(pending, buf[:n]...)
+		shift, ok := m.readFrames(pending)
+		if !ok {
+			break
+		}
+		pending = append(pending[:0], pending[shift:]...)
+	}
+	_ = m.Close()
+	getClientCodec().remove(m)
+	getTaskManager().Push(&RemoveClientTask{conn: m})
+}
+
+func handleKcpSession(sess *kcp.UDPSession) {
+	engine.SetKcpSessionOptions(sess)
+	c := newKcpConn(sess)
+	log.Infof("kcp conn[%s] opened, conv: %d", c.RemoteAddr(), sess.GetConv())
+	getTaskManager().Push(&AddClientTask{conn: c})
+	go c.loopWrite(c.write, c.closeConn)
+	c.loopRead()
+	log.Infof("kcp conn[%s] closed, conv: %d", c.RemoteAddr(), sess.GetConv())
+}
+
+// startKcp 配置了kcp地址时在tcp之外同时监听kcp, 共用客户端管理、心跳检查与消息路由
+func startKcp() {
+	addr := engine.GetConfig().ServerConfig().Kcp
+	if addr == "" {
+		return
+	}
+	listener, err := engine.ListenKcp(addr)
+	if err != nil {
+		log.Errorf("kcp listen at %s error: %s", addr, err.Error())
+		return
+	}
+	kcpListener = listener
+	log.Infof("gate[%s] kcp listen at: %s", engine.ServiceName(), addr)
+	go func() {
+		for {
+			sess, err := listener.AcceptKCP()
+			if err != nil {
+				log.Infof("kcp listener stopped: %s", err.Error())
+				return
+			}
+			go handleKcpSession(sess)
+		}
+	}()
+}
+
+func stopKcp() {
+	if kcpListener != nil {
+		_ = kcpListener.Close()
+	}
+}
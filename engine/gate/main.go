@@ -19,11 +19,14 @@ func main() {
 	defer engine.Close()
 
 	initTaskManager()
+	getClientCodec()
+	getSessionManager()
+	getMsgCodec()
 	getGameProxy().SyncFromEtcd()
 	initSysSignalMgr()
 
 	err := gnet.Serve(&eventLoop{}, engine.ListenProtoAddr(),
-		gnet.WithCodec(&engine.GNetCodec{}),
+		gnet.WithCodec(getClientCodec()),
 		gnet.WithLogger(log.Logger),
 		gnet.WithMulticore(true),
 		gnet.WithTCPKeepAlive(3*time.Minute),
@@ -1,17 +1,22 @@
 package main
 
 import (
-	"github.com/panjf2000/gnet"
 	"rpg/engine/engine"
 	"rpg/engine/message"
 )
 
-func responseHeartBeatToClient(clientConn gnet.Conn) {
+// sendToClient gate生成的消息发往客户端, 与game下发的消息一样分配序号并缓存, 只能在主线程调用
+func sendToClient(clientConn clientConn, buf []byte) error {
+	_, data := getSessionManager().outgoing(getClientId(clientConn), buf)
+	return getMsgCodec().write(clientConn, data)
+}
+
+func responseHeartBeatToClient(clientConn clientConn) {
 	data := map[string]interface{}{
 		engine.ClientMsgDataFieldType: engine.ClientMsgTypeHeartBeat,
 	}
 	if r, err := engine.GetProtocol().Marshal(data); err == nil {
-		_ = clientConn.AsyncWrite(r)
+		_ = sendToClient(clientConn, r)
 	}
 }
 
@@ -31,9 +36,10 @@ func processGameRpc(_ *engine.TcpClient, clientId engine.ConnectIdType, buf []by
 		r, _ := engine.GetProtocol().UnMarshal(buf)
 		log.Debug("[RPC]", r)
 	}
+	clientId, buf = getSessionManager().outgoing(clientId, buf)
 	if clientConn := getClientProxy().client(clientId); clientConn != nil {
-		_ = clientConn.AsyncWrite(buf)
-	} else {
+		_ = getMsgCodec().write(clientConn, buf)
+	} else if !getSessionManager().buffered(clientId) {
 		log.Warnf("process game rpc but client not found, clientId: %d", clientId)
 	}
 
@@ -46,20 +52,24 @@ func processRouterMessage(_ *engine.TcpClient, buf []byte) error {
 	if err := msg.Unmarshal(buf); err != nil {
 		return err
 	}
+	tlog := engine.TraceLogger(msg.TraceId)
 	gameConn := getGameProxy().getGameConnByName(msg.Target)
 	if gameConn == nil {
-		log.Warnf("route message to game[%s] but conn not found", msg.Target)
+		tlog.Warnf("route message to game[%s] but conn not found", msg.Target)
 		return nil
 	}
 	pb := &message.GameEntityRpc{
 		Data:       msg.Data,
 		Source:     engine.ServiceName(),
 		FromServer: true,
+		Ex:         msg.Ex,
+		ReplyTo:    msg.Source,
+		TraceId:    msg.TraceId,
 	}
 	if err := getGameProxy().sendProtoToGame(gameConn, engine.ServerMessageTypeEntityRpc, pb); err != nil {
-		log.Warnf("processRouterMessage send to game: %s, error: %s", msg.Target, err.Error())
+		tlog.Warnf("processRouterMessage send to game: %s, error: %s", msg.Target, err.Error())
 	} else {
-		log.Tracef("processRouterMessage send to game: %s", msg.Target)
+		tlog.Tracef("processRouterMessage send to game: %s", msg.Target)
 	}
 	return nil
 }
@@ -70,7 +80,7 @@ func processDisconnectClient(_ *engine.TcpClient, clientId engine.ConnectIdType)
 	if clientConn == nil {
 		return nil
 	}
-	_ = clientConn.Close()
+	getMsgCodec().close(clientConn)
 	return nil
 }
 
@@ -113,8 +123,8 @@ func processCreateEntityResponse(_ *engine.TcpClient, buf []byte) error {
 // processLoginByOther 通知前个连接被顶号
 func processLoginByOther(_ *engine.TcpClient, clientId engine.ConnectIdType) error {
 	if clientConn := getClientProxy().client(clientId); clientConn != nil {
-		_ = clientConn.AsyncWrite(genServerErrorMessage(engine.ErrMsgLoginByOther))
-		_ = clientConn.Close()
+		_ = sendToClient(clientConn, genServerErrorMessage(engine.ErrMsgLoginByOther))
+		getMsgCodec().close(clientConn)
 	}
 	return nil
 }
@@ -126,7 +136,7 @@ func processServerError(_ *engine.TcpClient, clientId engine.ConnectIdType, buf
 		return err
 	}
 	if clientConn := getClientProxy().client(clientId); clientConn != nil {
-		_ = clientConn.AsyncWrite(genServerErrorMessage(msg.ErrMsg))
+		_ = sendToClient(clientConn, genServerErrorMessage(msg.ErrMsg, msg.Args...))
 	}
 	return nil
 }
@@ -160,3 +170,71 @@ func processSetServerTime(_ *engine.TcpClient, buf []byte) error {
 	}
 	return nil
 }
+
+// processMigrateEntity entity迁移请求转发给目标game
+func processMigrateEntity(_ *engine.TcpClient, buf []byte) error {
+	msg := message.MigrateEntityRequest{}
+	if err := msg.Unmarshal(buf); err != nil {
+		return err
+	}
+	if err := getGameProxy().sendProtoToGameByName(msg.Target, engine.ServerMessageTypeMigrateEntity, &msg); err != nil {
+		log.Warnf("migrate entity[%d] from %s to %s error: %s", msg.EntityId, msg.Source, msg.Target, err.Error())
+		rsp := message.MigrateEntityResponse{EntityId: msg.EntityId, Source: msg.Source, Target: msg.Target, ErrMsg: err.Error(), Ex: msg.Ex}
+		_ = getGameProxy().sendProtoToGameByName(msg.Source, engine.ServerMessageTypeMigrateEntityRsp, &rsp)
+	}
+	return nil
+}
+
+// processMigrateEntityAbort 取消迁移的请求转发给目标game, 转发失败时不回复, 由源game重试或在目标game失效后自行恢复
+func processMigrateEntityAbort(_ *engine.TcpClient, buf []byte) error {
+	msg := message.MigrateEntityAbort{}
+	if err := msg.Unmarshal(buf); err != nil {
+		return err
+	}
+	if err := getGameProxy().sendProtoToGameByName(msg.Target, engine.ServerMessageTypeMigrateEntityAbort, &msg); err != nil {
+		log.Warnf("abort migrate entity[%d] from %s to %s error: %s", msg.EntityId, msg.Source, msg.Target, err.Error())
+	}
+	return nil
+}
+
+// processMigrateEntityResponse entity迁移结果通知给源game
+func processMigrateEntityResponse(_ *engine.TcpClient, buf []byte) error {
+	msg := message.MigrateEntityResponse{}
+	if err := msg.Unmarshal(buf); err != nil {
+		return err
+	}
+	if err := getGameProxy().sendProtoToGameByName(msg.Source, engine.ServerMessageTypeMigrateEntityRsp, &msg); err != nil {
+		log.Warnf("migrate entity[%d] response to %s error: %s", msg.EntityId, msg.Source, err.Error())
+	}
+	return nil
+}
+
+// processEntityRpcResponse entity rpc的返回值发给调用方所在的game
+func processEntityRpcResponse(_ *engine.TcpClient, buf []byte) error {
+	msg := message.EntityRpcResponse{}
+	if err := msg.Unmarshal(buf); err != nil {
+		return err
+	}
+	if err := getGameProxy().sendProtoToGameByName(msg.Target, engine.ServerMessageTypeEntityRpcRsp, &msg); err != nil {
+		engine.TraceLogger(msg.Ex.GetTraceId()).Warnf("entity rpc response to %s error: %s", msg.Target, err.Error())
+	}
+	return nil
+}
+
+// processForwardMessage 将消息原样转发给目标game
+func processForwardMessage(_ *engine.TcpClient, buf []byte) error {
+	msg := message.ForwardGameMessage{}
+	if err := msg.Unmarshal(buf); err != nil {
+		return err
+	}
+	gameConn := getGameProxy().getGameConnByName(msg.Target)
+	if gameConn == nil {
+		log.Warnf("forward message to game[%s] but conn not found", msg.Target)
+		return nil
+	}
+	head := engine.GenMessageHeader(uint8(msg.MsgType), engine.ConnectIdType(msg.ClientId))
+	if _, err := gameConn.Send(engine.GetProtocol().ConcatHeadAndBody(head, msg.Data)); err != nil {
+		log.Warnf("forward message to game[%s] error: %s", msg.Target, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"errors"
+	"github.com/panjf2000/gnet"
+	"rpg/engine/engine"
+	"sync"
+)
+
+const typedClientQueueSize = 1024 //typed连接待转换的消息队列长度, 队列满时认为客户端过慢并断开连接
+
+var msgCodecMgr *msgCodec
+
+// typedClient 使用typed编码的连接, 发往客户端的消息在连接自己的goroutine中转换后发送, 记录客户端已创建的entity用于确定rpc函数所属的def
+type typedClient struct {
+	sync.RWMutex
+	conn      clientConn
+	entities  map[engine.EntityIdType]string //entityId -> entity名称
+	queue     chan []byte                    //待转换的msgpack字典格式消息, nil表示发送完之前的消息后断开连接
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+func newTypedClient(c clientConn) *typedClient {
+	s := &typedClient{
+		conn:      c,
+		entities:  make(map[engine.EntityIdType]string),
+		queue:     make(chan []byte, typedClientQueueSize),
+		closeChan: make(chan struct{}),
+	}
+	go s.loopWrite()
+	return s
+}
+
+func (m *typedClient) entityName(entityId engine.EntityIdType) string {
+	m.RLock()
+	defer m.RUnlock()
+	return m.entities[entityId]
+}
+
+// push 主线程将消息放入转换队列
+func (m *typedClient) push(buf []byte) error {
+	select {
+	case <-m.closeChan:
+		return errors.New("typed client stopped")
+	default:
+	}
+	select {
+	case m.queue <- buf:
+		return nil
+	default:
+		log.Warnf("client[%s] typed queue full, close it", m.conn.RemoteAddr())
+		m.stop()
+		_ = m.conn.Close()
+		return errors.New("typed queue full")
+	}
+}
+
+func (m *typedClient) stop() {
+	m.closeOnce.Do(func() {
+		close(m.closeChan)
+	})
+}
+
+func (m *typedClient) loopWrite() {
+	for {
+		select {
+		case buf := <-m.queue:
+			if buf == nil {
+				_ = m.conn.Close()
+				return
+			}
+			if err := m.write(buf); err != nil {
+				log.Warnf("write typed message to client[%s] error: %s", m.conn.RemoteAddr(), err.Error())
+			}
+		case <-m.closeChan:
+			return
+		}
+	}
+}
+
+// write 将msgpack字典格式的消息转换为typed编码后发送
+func (m *typedClient) write(buf []byte) error {
+	data, err := engine.GetProtocol().UnMarshal(buf)
+	if err != nil {
+		return err
+	}
+	msgType := uint8(engine.InterfaceToInt(data[engine.ClientMsgDataFieldType]))
+	entityId := engine.EntityIdType(engine.InterfaceToInt(data[engine.ClientMsgDataFieldEntityID]))
+	switch msgType {
+	case engine.ClientMsgTypeCreateEntity:
+		if args, _ := data[engine.ClientMsgDataFieldArgs].([]interface{}); len(args) > 0 {
+			if name, ok := args[0].(string); ok {
+				m.Lock()
+				m.entities[entityId] = name
+				m.Unlock()
+			}
+		}
+	case engine.ClientMsgTypeDestroyEntity:
+		m.Lock()
+		delete(m.entities, entityId)
+		m.Unlock()
+	}
+	out, err := engine.MarshalTyped(msgType, data, m.entityName(entityId), true)
+	if err != nil {
+		return err
+	}
+	return m.conn.AsyncWrite(out)
+}
+
+// msgCodec 客户端消息编码, 连接默认使用msgpack字典, 登录前可协商为typed编码; 与game之间始终使用msgpack字典, 由gate转换, 发往客户端的消息在各连接的goroutine中转换
+type msgCodec struct {
+	typedEnabled bool                                  //是否接受typed编码
+	typed        map[engine.ConnectIdType]*typedClient //使用typed编码的连接
+}
+
+func getMsgCodec() *msgCodec {
+	if msgCodecMgr == nil {
+		msgCodecMgr = new(msgCodec)
+		msgCodecMgr.init()
+	}
+	return msgCodecMgr
+}
+
+func (m *msgCodec) init() {
+	m.typedEnabled = engine.GetConfig().ServerConfig().Codec == engine.ClientCodecTyped
+	m.typed = make(map[engine.ConnectIdType]*typedClient)
+}
+
+// negotiate 处理客户端的编码协商, 参数为[期望的编码], 回包为[实际使用的编码], 回包按协商前的编码发送, 之后的消息使用新编码
+func (m *msgCodec) negotiate(c clientConn, clientId engine.ConnectIdType, data []byte) ([]byte, gnet.Action) {
+	r, err := engine.GetProtocol().UnMarshal(data)
+	if err != nil {
+		log.Warnf("client[%d] negotiate codec error: %s", clientId, err.Error())
+		return genServerErrorMessage(engine.ErrMsgInvalidMessage), gnet.None
+	}
+	args, _ := r[engine.ClientMsgDataFieldArgs].([]interface{})
+	codec := engine.ClientCodecMsgpack
+	_, typed := m.typed[clientId]
+	if typed {
+		codec = engine.ClientCodecTyped
+	}
+	//绑定entity后不允许切换编码, 避免丢失已创建entity的记录; 已使用typed编码时不能切回, 避免切换后的消息先于队列中的消息发出
+	if len(args) > 0 && getGameProxy().getBindEntity(clientId) == 0 && !typed {
+		if want, _ := args[0].(string); want == engine.ClientCodecMsgpack || (want == engine.ClientCodecTyped && m.typedEnabled) {
+			codec = want
+		}
+	}
+	rsp, err := engine.GetProtocol().Marshal(map[string]interface{}{
+		engine.ClientMsgDataFieldType: engine.ClientMsgTypeCodec,
+		engine.ClientMsgDataFieldArgs: []interface{}{codec},
+	})
+	if err != nil {
+		log.Warnf("marshal codec response error: %s", err.Error())
+		return nil, gnet.None
+	}
+	_ = m.write(c, rsp)
+	if codec == engine.ClientCodecTyped && !typed {
+		m.typed[clientId] = newTypedClient(c)
+	}
+	log.Debugf("client[%d] use codec: %s, request: %v", clientId, codec, args)
+	return nil, gnet.None
+}
+
+// decode 客户端消息转换为[消息类型][msgpack字典]格式
+func (m *msgCodec) decode(clientId engine.ConnectIdType, frame []byte) ([]byte, error) {
+	if len(frame) == 0 {
+		return nil, errors.New("empty message")
+	}
+	s, ok := m.typed[clientId]
+	if !ok {
+		return frame, nil
+	}
+	msgType, data, err := engine.UnMarshalTyped(frame, s.entityName, false)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := engine.GetProtocol().Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{msgType}, buf...), nil
+}
+
+// write 按连接的编码发送msgpack字典格式的消息, 只能在主线程调用; typed编码的连接只放入转换队列
+func (m *msgCodec) write(c clientConn, buf []byte) error {
+	if s, ok := m.typed[getClientId(c)]; ok {
+		return s.push(buf)
+	}
+	return c.AsyncWrite(buf)
+}
+
+// close 发送完之前写入的消息后断开连接, 只能在主线程调用
+func (m *msgCodec) close(c clientConn) {
+	if s, ok := m.typed[getClientId(c)]; ok {
+		if s.push(nil) == nil {
+			return
+		}
+	}
+	_ = c.Close()
+}
+
+// inherit 恢复会话时新连接沿用原连接已创建entity的记录
+func (m *msgCodec) inherit(oldClientId, clientId engine.ConnectIdType) {
+	old, ok := m.typed[oldClientId]
+	if !ok {
+		return
+	}
+	old.stop()
+	delete(m.typed, oldClientId)
+	if s, ok := m.typed[clientId]; ok {
+		old.RLock()
+		s.Lock()
+		for entityId, name := range old.entities {
+			s.entities[entityId] = name
+		}
+		s.Unlock()
+		old.RUnlock()
+	}
+}
+
+func (m *msgCodec) remove(clientId engine.ConnectIdType) {
+	if s, ok := m.typed[clientId]; ok {
+		s.stop()
+		delete(m.typed, clientId)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"github.com/panjf2000/gnet"
+	"rpg/engine/engine"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMsgRate         = 10 //非rpc消息默认每秒补充的令牌数
+	defaultMsgBurst        = 1  //非rpc消息默认的桶容量
+	defaultRpcRate         = 10 //rpc函数默认每秒补充的令牌数
+	defaultRpcBurst        = 3  //rpc函数默认的桶容量
+	defaultRateLimitWindow = 10 //超限次数的默认统计窗口, 单位: 秒
+	defaultRateLimitBusy   = 1  //窗口内超限次数达到该值时回复ErrMsgTooBusy, 默认第一次超限即回复
+)
+
+// limitAction 客户端消息超限后的处理方式
+type limitAction int
+
+const (
+	limitPass limitAction = iota //未超限
+	limitDrop                    //丢弃消息
+	limitBusy                    //丢弃消息并回复ErrMsgTooBusy
+	limitKick                    //回复ErrMsgTooBusy并断开连接
+)
+
+var limitActionNames = map[limitAction]string{
+	limitPass: "pass",
+	limitDrop: "drop",
+	limitBusy: "busy",
+	limitKick: "kick",
+}
+
+func (m limitAction) String() string {
+	return limitActionNames[m]
+}
+
+// reply 超限消息的回包
+func (m limitAction) reply() ([]byte, gnet.Action) {
+	switch m {
+	case limitBusy:
+		return genServerErrorMessage(engine.ErrMsgTooBusy), gnet.None
+	case limitKick:
+		return genServerErrorMessage(engine.ErrMsgTooBusy), gnet.Close
+	}
+	return nil, gnet.None
+}
+
+// tokenBucket 令牌桶, 按距上次取令牌的时间补充令牌
+type tokenBucket struct {
+	tokens float64   //当前令牌数
+	last   time.Time //上次补充令牌的时间, 为零值时桶是满的
+}
+
+// take 取一个令牌, 令牌不足时返回false
+func (m *tokenBucket) take(limit engine.RateLimit, now time.Time) bool {
+	burst := float64(limit.Burst)
+	if m.last.IsZero() {
+		m.tokens = burst
+	} else if m.tokens += now.Sub(m.last).Seconds() * limit.Rate; m.tokens > burst {
+		m.tokens = burst
+	}
+	m.last = now
+	if m.tokens < 1 {
+		return false
+	}
+	m.tokens -= 1
+	return true
+}
+
+var rateLimiterMgr *rateLimiter
+
+// rateLimiter 客户端消息的限流配置, 由config与def生成, 各连接的令牌桶与超限次数记录在ClientMetrics中
+type rateLimiter struct {
+	messages   map[uint8]engine.RateLimit  //消息类型 -> 限流
+	methods    map[string]engine.RateLimit //mask name -> 限流
+	rpcDefault engine.RateLimit            //未配置限流的rpc函数
+	window     time.Duration               //超限次数的统计窗口
+	busy       int                         //回复ErrMsgTooBusy的超限次数
+	kick       int                         //断开连接的超限次数, 小于等于0不断开(默认)
+}
+
+func getRateLimiter() *rateLimiter {
+	if rateLimiterMgr == nil {
+		rateLimiterMgr = new(rateLimiter)
+		rateLimiterMgr.init()
+	}
+	return rateLimiterMgr
+}
+
+func (m *rateLimiter) init() {
+	m.messages = make(map[uint8]engine.RateLimit)
+	m.methods = make(map[string]engine.RateLimit)
+	m.rpcDefault = engine.RateLimit{Rate: defaultRpcRate, Burst: defaultRpcBurst}
+	m.window = defaultRateLimitWindow * time.Second
+	m.busy = defaultRateLimitBusy
+
+	cfg := engine.GetConfig().ServerConfig().RateLimit
+	overrides := make(map[string]engine.RateLimit)
+	if cfg != nil {
+		m.messages = cfg.MessageLimits()
+		for name, limit := range cfg.Methods {
+			//配置文件的key不区分大小写
+			overrides[strings.ToLower(name)] = limit
+		}
+		if cfg.Window > 0 {
+			m.window = time.Duration(cfg.Window) * time.Second
+		}
+		if cfg.Busy > 0 {
+			m.busy = cfg.Busy
+		}
+		m.kick = cfg.Kick
+	}
+	for maskName, method := range engine.ExposedMethods() {
+		if limit, ok := overrides[strings.ToLower(method.Name)]; ok {
+			m.methods[maskName] = limit
+		} else if method.RateLimit != nil {
+			m.methods[maskName] = *method.RateLimit
+		}
+	}
+	log.Infof("client rate limit, messages: %v, methods: %v, window: %s, busy: %d, kick: %d", m.messages, m.methods, m.window, m.busy, m.kick)
+}
+
+// checkMessage 按消息类型取令牌, 返回超限后的处理方式
+// rpc消息未配置时不按类型限流, 只按函数限流; 未配置限流的非rpc消息超限时只丢弃, 不回复ErrMsgTooBusy
+func (m *rateLimiter) checkMessage(metric *ClientMetricMsg, active *ClientMetricsActive, now time.Time) limitAction {
+	limit, configured := m.messages[metric.msgId]
+	if !configured {
+		if metric.msgId == engine.ClientMsgTypeEntityRpc {
+			return limitPass
+		}
+		limit = engine.RateLimit{Rate: defaultMsgRate, Burst: defaultMsgBurst}
+	}
+	if metric.bucket.take(limit, now) {
+		return limitPass
+	}
+	metric.limitCount += 1
+	action := m.penalize(active, now)
+	if !configured && action == limitBusy {
+		action = limitDrop
+	}
+	return action
+}
+
+// checkMethod 按rpc函数取令牌, 返回超限后的处理方式
+func (m *rateLimiter) checkMethod(metric *ClientMetricsRpc, active *ClientMetricsActive, now time.Time) limitAction {
+	limit, ok := m.methods[metric.rpcName]
+	if !ok {
+		limit = m.rpcDefault
+	}
+	if metric.bucket.take(limit, now) {
+		return limitPass
+	}
+	metric.limitCount += 1
+	return m.penalize(active, now)
+}
+
+// penalize 记录一次超限, 按统计窗口内的超限次数返回处理方式
+func (m *rateLimiter) penalize(active *ClientMetricsActive, now time.Time) limitAction {
+	if now.Sub(active.limitWindowStart) > m.window {
+		active.limitWindowStart = now
+		active.limitCount = 0
+	}
+	active.limitCount += 1
+	active.limitTotal += 1
+	if m.kick > 0 && active.limitCount >= m.kick {
+		return limitKick
+	}
+	if active.limitCount >= m.busy {
+		return limitBusy
+	}
+	return limitDrop
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"rpg/engine/engine"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketTake(t *testing.T) {
+	start := time.Unix(1000, 0)
+	limit := engine.RateLimit{Rate: 10, Burst: 3}
+	tests := []struct {
+		name  string
+		takes []time.Duration //每次取令牌相对start的时间
+		want  []bool
+	}{
+		{"burst then empty", []time.Duration{0, 0, 0, 0}, []bool{true, true, true, false}},
+		{"refill one token", []time.Duration{0, 0, 0, 0, 100 * time.Millisecond, 100 * time.Millisecond}, []bool{true, true, true, false, true, false}},
+		{"steady at rate", []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}, []bool{true, true, true, true}},
+		{"refill capped by burst", []time.Duration{0, 10 * time.Second, 10 * time.Second, 10 * time.Second, 10 * time.Second}, []bool{true, true, true, true, false}},
+		{"fast caller throttled to rate", []time.Duration{0, 50 * time.Millisecond, 100 * time.Millisecond, 150 * time.Millisecond, 200 * time.Millisecond, 250 * time.Millisecond, 300 * time.Millisecond}, []bool{true, true, true, true, true, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tokenBucket{}
+			for i, d := range tt.takes {
+				if got := b.take(limit, start.Add(d)); got != tt.want[i] {
+					t.Fatalf("take[%d] at %s = %v, want %v", i, d, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterPenalize(t *testing.T) {
+	start := time.Unix(1000, 0)
+	tests := []struct {
+		name  string
+		busy  int
+		kick  int
+		times []time.Duration
+		want  []limitAction
+	}{
+		{"default busy at once, never kick", 1, 0, []time.Duration{0, 0, 0, 0}, []limitAction{limitBusy, limitBusy, limitBusy, limitBusy}},
+		{"drop before busy", 3, 0, []time.Duration{0, 0, 0, 0}, []limitAction{limitDrop, limitDrop, limitBusy, limitBusy}},
+		{"kick when configured", 2, 3, []time.Duration{0, 0, 0, 0}, []limitAction{limitDrop, limitBusy, limitKick, limitKick}},
+		{"window resets count", 2, 3, []time.Duration{0, 0, 11 * time.Second, 11 * time.Second}, []limitAction{limitDrop, limitBusy, limitDrop, limitBusy}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &rateLimiter{window: 10 * time.Second, busy: tt.busy, kick: tt.kick}
+			active := &ClientMetricsActive{}
+			for i, d := range tt.times {
+				if got := m.penalize(active, start.Add(d)); got != tt.want[i] {
+					t.Fatalf("penalize[%d] = %s, want %s", i, got, tt.want[i])
+				}
+			}
+			if active.limitTotal != len(tt.times) {
+				t.Fatalf("limitTotal = %d, want %d", active.limitTotal, len(tt.times))
+			}
+		})
+	}
+}
+
+func TestRateLimiterCheckMessage(t *testing.T) {
+	start := time.Unix(1000, 0)
+	m := &rateLimiter{
+		messages: map[uint8]engine.RateLimit{engine.ClientMsgTypeLogin: {Rate: 1, Burst: 1}},
+		window:   10 * time.Second,
+		busy:     defaultRateLimitBusy,
+	}
+	tests := []struct {
+		name    string
+		msgType uint8
+		want    []limitAction
+	}{
+		{"rpc not limited by type", engine.ClientMsgTypeEntityRpc, []limitAction{limitPass, limitPass, limitPass}},
+		{"configured type replies busy", engine.ClientMsgTypeLogin, []limitAction{limitPass, limitBusy, limitBusy}},
+		{"default type only drops", engine.ClientMsgTypeHeartBeat, []limitAction{limitPass, limitDrop, limitDrop}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metric := &ClientMetricMsg{msgId: tt.msgType}
+			active := &ClientMetricsActive{}
+			for i, want := range tt.want {
+				if got := m.checkMessage(metric, active, start); got != want {
+					t.Fatalf("check[%d] = %s, want %s", i, got, want)
+				}
+			}
+		})
+	}
+}
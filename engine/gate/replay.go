@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"rpg/engine/engine"
+)
+
+const (
+	replayMaxMessages = 1024    //重发缓存的最大消息数
+	replayMaxBytes    = 1 << 20 //重发缓存的最大字节数
+)
+
+// replayBuffer 发往客户端的消息序号与重发缓存, 只保留最近的消息, 超出上限时丢弃最早的消息
+type replayBuffer struct {
+	seq      uint64   //最后分配的序号
+	first    uint64   //messages[0]的序号
+	messages [][]byte //已编号的消息, 序号从first开始连续
+	size     int      //缓存消息的总字节数
+}
+
+// push 为消息分配序号并缓存, 返回带序号的消息
+func (m *replayBuffer) push(buf []byte) ([]byte, error) {
+	data, err := appendMessageSeq(buf, m.seq+1)
+	if err != nil {
+		return nil, err
+	}
+	m.seq++
+	if len(m.messages) == 0 {
+		m.first = m.seq
+	}
+	m.messages = append(m.messages, data)
+	m.size += len(data)
+	for len(m.messages) > 0 && (len(m.messages) > replayMaxMessages || m.size > replayMaxBytes) {
+		m.size -= len(m.messages[0])
+		m.messages[0] = nil
+		m.messages = m.messages[1:]
+		m.first++
+	}
+	return data, nil
+}
+
+// since 序号ack之后的所有消息, 其中有消息已被丢弃时返回false
+func (m *replayBuffer) since(ack uint64) ([][]byte, bool) {
+	if ack >= m.seq {
+		return nil, true
+	}
+	if len(m.messages) == 0 || ack+1 < m.first {
+		return nil, false
+	}
+	return m.messages[ack+1-m.first:], true
+}
+
+// appendMessageSeq 在msgpack编码的消息字典末尾追加序号字段, 只修改字典头的元素个数, 避免重新编码整条消息
+func appendMessageSeq(buf []byte, seq uint64) ([]byte, error) {
+	if len(buf) == 0 {
+		return nil, errors.New("empty message")
+	}
+	var head, body []byte
+	switch b := buf[0]; {
+	case b >= 0x80 && b < 0x8f: //fixmap
+		head, body = []byte{b + 1}, buf[1:]
+	case b == 0x8f:
+		head, body = []byte{0xde, 0x00, 0x10}, buf[1:]
+	case b == 0xde && len(buf) >= 3: //map16
+		n := binary.BigEndian.Uint16(buf[1:3])
+		if n == 0xffff {
+			head = []byte{0xdf, 0, 0, 0, 0}
+			binary.BigEndian.PutUint32(head[1:], uint32(n)+1)
+		} else {
+			head = []byte{0xde, 0, 0}
+			binary.BigEndian.PutUint16(head[1:], n+1)
+		}
+		body = buf[3:]
+	case b == 0xdf && len(buf) >= 5: //map32
+		head = []byte{0xdf, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(head[1:], binary.BigEndian.Uint32(buf[1:5])+1)
+		body = buf[5:]
+	default:
+		return nil, errors.New("message is not a map")
+	}
+	field, err := engine.GetProtocol().Marshal(engine.ClientMsgDataFieldSeq)
+	if err != nil {
+		return nil, err
+	}
+	value, err := engine.GetProtocol().Marshal(seq)
+	if err != nil {
+		return nil, err
+	}
+	r := make([]byte, 0, len(head)+len(body)+len(field)+len(value))
+	r = append(r, head...)
+	r = append(r, body...)
+	r = append(r, field...)
+	return append(r, value...), nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"rpg/engine/engine"
+	"testing"
+)
+
+func TestAppendMessageSeq(t *testing.T) {
+	marshal := func(n int) []byte {
+		data := map[string]interface{}{}
+		for i := 0; i < n; i++ {
+			data[string(rune('a'+i%26))+string(rune('a'+i/26))] = i
+		}
+		buf, _ := engine.GetProtocol().Marshal(data)
+		return buf
+	}
+	tests := []struct {
+		name    string
+		buf     []byte
+		fields  int
+		wantErr bool
+	}{
+		{"empty fixmap", marshal(0), 0, false},
+		{"fixmap", marshal(3), 3, false},
+		{"full fixmap", marshal(15), 15, false},
+		{"map16", marshal(20), 20, false},
+		{"empty message", nil, 0, true},
+		{"not a map", []byte{0x91, 0x01}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := appendMessageSeq(tt.buf, 7)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("appendMessageSeq error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			r, err := engine.GetProtocol().UnMarshal(data)
+			if err != nil {
+				t.Fatalf("unmarshal error: %s", err.Error())
+			}
+			if len(r) != tt.fields+1 {
+				t.Fatalf("fields = %d, want %d", len(r), tt.fields+1)
+			}
+			if seq := engine.InterfaceToInt(r[engine.ClientMsgDataFieldSeq]); seq != 7 {
+				t.Fatalf("seq = %d, want 7", seq)
+			}
+		})
+	}
+}
+
+func TestReplayBuffer(t *testing.T) {
+	msg, _ := engine.GetProtocol().Marshal(map[string]interface{}{engine.ClientMsgDataFieldType: engine.ClientMsgTypeEntityRpc})
+	repeat := func(buf []byte, n int) [][]byte {
+		r := make([][]byte, n)
+		for i := range r {
+			r[i] = buf
+		}
+		return r
+	}
+	big, _ := engine.GetProtocol().Marshal(map[string]interface{}{engine.ClientMsgDataFieldArgs: string(bytes.Repeat([]byte{'x'}, replayMaxBytes/4))})
+	tests := []struct {
+		name     string
+		messages [][]byte
+		ack      uint64
+		want     []uint64 //补发消息的序号
+		ok       bool
+	}{
+		{"nothing pushed", nil, 0, nil, true},
+		{"all acked", repeat(msg, 2), 2, nil, true},
+		{"ack ahead", [][]byte{msg}, 5, nil, true},
+		{"replay from zero", repeat(msg, 3), 0, []uint64{1, 2, 3}, true},
+		{"replay tail", repeat(msg, 3), 1, []uint64{2, 3}, true},
+		{"count overflow", repeat(msg, replayMaxMessages+2), 1, nil, false},
+		{"size overflow drops oldest", repeat(big, 5), 1, nil, false},
+		{"size overflow keeps recent", repeat(big, 5), 3, []uint64{4, 5}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := new(replayBuffer)
+			for _, m := range tt.messages {
+				if _, err := b.push(m); err != nil {
+					t.Fatalf("push error: %s", err.Error())
+				}
+			}
+			if b.seq != uint64(len(tt.messages)) {
+				t.Fatalf("seq = %d, want %d", b.seq, len(tt.messages))
+			}
+			if len(b.messages) > replayMaxMessages || b.size > replayMaxBytes {
+				t.Fatalf("buffer exceeds limit, messages: %d, size: %d", len(b.messages), b.size)
+			}
+			replay, ok := b.since(tt.ack)
+			if ok != tt.ok {
+				t.Fatalf("since(%d) ok = %v, want %v", tt.ack, ok, tt.ok)
+			}
+			if len(replay) != len(tt.want) {
+				t.Fatalf("since(%d) = %d message(s), want %d", tt.ack, len(replay), len(tt.want))
+			}
+			for i, data := range replay {
+				r, _ := engine.GetProtocol().UnMarshal(data)
+				if seq := uint64(engine.InterfaceToInt(r[engine.ClientMsgDataFieldSeq])); seq != tt.want[i] {
+					t.Fatalf("replay[%d] seq = %d, want %d", i, seq, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/panjf2000/gnet"
+	"rpg/engine/engine"
+	"time"
+)
+
+const sessionTokenLen = 16 //会话凭证随机字节数
+
+var sessionMgr *sessionManager
+
+type suspendedSession struct {
+	clientId engine.ConnectIdType //已断开的连接
+	expire   time.Time            //会话过期时间
+	timerId  int64                //代替客户端发送心跳的定时器
+}
+
+// sessionManager 断线重连的会话凭证, 连接绑定entity时下发凭证, 连接断开后在有效期内可凭凭证将新连接绑定到原entity而无需重新登录
+type sessionManager struct {
+	resumeTime time.Duration                                 //断线后会话的保留时间, 为0不开启
+	tokens     map[engine.ConnectIdType]string               //clientId -> 当前有效的凭证
+	suspended  map[string]*suspendedSession                  //凭证 -> 等待恢复的会话
+	buffers    map[engine.ConnectIdType]*replayBuffer        //clientId -> 发往客户端的消息序号与重发缓存
+	aliases    map[engine.ConnectIdType]engine.ConnectIdType //恢复中的原clientId -> 新clientId, game重新绑定前发往原连接的消息转发到新连接
+	resuming   map[engine.ConnectIdType]engine.ConnectIdType //新clientId -> 恢复中的原clientId
+}
+
+func getSessionManager() *sessionManager {
+	if sessionMgr == nil {
+		sessionMgr = new(sessionManager)
+		sessionMgr.init()
+	}
+	return sessionMgr
+}
+
+func (m *sessionManager) init() {
+	m.resumeTime = time.Duration(engine.GetConfig().ServerConfig().ResumeTime) * time.Second
+	m.tokens = make(map[engine.ConnectIdType]string)
+	m.suspended = make(map[string]*suspendedSession)
+	m.buffers = make(map[engine.ConnectIdType]*replayBuffer)
+	m.aliases = make(map[engine.ConnectIdType]engine.ConnectIdType)
+	m.resuming = make(map[engine.ConnectIdType]engine.ConnectIdType)
+}
+
+func (m *sessionManager) enabled() bool {
+	return m.resumeTime > 0
+}
+
+// issue 连接绑定entity后下发新的凭证, 旧凭证作废, 恢复的会话沿用原连接的消息序号与重发缓存
+func (m *sessionManager) issue(clientId engine.ConnectIdType) {
+	if !m.enabled() {
+		return
+	}
+	if oldClientId, ok := m.resuming[clientId]; ok {
+		if b, find := m.buffers[oldClientId]; find {
+			m.buffers[clientId] = b
+			delete(m.buffers, oldClientId)
+		}
+		delete(m.aliases, oldClientId)
+		delete(m.resuming, clientId)
+	}
+	if _, ok := m.buffers[clientId]; !ok {
+		m.buffers[clientId] = new(replayBuffer)
+	}
+	conn := getClientProxy().client(clientId)
+	if conn == nil {
+		return
+	}
+	buf := make([]byte, sessionTokenLen)
+	if _, err := rand.Read(buf); err != nil {
+		log.Warnf("generate session token error: %s", err.Error())
+		return
+	}
+	token := hex.EncodeToString(buf)
+	data, err := engine.GetProtocol().Marshal(map[string]interface{}{
+		engine.ClientMsgDataFieldType: engine.ClientMsgTypeSessionToken,
+		engine.ClientMsgDataFieldArgs: []interface{}{token, int(m.resumeTime.Seconds())},
+	})
+	if err != nil {
+		log.Warnf("marshal session token error: %s", err.Error())
+		return
+	}
+	m.tokens[clientId] = token
+	_ = getMsgCodec().write(conn, data)
+}
+
+// revoke 连接与entity解绑时凭证作废
+func (m *sessionManager) revoke(clientId engine.ConnectIdType) {
+	delete(m.tokens, clientId)
+}
+
+// suspend 连接断开时保留会话, 返回false表示没有可恢复的会话
+func (m *sessionManager) suspend(clientId engine.ConnectIdType) bool {
+	token, ok := m.tokens[clientId]
+	if !ok {
+		return false
+	}
+	delete(m.tokens, clientId)
+	//排空期间断开的客户端会连接其他gate, 不再保留会话
+	if getGameProxy().getBindEntity(clientId) == 0 || getDrainer().draining() {
+		return false
+	}
+	heartbeatDuration := engine.GetConfig().HeartBeatInterval
+	if heartbeatDuration <= 0 {
+		heartbeatDuration = engine.HeartbeatTick
+	}
+	interval := time.Duration(heartbeatDuration) * time.Second
+	m.suspended[token] = &suspendedSession{
+		clientId: clientId,
+		expire:   time.Now().Add(m.resumeTime),
+		timerId:  engine.GetTimer().AddTimer(interval, interval, m.keepAliveTimerCb, token),
+	}
+	log.Infof("client[%d] session suspended for %s", clientId, m.resumeTime)
+	return true
+}
+
+// keepAliveTimerCb 会话保留期间代替客户端向game发送心跳, 避免entity因心跳超时与连接解绑
+func (m *sessionManager) keepAliveTimerCb(params ...interface{}) {
+	token := params[0].(string)
+	s, ok := m.suspended[token]
+	if !ok {
+		return
+	}
+	if time.Now().After(s.expire) {
+		m.expire(token)
+		return
+	}
+	if conn := getGameProxy().getGameConn(s.clientId); conn != nil {
+		_ = getGameProxy().sendHeartbeat(conn, s.clientId)
+	}
+}
+
+// expire 会话过期, 通知game客户端已断开
+func (m *sessionManager) expire(token string) {
+	s, ok := m.suspended[token]
+	if !ok {
+		return
+	}
+	m.remove(token)
+	log.Infof("client[%d] session expired", s.clientId)
+	m.release(s.clientId)
+	getMsgCodec().remove(s.clientId)
+	getGameProxy().onClientClosed(s.clientId)
+}
+
+// expireAll 所有保留中的会话立即过期
+func (m *sessionManager) expireAll() {
+	for token := range m.suspended {
+		m.expire(token)
+	}
+}
+
+func (m *sessionManager) remove(token string) {
+	if s, ok := m.suspended[token]; ok {
+		engine.GetTimer().Cancel(s.timerId)
+		delete(m.suspended, token)
+	}
+}
+
+// release 连接不再恢复时释放重发缓存
+func (m *sessionManager) release(clientId engine.ConnectIdType) {
+	delete(m.buffers, clientId)
+	if newClientId, ok := m.aliases[clientId]; ok {
+		delete(m.aliases, clientId)
+		delete(m.resuming, newClientId)
+	}
+	if oldClientId, ok := m.resuming[clientId]; ok {
+		delete(m.buffers, oldClientId)
+		delete(m.aliases, oldClientId)
+		delete(m.resuming, clientId)
+	}
+}
+
+// outgoing 为发往客户端的消息分配序号并缓存, 返回实际的目标连接与带序号的消息; 会话保留期间的消息只缓存, 恢复时重发
+func (m *sessionManager) outgoing(clientId engine.ConnectIdType, buf []byte) (engine.ConnectIdType, []byte) {
+	b, ok := m.buffers[clientId]
+	if !ok {
+		return clientId, buf
+	}
+	data, err := b.push(buf)
+	if err != nil {
+		log.Warnf("client[%d] sequence message error: %s", clientId, err.Error())
+		return clientId, buf
+	}
+	if newClientId, find := m.aliases[clientId]; find {
+		return newClientId, data
+	}
+	return clientId, data
+}
+
+// buffered 消息是否已缓存等待会话恢复
+func (m *sessionManager) buffered(clientId engine.ConnectIdType) bool {
+	_, ok := m.buffers[clientId]
+	return ok
+}
+
+// resume 处理客户端的恢复会话请求, 参数为[会话凭证, 已收到的最大消息序号], 成功后game重新绑定entity时下发新凭证, 失败时客户端需重新登录
+func (m *sessionManager) resume(clientId engine.ConnectIdType, data []byte) ([]byte, gnet.Action) {
+	if err := m.doResume(clientId, data); err != nil {
+		log.Infof("client[%d] resume session failed: %s", clientId, err.Error())
+		return genServerErrorMessage(engine.ErrMsgResumeFailed), gnet.None
+	}
+	return nil, gnet.None
+}
+
+func (m *sessionManager) doResume(clientId engine.ConnectIdType, data []byte) error {
+	if !m.enabled() {
+		return errors.New("session resume disabled")
+	}
+	if getGameProxy().getBindEntity(clientId) != 0 {
+		return errors.New("client already bound")
+	}
+	r, err := engine.GetProtocol().UnMarshal(data)
+	if err != nil {
+		return err
+	}
+	args, _ := r[engine.ClientMsgDataFieldArgs].([]interface{})
+	if len(args) < 1 {
+		return errors.New("invalid resume args")
+	}
+	token, _ := args[0].(string)
+	s, ok := m.suspended[token]
+	if !ok {
+		return errors.New("invalid session token")
+	}
+	conn := getClientProxy().client(clientId)
+	if conn == nil {
+		return errors.New("client conn not found")
+	}
+	m.remove(token)
+	//没有上报序号或缓存已溢出时无法补发, 由game全量同步客户端entity
+	var replay [][]byte
+	var lastSeq uint64
+	resync := true
+	if b, find := m.buffers[s.clientId]; find {
+		lastSeq = b.seq
+		if len(args) > 1 {
+			replay, ok = b.since(uint64(engine.InterfaceToInt(args[1])))
+			resync = !ok
+		}
+	}
+	if err = getGameProxy().resumeClient(s.clientId, clientId, resync); err != nil {
+		m.release(s.clientId)
+		getMsgCodec().remove(s.clientId)
+		getGameProxy().onClientClosed(s.clientId)
+		return err
+	}
+	getMsgCodec().inherit(s.clientId, clientId)
+	m.aliases[s.clientId] = clientId
+	m.resuming[clientId] = s.clientId
+	rsp, err := engine.GetProtocol().Marshal(map[string]interface{}{
+		engine.ClientMsgDataFieldType: engine.ClientMsgTypeResume,
+		engine.ClientMsgDataFieldArgs: []interface{}{resync, lastSeq},
+	})
+	if err != nil {
+		return err
+	}
+	_ = getMsgCodec().write(conn, rsp)
+	for _, buf := range replay {
+		_ = getMsgCodec().write(conn, buf)
+	}
+	log.Infof("client[%d] resume session of client[%d], replay: %d, resync: %v", clientId, s.clientId, len(replay), resync)
+	return nil
+}
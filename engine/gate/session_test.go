@@ -0,0 +1,129 @@
+package main
+
+import (
+	"reflect"
+	"rpg/engine/engine"
+	"testing"
+	"time"
+)
+
+// newTestSessionManager 不依赖配置的会话管理, oldId恢复到newId中, oldId的缓存已分配seq个序号
+func newTestSessionManager(oldId, newId engine.ConnectIdType, seq uint64) *sessionManager {
+	m := &sessionManager{resumeTime: time.Minute}
+	m.tokens = make(map[engine.ConnectIdType]string)
+	m.suspended = make(map[string]*suspendedSession)
+	m.buffers = make(map[engine.ConnectIdType]*replayBuffer)
+	m.aliases = make(map[engine.ConnectIdType]engine.ConnectIdType)
+	m.resuming = make(map[engine.ConnectIdType]engine.ConnectIdType)
+	if oldId != 0 {
+		m.buffers[oldId] = &replayBuffer{seq: seq}
+	}
+	if oldId != 0 && newId != 0 {
+		m.aliases[oldId] = newId
+		m.resuming[newId] = oldId
+	}
+	return m
+}
+
+func TestSessionIssue(t *testing.T) {
+	tests := []struct {
+		name       string
+		resumeTime time.Duration
+		oldId      engine.ConnectIdType //恢复中的原连接, 0表示新登录
+		clientId   engine.ConnectIdType
+		wantSeq    uint64 //clientId缓存的序号
+		wantBuffer bool   //clientId是否有缓存
+	}{
+		{"new login", time.Minute, 0, 2, 0, true},
+		{"resumed inherits buffer", time.Minute, 1, 2, 5, true},
+		{"resume disabled", 0, 0, 2, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestSessionManager(tt.oldId, tt.clientId, 5)
+			m.resumeTime = tt.resumeTime
+			m.issue(tt.clientId)
+			b, ok := m.buffers[tt.clientId]
+			if ok != tt.wantBuffer {
+				t.Fatalf("buffer exists = %v, want %v", ok, tt.wantBuffer)
+			}
+			if ok && b.seq != tt.wantSeq {
+				t.Fatalf("buffer seq = %d, want %d", b.seq, tt.wantSeq)
+			}
+			if _, ok = m.buffers[tt.oldId]; ok && tt.oldId != 0 {
+				t.Fatalf("buffer of old client[%d] not moved", tt.oldId)
+			}
+			if len(m.aliases) != 0 || len(m.resuming) != 0 {
+				t.Fatalf("resume state left: aliases %v, resuming %v", m.aliases, m.resuming)
+			}
+		})
+	}
+}
+
+func TestSessionOutgoing(t *testing.T) {
+	msg, _ := engine.GetProtocol().Marshal(map[string]interface{}{engine.ClientMsgDataFieldType: engine.ClientMsgTypeEntityRpc})
+	tests := []struct {
+		name     string
+		oldId    engine.ConnectIdType //有缓存的连接
+		newId    engine.ConnectIdType //oldId恢复到的新连接, 0表示未在恢复中
+		clientId engine.ConnectIdType //消息的目标连接
+		wantId   engine.ConnectIdType //实际发送的连接
+		wantSeq  int64                //消息的序号, 0表示不编号
+	}{
+		{"no session", 0, 0, 1, 1, 0},
+		{"buffered client", 1, 0, 1, 1, 4},
+		{"old client routed to new conn", 1, 2, 1, 2, 4},
+		{"new conn before rebind", 1, 2, 2, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestSessionManager(tt.oldId, tt.newId, 3)
+			id, data := m.outgoing(tt.clientId, msg)
+			if id != tt.wantId {
+				t.Fatalf("target = %d, want %d", id, tt.wantId)
+			}
+			r, err := engine.GetProtocol().UnMarshal(data)
+			if err != nil {
+				t.Fatalf("unmarshal error: %s", err.Error())
+			}
+			if seq := engine.InterfaceToInt(r[engine.ClientMsgDataFieldSeq]); seq != tt.wantSeq {
+				t.Fatalf("seq = %d, want %d", seq, tt.wantSeq)
+			}
+			//编号的消息缓存在原连接下, 恢复时可补发
+			if b, ok := m.buffers[tt.oldId]; tt.wantSeq > 0 && (!ok || len(b.messages) != 1) {
+				t.Fatalf("message not buffered for client[%d]", tt.oldId)
+			}
+		})
+	}
+}
+
+func TestSessionRelease(t *testing.T) {
+	tests := []struct {
+		name      string
+		oldId     engine.ConnectIdType
+		newId     engine.ConnectIdType
+		releaseId engine.ConnectIdType
+	}{
+		{"session expired", 1, 0, 1},
+		{"old session released mid resume", 1, 2, 1},
+		{"new conn closed mid resume", 1, 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestSessionManager(tt.oldId, tt.newId, 3)
+			//其他连接的会话不受影响
+			m.buffers[9] = new(replayBuffer)
+			m.release(tt.releaseId)
+			want := map[engine.ConnectIdType]*replayBuffer{9: {}}
+			if !reflect.DeepEqual(m.buffers, want) {
+				t.Fatalf("buffers = %v, want only client[9]", m.buffers)
+			}
+			if len(m.aliases) != 0 || len(m.resuming) != 0 {
+				t.Fatalf("resume state left: aliases %v, resuming %v", m.aliases, m.resuming)
+			}
+			if m.buffered(tt.oldId) {
+				t.Fatalf("client[%d] still buffered", tt.oldId)
+			}
+		})
+	}
+}
@@ -34,10 +34,18 @@ func (m *systemSignal) init() {
 	m.ch = make(chan os.Signal, 1)
 	signal.Notify(m.ch, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
 	signal.Notify(m.ch, syscall.SIGQUIT, syscall.SIGILL, syscall.SIGABRT)
+	//SIGUSR1开始排空, 排空期间收到其他信号时立即退出
+	signal.Notify(m.ch, syscall.SIGUSR1)
 
 	go func() {
-		s := <-m.ch
-		log.Infof("received signal: %s", s.String())
-		getTaskManager().Push(&ServerStopTask{quitStatus: quitStatusQuited})
+		for s := range m.ch {
+			log.Infof("received signal: %s", s.String())
+			if s == syscall.SIGUSR1 {
+				getTaskManager().Push(&GateDrainTask{})
+				continue
+			}
+			getTaskManager().Push(&ServerStopTask{quitStatus: quitStatusQuited})
+			return
+		}
 	}()
 }
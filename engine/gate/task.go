@@ -7,21 +7,32 @@ import (
 )
 
 type AddClientTask struct {
-	conn gnet.Conn
+	conn clientConn
 }
 
 func (m *AddClientTask) HandleTask() error {
+	if getDrainer().draining() {
+		getDrainer().reject(m.conn)
+		return nil
+	}
 	getClientProxy().addConn(m.conn)
 	return nil
 }
 
 type RemoveClientTask struct {
-	clientId engine.ConnectIdType
+	conn clientConn
 }
 
 func (m *RemoveClientTask) HandleTask() error {
-	getClientProxy().removeConn(m.clientId)
-	getGameProxy().onClientClosed(m.clientId)
+	//在主线程获取clientId, 保证在AddClientTask之后执行
+	clientId := getClientId(m.conn)
+	getClientProxy().removeConn(clientId)
+	//有可恢复的会话时暂不通知game, 会话过期后再通知
+	if !getSessionManager().suspend(clientId) {
+		getSessionManager().release(clientId)
+		getMsgCodec().remove(clientId)
+		getGameProxy().onClientClosed(clientId)
+	}
 	return nil
 }
 
@@ -54,17 +65,24 @@ func (m *RemoveGameServerTask) HandleTask() error {
 }
 
 type ClientMessageTask struct {
-	conn gnet.Conn
+	conn clientConn
 	buf  []byte
 }
 
 func (m *ClientMessageTask) HandleTask() error {
 	clientId := getClientId(m.conn)
 	if c := getClientProxy().client(clientId); c != nil {
-		if data, action := getGameProxy().ClientSendToGame(c, m.buf[0], m.buf[1:]); data != nil {
-			_ = c.AsyncWrite(data)
+		buf, err := getMsgCodec().decode(clientId, m.buf)
+		if err != nil {
+			log.Warnf("decode client[%d] message error: %s", clientId, err.Error())
+			_ = sendToClient(c, genServerErrorMessage(engine.ErrMsgInvalidMessage))
+			getMsgCodec().close(c)
+			return nil
+		}
+		if data, action := getGameProxy().ClientSendToGame(c, buf[0], buf[1:]); data != nil {
+			_ = sendToClient(c, data)
 			if action == gnet.Close {
-				_ = c.Close()
+				getMsgCodec().close(c)
 				log.Infof("server close client[%d] connection", clientId)
 			}
 		}
@@ -74,6 +92,14 @@ func (m *ClientMessageTask) HandleTask() error {
 	return nil
 }
 
+type GateDrainTask struct {
+}
+
+func (m *GateDrainTask) HandleTask() error {
+	getDrainer().start()
+	return nil
+}
+
 type ServerStopTask struct {
 	quitStatus int32
 }
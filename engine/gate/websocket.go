@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"github.com/gorilla/websocket"
+	"github.com/panjf2000/gnet"
+	"net/http"
+	"rpg/engine/engine"
+	"time"
+)
+
+const wsWriteTimeout = 10 * time.Second //单条消息写超时
+
+var wsServer *http.Server
+
+var wsUpGrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+	EnableCompression: true,
+}
+
+// wsConn websocket客户端连接, 每个binary帧是一条完整的消息, 内容与tcp连接去掉长度字段后相同
+type wsConn struct {
+	asyncConn
+	conn *websocket.Conn
+}
+
+func newWsConn(conn *websocket.Conn) *wsConn {
+	return &wsConn{
+		asyncConn: newAsyncConn(conn.RemoteAddr()),
+		conn:      conn,
+	}
+}
+
+func (m *wsConn) write(buf []byte) error {
+	if crypto := getClientCodec().sendCrypto(m, buf); crypto != nil {
+		buf = crypto.Seal(buf)
+	}
+	_ = m.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return m.conn.WriteMessage(websocket.BinaryMessage, buf)
+}
+
+func (m *wsConn) closeConn() {
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	_ = m.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	_ = m.conn.Close()
+}
+
+func (m *wsConn) loopRead() {
+	for {
+		ty, data, err := m.conn.ReadMessage()
+		if err != nil {
+			log.Infof("read from websocket client[%s] error: %s", m.RemoteAddr(), err.Error())
+			break
+		}
+		if ty != websocket.BinaryMessage {
+			continue
+		}
+		if !getClientCodec().established(m) {
+			out, action := getClientCodec().handshake(m, data)
+			if out != nil {
+				_ = m.AsyncWrite(out)
+			}
+			if action == gnet.Close {
+				break
+			}
+			continue
+		}
+		if crypto := getClientCodec().recvCrypto(m); crypto != nil {
+			if data, err = crypto.Open(data); err != nil {
+				log.Warnf("decrypt message from websocket client[%s] error: %s", m.RemoteAddr(), err.Error())
+				break
+			}
+		}
+		if len(data) == 0 {
+			continue
+		}
+		getTaskManager().Push(&ClientMessageTask{conn: m, buf: data})
+	}
+	_ = m.Close()
+	getClientCodec().remove(m)
+	getTaskManager().Push(&RemoveClientTask{conn: m})
+}
+
+func handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := wsUpGrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Warnf("websocket upgrade from [%s] error: %s", r.RemoteAddr, err.Error())
+		return
+	}
+	ws.SetReadLimit(int64(engine.GetProtocol().MaxMessageLen()))
+	c := newWsConn(ws)
+	log.Infof("websocket conn[%s] opened", c.RemoteAddr())
+	getTaskManager().Push(&AddClientTask{conn: c})
+	go c.loopWrite(c.write, c.closeConn)
+	c.loopRead()
+	log.Infof("websocket conn[%s] closed", c.RemoteAddr())
+}
+
+// startWebSocket 配置了websocket地址时在tcp之外同时监听websocket, 两者共用客户端管理与消息路由
+func startWebSocket() {
+	addr := engine.GetConfig().ServerConfig().WebSocket
+	if addr == "" {
+		return
+	}
+	wsServer = &http.Server{Addr: addr, Handler: http.HandlerFunc(handleWebSocket)}
+	go func() {
+		log.Infof("gate[%s] websocket listen at: %s", engine.ServiceName(), addr)
+		if err := wsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Errorf("websocket serve error: %s", err.Error())
+		}
+	}()
+}
+
+// stopWebSocketListener 只关闭websocket监听, 已升级的连接由各自的goroutine处理, 不受影响
+func stopWebSocketListener() {
+	if wsServer == nil {
+		return
+	}
+	if err := wsServer.Close(); err != nil {
+		log.Warnf("close websocket listener error: %s", err.Error())
+	}
+}
+
+func stopWebSocket() {
+	if wsServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	_ = wsServer.Shutdown(ctx)
+}
@@ -23,7 +23,8 @@ var _ = math.Inf
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
 type ExtraInfo struct {
-	Uuid string `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Uuid    string `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	TraceId string `protobuf:"bytes,2,opt,name=traceId,proto3" json:"traceId,omitempty"`
 }
 
 func (m *ExtraInfo) Reset()         { *m = ExtraInfo{} }
@@ -66,6 +67,13 @@ func (m *ExtraInfo) GetUuid() string {
 	return ""
 }
 
+func (m *ExtraInfo) GetTraceId() string {
+	if m != nil {
+		return m.TraceId
+	}
+	return ""
+}
+
 // 发往db的消息
 type DBCommandRequest struct {
 	TaskType   uint32     `protobuf:"varint,1,opt,name=taskType,proto3" json:"taskType,omitempty"`
@@ -76,6 +84,7 @@ type DBCommandRequest struct {
 	Data       []byte     `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
 	Ex         *ExtraInfo `protobuf:"bytes,7,opt,name=ex,proto3" json:"ex,omitempty"`
 	DbType     uint32     `protobuf:"varint,8,opt,name=dbType,proto3" json:"dbType,omitempty"`
+	TraceId    string     `protobuf:"bytes,9,opt,name=traceId,proto3" json:"traceId,omitempty"`
 }
 
 func (m *DBCommandRequest) Reset()         { *m = DBCommandRequest{} }
@@ -167,6 +176,13 @@ func (m *DBCommandRequest) GetDbType() uint32 {
 	return 0
 }
 
+func (m *DBCommandRequest) GetTraceId() string {
+	if m != nil {
+		return m.TraceId
+	}
+	return ""
+}
+
 // db返回的消息
 type DBCommandResponse struct {
 	TaskType uint32     `protobuf:"varint,1,opt,name=taskType,proto3" json:"taskType,omitempty"`
@@ -246,9 +262,12 @@ func (m *DBCommandResponse) GetEx() *ExtraInfo {
 
 // 发往game的entity rpc消息
 type GameEntityRpc struct {
-	Data       []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
-	Source     string `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
-	FromServer bool   `protobuf:"varint,3,opt,name=fromServer,proto3" json:"fromServer,omitempty"`
+	Data       []byte     `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Source     string     `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	FromServer bool       `protobuf:"varint,3,opt,name=fromServer,proto3" json:"fromServer,omitempty"`
+	Ex         *ExtraInfo `protobuf:"bytes,4,opt,name=ex,proto3" json:"ex,omitempty"`
+	ReplyTo    string     `protobuf:"bytes,5,opt,name=replyTo,proto3" json:"replyTo,omitempty"`
+	TraceId    string     `protobuf:"bytes,6,opt,name=traceId,proto3" json:"traceId,omitempty"`
 }
 
 func (m *GameEntityRpc) Reset()         { *m = GameEntityRpc{} }
@@ -305,10 +324,32 @@ func (m *GameEntityRpc) GetFromServer() bool {
 	return false
 }
 
-// 主动连接的一方将自己的服务名通知给对端
+func (m *GameEntityRpc) GetEx() *ExtraInfo {
+	if m != nil {
+		return m.Ex
+	}
+	return nil
+}
+
+func (m *GameEntityRpc) GetReplyTo() string {
+	if m != nil {
+		return m.ReplyTo
+	}
+	return ""
+}
+
+func (m *GameEntityRpc) GetTraceId() string {
+	if m != nil {
+		return m.TraceId
+	}
+	return ""
+}
+
+// 主动连接的一方将自己的服务名通知给对端, isGame表示是game之间的直连
 type SayHello struct {
 	ServiceName string `protobuf:"bytes,1,opt,name=serviceName,proto3" json:"serviceName,omitempty"`
 	Inner       bool   `protobuf:"varint,2,opt,name=inner,proto3" json:"inner,omitempty"`
+	IsGame      bool   `protobuf:"varint,3,opt,name=isGame,proto3" json:"isGame,omitempty"`
 }
 
 func (m *SayHello) Reset()         { *m = SayHello{} }
@@ -358,10 +399,20 @@ func (m *SayHello) GetInner() bool {
 	return false
 }
 
+func (m *SayHello) GetIsGame() bool {
+	if m != nil {
+		return m.IsGame
+	}
+	return false
+}
+
 // game发往gate要求转发的消息
 type GameRouterRpc struct {
-	Target string `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
-	Data   []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Target  string     `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	Data    []byte     `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Ex      *ExtraInfo `protobuf:"bytes,3,opt,name=ex,proto3" json:"ex,omitempty"`
+	Source  string     `protobuf:"bytes,4,opt,name=source,proto3" json:"source,omitempty"`
+	TraceId string     `protobuf:"bytes,5,opt,name=traceId,proto3" json:"traceId,omitempty"`
 }
 
 func (m *GameRouterRpc) Reset()         { *m = GameRouterRpc{} }
@@ -411,6 +462,96 @@ func (m *GameRouterRpc) GetData() []byte {
 	return nil
 }
 
+func (m *GameRouterRpc) GetEx() *ExtraInfo {
+	if m != nil {
+		return m.Ex
+	}
+	return nil
+}
+
+func (m *GameRouterRpc) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *GameRouterRpc) GetTraceId() string {
+	if m != nil {
+		return m.TraceId
+	}
+	return ""
+}
+
+// entity rpc的返回值, data为msgpack序列化的返回值列表
+type EntityRpcResponse struct {
+	Target string     `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	Data   []byte     `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	ErrMsg string     `protobuf:"bytes,3,opt,name=errMsg,proto3" json:"errMsg,omitempty"`
+	Ex     *ExtraInfo `protobuf:"bytes,4,opt,name=ex,proto3" json:"ex,omitempty"`
+}
+
+func (m *EntityRpcResponse) Reset()         { *m = EntityRpcResponse{} }
+func (m *EntityRpcResponse) String() string { return proto.CompactTextString(m) }
+func (*EntityRpcResponse) ProtoMessage()    {}
+func (*EntityRpcResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_33c57e4bae7b9afd, []int{6}
+}
+func (m *EntityRpcResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *EntityRpcResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_EntityRpcResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *EntityRpcResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EntityRpcResponse.Merge(m, src)
+}
+func (m *EntityRpcResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *EntityRpcResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_EntityRpcResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EntityRpcResponse proto.InternalMessageInfo
+
+func (m *EntityRpcResponse) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
+func (m *EntityRpcResponse) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *EntityRpcResponse) GetErrMsg() string {
+	if m != nil {
+		return m.ErrMsg
+	}
+	return ""
+}
+
+func (m *EntityRpcResponse) GetEx() *ExtraInfo {
+	if m != nil {
+		return m.Ex
+	}
+	return nil
+}
+
 // 创建entity消息请求
 type CreateEntityRequest struct {
 	EntityName string     `protobuf:"bytes,1,opt,name=entityName,proto3" json:"entityName,omitempty"`
@@ -422,7 +563,7 @@ func (m *CreateEntityRequest) Reset()         { *m = CreateEntityRequest{} }
 func (m *CreateEntityRequest) String() string { return proto.CompactTextString(m) }
 func (*CreateEntityRequest) ProtoMessage()    {}
 func (*CreateEntityRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_33c57e4bae7b9afd, []int{6}
+	return fileDescriptor_33c57e4bae7b9afd, []int{7}
 }
 func (m *CreateEntityRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -484,7 +625,7 @@ func (m *CreateEntityResponse) Reset()         { *m = CreateEntityResponse{} }
 func (m *CreateEntityResponse) String() string { return proto.CompactTextString(m) }
 func (*CreateEntityResponse) ProtoMessage()    {}
 func (*CreateEntityResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_33c57e4bae7b9afd, []int{7}
+	return fileDescriptor_33c57e4bae7b9afd, []int{8}
 }
 func (m *CreateEntityResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -541,16 +682,17 @@ func (m *CreateEntityResponse) GetEx() *ExtraInfo {
 	return nil
 }
 
-// 服务器错误信息
+// 服务器错误信息, args为错误信息的附加参数
 type ServerError struct {
-	ErrMsg string `protobuf:"bytes,1,opt,name=errMsg,proto3" json:"errMsg,omitempty"`
+	ErrMsg string   `protobuf:"bytes,1,opt,name=errMsg,proto3" json:"errMsg,omitempty"`
+	Args   []string `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
 }
 
 func (m *ServerError) Reset()         { *m = ServerError{} }
 func (m *ServerError) String() string { return proto.CompactTextString(m) }
 func (*ServerError) ProtoMessage()    {}
 func (*ServerError) Descriptor() ([]byte, []int) {
-	return fileDescriptor_33c57e4bae7b9afd, []int{8}
+	return fileDescriptor_33c57e4bae7b9afd, []int{9}
 }
 func (m *ServerError) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -586,6 +728,13 @@ func (m *ServerError) GetErrMsg() string {
 	return ""
 }
 
+func (m *ServerError) GetArgs() []string {
+	if m != nil {
+		return m.Args
+	}
+	return nil
+}
+
 // 客户端连接绑定到entity
 type ClientBindEntity struct {
 	EntityId int64  `protobuf:"varint,1,opt,name=entityId,proto3" json:"entityId,omitempty"`
@@ -597,7 +746,7 @@ func (m *ClientBindEntity) Reset()         { *m = ClientBindEntity{} }
 func (m *ClientBindEntity) String() string { return proto.CompactTextString(m) }
 func (*ClientBindEntity) ProtoMessage()    {}
 func (*ClientBindEntity) Descriptor() ([]byte, []int) {
-	return fileDescriptor_33c57e4bae7b9afd, []int{9}
+	return fileDescriptor_33c57e4bae7b9afd, []int{10}
 }
 func (m *ClientBindEntity) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -657,7 +806,7 @@ func (m *SetServerTimeOffset) Reset()         { *m = SetServerTimeOffset{} }
 func (m *SetServerTimeOffset) String() string { return proto.CompactTextString(m) }
 func (*SetServerTimeOffset) ProtoMessage()    {}
 func (*SetServerTimeOffset) Descriptor() ([]byte, []int) {
-	return fileDescriptor_33c57e4bae7b9afd, []int{10}
+	return fileDescriptor_33c57e4bae7b9afd, []int{11}
 }
 func (m *SetServerTimeOffset) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -700,170 +849,472 @@ func (m *SetServerTimeOffset) GetTargets() []string {
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*ExtraInfo)(nil), "ExtraInfo")
-	proto.RegisterType((*DBCommandRequest)(nil), "DBCommandRequest")
-	proto.RegisterType((*DBCommandResponse)(nil), "DBCommandResponse")
-	proto.RegisterType((*GameEntityRpc)(nil), "GameEntityRpc")
-	proto.RegisterType((*SayHello)(nil), "SayHello")
-	proto.RegisterType((*GameRouterRpc)(nil), "GameRouterRpc")
-	proto.RegisterType((*CreateEntityRequest)(nil), "CreateEntityRequest")
-	proto.RegisterType((*CreateEntityResponse)(nil), "CreateEntityResponse")
-	proto.RegisterType((*ServerError)(nil), "ServerError")
-	proto.RegisterType((*ClientBindEntity)(nil), "ClientBindEntity")
-	proto.RegisterType((*SetServerTimeOffset)(nil), "SetServerTimeOffset")
+// entity迁移到其他game的请求, data为msgpack序列化的entity数据
+type MigrateEntityRequest struct {
+	EntityId int64      `protobuf:"varint,1,opt,name=entityId,proto3" json:"entityId,omitempty"`
+	Source   string     `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Target   string     `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	Data     []byte     `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	Ex       *ExtraInfo `protobuf:"bytes,5,opt,name=ex,proto3" json:"ex,omitempty"`
 }
 
-func init() { proto.RegisterFile("message.proto", fileDescriptor_33c57e4bae7b9afd) }
+func (m *MigrateEntityRequest) Reset()         { *m = MigrateEntityRequest{} }
+func (m *MigrateEntityRequest) String() string { return proto.CompactTextString(m) }
+func (*MigrateEntityRequest) ProtoMessage()    {}
+func (*MigrateEntityRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_33c57e4bae7b9afd, []int{12}
+}
+func (m *MigrateEntityRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *MigrateEntityRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_MigrateEntityRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *MigrateEntityRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MigrateEntityRequest.Merge(m, src)
+}
+func (m *MigrateEntityRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *MigrateEntityRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MigrateEntityRequest.DiscardUnknown(m)
+}
 
-var fileDescriptor_33c57e4bae7b9afd = []byte{
-	// 554 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x94, 0xb1, 0x8e, 0xda, 0x40,
-	0x10, 0x86, 0x6f, 0x31, 0x70, 0x66, 0x38, 0xa4, 0x8b, 0xef, 0x84, 0x2c, 0x0a, 0xc7, 0x72, 0x14,
-	0x89, 0x8a, 0x22, 0x29, 0xaf, 0x83, 0xa0, 0xcb, 0x15, 0x49, 0xa4, 0xe5, 0xaa, 0xa4, 0x5a, 0xec,
-	0x01, 0x59, 0xb1, 0xbd, 0xdc, 0xee, 0xfa, 0x04, 0x2f, 0x90, 0x3a, 0x52, 0x5e, 0x2a, 0xe5, 0x95,
-	0x29, 0x23, 0x90, 0xf2, 0x1c, 0x91, 0xd7, 0x8b, 0x31, 0x11, 0x49, 0x91, 0x6e, 0xff, 0x99, 0xd5,
-	0xcc, 0x37, 0xf3, 0xaf, 0x16, 0x7a, 0x29, 0x4a, 0xc9, 0x96, 0x38, 0x5a, 0x09, 0xae, 0x78, 0xf0,
-	0x1c, 0x3a, 0xd3, 0xb5, 0x12, 0xec, 0x2e, 0x5b, 0x70, 0xc7, 0x81, 0x66, 0x9e, 0xc7, 0x91, 0x4b,
-	0x7c, 0x32, 0xec, 0x50, 0x7d, 0x0e, 0x7e, 0x11, 0xb8, 0x7c, 0x33, 0x9e, 0xf0, 0x34, 0x65, 0x59,
-	0x44, 0xf1, 0x21, 0x47, 0xa9, 0x9c, 0x01, 0xd8, 0x8a, 0xc9, 0xcf, 0xf7, 0x9b, 0x15, 0xea, 0xcb,
-	0x3d, 0x5a, 0xe9, 0x22, 0x87, 0x99, 0x8a, 0xd5, 0xe6, 0x2e, 0x72, 0x1b, 0x3e, 0x19, 0x5a, 0xb4,
-	0xd2, 0x45, 0x2e, 0x62, 0x8a, 0xcd, 0x99, 0x44, 0xd7, 0xd2, 0x4d, 0x2a, 0xed, 0x78, 0x00, 0x21,
-	0x4f, 0x12, 0x0c, 0x55, 0xcc, 0x33, 0xb7, 0xa9, 0xb3, 0xb5, 0x88, 0xd3, 0x87, 0xf6, 0x22, 0x4e,
-	0x14, 0x0a, 0xb7, 0xe5, 0x93, 0xe1, 0x05, 0x35, 0xaa, 0x80, 0x2e, 0x6a, 0xb8, 0x6d, 0x1d, 0xd5,
-	0x67, 0x67, 0x00, 0x0d, 0x5c, 0xbb, 0xe7, 0x3e, 0x19, 0x76, 0x5f, 0xc1, 0xa8, 0x1a, 0x90, 0x36,
-	0x70, 0x5d, 0xd4, 0x89, 0xe6, 0x9a, 0xdc, 0xd6, 0xe4, 0x46, 0x05, 0xdf, 0x08, 0x3c, 0xab, 0x0d,
-	0x2a, 0x57, 0x3c, 0x93, 0xf8, 0xdf, 0x93, 0xee, 0xa9, 0xac, 0x1a, 0x55, 0x1f, 0xda, 0x28, 0xc4,
-	0x3b, 0xb9, 0xd4, 0xd3, 0x5d, 0x50, 0xa3, 0x0c, 0x6d, 0xeb, 0x14, 0x6d, 0xf0, 0x09, 0x7a, 0xb7,
-	0x2c, 0xc5, 0xa9, 0xae, 0x4b, 0x57, 0x61, 0x55, 0x98, 0x1c, 0x17, 0x96, 0x3c, 0x17, 0x21, 0x6a,
-	0x8c, 0x0e, 0x35, 0xaa, 0x58, 0xe9, 0x42, 0xf0, 0x74, 0x86, 0xe2, 0x11, 0x85, 0x46, 0xb1, 0x69,
-	0x2d, 0x12, 0x8c, 0xc1, 0x9e, 0xb1, 0xcd, 0x5b, 0x4c, 0x12, 0xee, 0xf8, 0xd0, 0x95, 0x28, 0x1e,
-	0xe3, 0x10, 0xdf, 0xb3, 0x14, 0xcd, 0x13, 0xa8, 0x87, 0x9c, 0x6b, 0x68, 0xc5, 0x59, 0x86, 0x42,
-	0x37, 0xb1, 0x69, 0x29, 0x82, 0x9b, 0x12, 0x90, 0xf2, 0x5c, 0xa1, 0x28, 0x00, 0xfb, 0xd0, 0x56,
-	0x4c, 0x2c, 0x51, 0x99, 0x1a, 0x46, 0x55, 0xe0, 0x8d, 0x03, 0x78, 0xf0, 0x00, 0x57, 0x13, 0x81,
-	0x4c, 0xed, 0xe7, 0x33, 0xcf, 0xcb, 0x03, 0x28, 0x17, 0x59, 0x43, 0xa9, 0x45, 0x8a, 0xbc, 0xd4,
-	0x13, 0xe8, 0x7c, 0x39, 0x73, 0x2d, 0x62, 0x16, 0x6a, 0x9d, 0x5c, 0xe8, 0x17, 0x02, 0xd7, 0xc7,
-	0x3d, 0x0f, 0x4e, 0x57, 0x6e, 0x92, 0x3f, 0xdc, 0x3c, 0x38, 0x67, 0x16, 0x6c, 0x9c, 0x3b, 0x06,
-	0xb1, 0xfe, 0x02, 0xd2, 0x3c, 0x09, 0xf2, 0x12, 0xba, 0xa5, 0x0d, 0x53, 0x21, 0xb8, 0xa8, 0xb5,
-	0x20, 0xf5, 0x16, 0xc1, 0x1c, 0x2e, 0x27, 0x49, 0x8c, 0x99, 0x1a, 0xc7, 0x59, 0x54, 0x22, 0xff,
-	0x13, 0x75, 0x00, 0x76, 0xa8, 0xef, 0x9b, 0x47, 0xd9, 0xa3, 0x95, 0x2e, 0x7a, 0xe4, 0xd9, 0x3c,
-	0xce, 0x22, 0xf3, 0x16, 0x8c, 0x0a, 0x6e, 0xe1, 0x6a, 0x86, 0xaa, 0xa4, 0xb9, 0x8f, 0x53, 0xfc,
-	0xb0, 0x58, 0x48, 0x54, 0xc5, 0x75, 0xae, 0x4f, 0xba, 0x49, 0x8b, 0x1a, 0xe5, 0xb8, 0x70, 0x5e,
-	0x7a, 0x2a, 0xdd, 0x86, 0x6f, 0x0d, 0x3b, 0x74, 0x2f, 0xc7, 0x2f, 0xbe, 0x6f, 0x3d, 0xf2, 0xb4,
-	0xf5, 0xc8, 0xcf, 0xad, 0x47, 0xbe, 0xee, 0xbc, 0xb3, 0xa7, 0x9d, 0x77, 0xf6, 0x63, 0xe7, 0x9d,
-	0x7d, 0xec, 0x8c, 0x6e, 0xcc, 0xc7, 0x33, 0x6f, 0xeb, 0x9f, 0xe7, 0xf5, 0xef, 0x00, 0x00, 0x00,
-	0xff, 0xff, 0xb1, 0x46, 0xc5, 0x20, 0x8a, 0x04, 0x00, 0x00,
+var xxx_messageInfo_MigrateEntityRequest proto.InternalMessageInfo
+
+func (m *MigrateEntityRequest) GetEntityId() int64 {
+	if m != nil {
+		return m.EntityId
+	}
+	return 0
 }
 
-func (m *ExtraInfo) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *MigrateEntityRequest) GetSource() string {
+	if m != nil {
+		return m.Source
 	}
-	return dAtA[:n], nil
+	return ""
 }
 
-func (m *ExtraInfo) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *MigrateEntityRequest) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
 }
 
-func (m *ExtraInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Uuid) > 0 {
-		i -= len(m.Uuid)
-		copy(dAtA[i:], m.Uuid)
-		i = encodeVarintMessage(dAtA, i, uint64(len(m.Uuid)))
-		i--
-		dAtA[i] = 0xa
+func (m *MigrateEntityRequest) GetData() []byte {
+	if m != nil {
+		return m.Data
 	}
-	return len(dAtA) - i, nil
+	return nil
 }
 
-func (m *DBCommandRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *MigrateEntityRequest) GetEx() *ExtraInfo {
+	if m != nil {
+		return m.Ex
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *DBCommandRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+// entity迁移结果
+type MigrateEntityResponse struct {
+	EntityId int64      `protobuf:"varint,1,opt,name=entityId,proto3" json:"entityId,omitempty"`
+	Source   string     `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Target   string     `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	ErrMsg   string     `protobuf:"bytes,4,opt,name=errMsg,proto3" json:"errMsg,omitempty"`
+	Ex       *ExtraInfo `protobuf:"bytes,5,opt,name=ex,proto3" json:"ex,omitempty"`
 }
 
-func (m *DBCommandRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.DbType != 0 {
-		i = encodeVarintMessage(dAtA, i, uint64(m.DbType))
-		i--
-		dAtA[i] = 0x40
-	}
-	if m.Ex != nil {
-		{
-			size, err := m.Ex.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintMessage(dAtA, i, uint64(size))
+func (m *MigrateEntityResponse) Reset()         { *m = MigrateEntityResponse{} }
+func (m *MigrateEntityResponse) String() string { return proto.CompactTextString(m) }
+func (*MigrateEntityResponse) ProtoMessage()    {}
+func (*MigrateEntityResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_33c57e4bae7b9afd, []int{13}
+}
+func (m *MigrateEntityResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *MigrateEntityResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_MigrateEntityResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
-		i--
-		dAtA[i] = 0x3a
-	}
-	if len(m.Data) > 0 {
-		i -= len(m.Data)
-		copy(dAtA[i:], m.Data)
-		i = encodeVarintMessage(dAtA, i, uint64(len(m.Data)))
-		i--
-		dAtA[i] = 0x32
+		return b[:n], nil
 	}
-	if len(m.Filter) > 0 {
-		i -= len(m.Filter)
-		copy(dAtA[i:], m.Filter)
-		i = encodeVarintMessage(dAtA, i, uint64(len(m.Filter)))
-		i--
-		dAtA[i] = 0x2a
+}
+func (m *MigrateEntityResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MigrateEntityResponse.Merge(m, src)
+}
+func (m *MigrateEntityResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *MigrateEntityResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_MigrateEntityResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MigrateEntityResponse proto.InternalMessageInfo
+
+func (m *MigrateEntityResponse) GetEntityId() int64 {
+	if m != nil {
+		return m.EntityId
 	}
-	if len(m.Collection) > 0 {
-		i -= len(m.Collection)
-		copy(dAtA[i:], m.Collection)
-		i = encodeVarintMessage(dAtA, i, uint64(len(m.Collection)))
-		i--
-		dAtA[i] = 0x22
+	return 0
+}
+
+func (m *MigrateEntityResponse) GetSource() string {
+	if m != nil {
+		return m.Source
 	}
-	if len(m.Database) > 0 {
-		i -= len(m.Database)
-		copy(dAtA[i:], m.Database)
-		i = encodeVarintMessage(dAtA, i, uint64(len(m.Database)))
-		i--
-		dAtA[i] = 0x1a
+	return ""
+}
+
+func (m *MigrateEntityResponse) GetTarget() string {
+	if m != nil {
+		return m.Target
 	}
-	if m.EntityId != 0 {
-		i = encodeVarintMessage(dAtA, i, uint64(m.EntityId))
+	return ""
+}
+
+func (m *MigrateEntityResponse) GetErrMsg() string {
+	if m != nil {
+		return m.ErrMsg
+	}
+	return ""
+}
+
+func (m *MigrateEntityResponse) GetEx() *ExtraInfo {
+	if m != nil {
+		return m.Ex
+	}
+	return nil
+}
+
+// 源game等待迁移结果超时后取消迁移, 目标game未创建entity时不再创建, 已创建时以MigrateEntityResponse回复成功
+type MigrateEntityAbort struct {
+	EntityId    int64      `protobuf:"varint,1,opt,name=entityId,proto3" json:"entityId,omitempty"`
+	Source      string     `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Target      string     `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	MigrateUuid string     `protobuf:"bytes,4,opt,name=migrateUuid,proto3" json:"migrateUuid,omitempty"`
+	Ex          *ExtraInfo `protobuf:"bytes,5,opt,name=ex,proto3" json:"ex,omitempty"`
+}
+
+func (m *MigrateEntityAbort) Reset()         { *m = MigrateEntityAbort{} }
+func (m *MigrateEntityAbort) String() string { return proto.CompactTextString(m) }
+func (*MigrateEntityAbort) ProtoMessage()    {}
+func (*MigrateEntityAbort) Descriptor() ([]byte, []int) {
+	return fileDescriptor_33c57e4bae7b9afd, []int{14}
+}
+func (m *MigrateEntityAbort) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *MigrateEntityAbort) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_MigrateEntityAbort.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *MigrateEntityAbort) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MigrateEntityAbort.Merge(m, src)
+}
+func (m *MigrateEntityAbort) XXX_Size() int {
+	return m.Size()
+}
+func (m *MigrateEntityAbort) XXX_DiscardUnknown() {
+	xxx_messageInfo_MigrateEntityAbort.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MigrateEntityAbort proto.InternalMessageInfo
+
+func (m *MigrateEntityAbort) GetEntityId() int64 {
+	if m != nil {
+		return m.EntityId
+	}
+	return 0
+}
+
+func (m *MigrateEntityAbort) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *MigrateEntityAbort) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
+func (m *MigrateEntityAbort) GetMigrateUuid() string {
+	if m != nil {
+		return m.MigrateUuid
+	}
+	return ""
+}
+
+func (m *MigrateEntityAbort) GetEx() *ExtraInfo {
+	if m != nil {
+		return m.Ex
+	}
+	return nil
+}
+
+// 由gate原样转发给指定game的消息
+type ForwardGameMessage struct {
+	Target   string `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	MsgType  uint32 `protobuf:"varint,2,opt,name=msgType,proto3" json:"msgType,omitempty"`
+	ClientId uint32 `protobuf:"varint,3,opt,name=clientId,proto3" json:"clientId,omitempty"`
+	Data     []byte `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *ForwardGameMessage) Reset()         { *m = ForwardGameMessage{} }
+func (m *ForwardGameMessage) String() string { return proto.CompactTextString(m) }
+func (*ForwardGameMessage) ProtoMessage()    {}
+func (*ForwardGameMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_33c57e4bae7b9afd, []int{15}
+}
+func (m *ForwardGameMessage) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ForwardGameMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ForwardGameMessage.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ForwardGameMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ForwardGameMessage.Merge(m, src)
+}
+func (m *ForwardGameMessage) XXX_Size() int {
+	return m.Size()
+}
+func (m *ForwardGameMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_ForwardGameMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ForwardGameMessage proto.InternalMessageInfo
+
+func (m *ForwardGameMessage) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
+func (m *ForwardGameMessage) GetMsgType() uint32 {
+	if m != nil {
+		return m.MsgType
+	}
+	return 0
+}
+
+func (m *ForwardGameMessage) GetClientId() uint32 {
+	if m != nil {
+		return m.ClientId
+	}
+	return 0
+}
+
+func (m *ForwardGameMessage) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+// 客户端断线重连恢复会话, 消息头中的clientId为新连接
+type ResumeClient struct {
+	OldClientId uint32 `protobuf:"varint,1,opt,name=oldClientId,proto3" json:"oldClientId,omitempty"`
+	Resync      bool   `protobuf:"varint,2,opt,name=resync,proto3" json:"resync,omitempty"`
+}
+
+func (m *ResumeClient) Reset()         { *m = ResumeClient{} }
+func (m *ResumeClient) String() string { return proto.CompactTextString(m) }
+func (*ResumeClient) ProtoMessage()    {}
+func (*ResumeClient) Descriptor() ([]byte, []int) {
+	return fileDescriptor_33c57e4bae7b9afd, []int{16}
+}
+func (m *ResumeClient) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ResumeClient) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ResumeClient.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ResumeClient) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResumeClient.Merge(m, src)
+}
+func (m *ResumeClient) XXX_Size() int {
+	return m.Size()
+}
+func (m *ResumeClient) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResumeClient.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResumeClient proto.InternalMessageInfo
+
+func (m *ResumeClient) GetOldClientId() uint32 {
+	if m != nil {
+		return m.OldClientId
+	}
+	return 0
+}
+
+func (m *ResumeClient) GetResync() bool {
+	if m != nil {
+		return m.Resync
+	}
+	return false
+}
+
+func init() {
+	proto.RegisterType((*ExtraInfo)(nil), "ExtraInfo")
+	proto.RegisterType((*DBCommandRequest)(nil), "DBCommandRequest")
+	proto.RegisterType((*DBCommandResponse)(nil), "DBCommandResponse")
+	proto.RegisterType((*GameEntityRpc)(nil), "GameEntityRpc")
+	proto.RegisterType((*SayHello)(nil), "SayHello")
+	proto.RegisterType((*GameRouterRpc)(nil), "GameRouterRpc")
+	proto.RegisterType((*EntityRpcResponse)(nil), "EntityRpcResponse")
+	proto.RegisterType((*CreateEntityRequest)(nil), "CreateEntityRequest")
+	proto.RegisterType((*CreateEntityResponse)(nil), "CreateEntityResponse")
+	proto.RegisterType((*ServerError)(nil), "ServerError")
+	proto.RegisterType((*ClientBindEntity)(nil), "ClientBindEntity")
+	proto.RegisterType((*SetServerTimeOffset)(nil), "SetServerTimeOffset")
+	proto.RegisterType((*MigrateEntityRequest)(nil), "MigrateEntityRequest")
+	proto.RegisterType((*MigrateEntityResponse)(nil), "MigrateEntityResponse")
+	proto.RegisterType((*MigrateEntityAbort)(nil), "MigrateEntityAbort")
+	proto.RegisterType((*ForwardGameMessage)(nil), "ForwardGameMessage")
+	proto.RegisterType((*ResumeClient)(nil), "ResumeClient")
+}
+
+func init() { proto.RegisterFile("message.proto", fileDescriptor_33c57e4bae7b9afd) }
+
+var fileDescriptor_33c57e4bae7b9afd = []byte{
+	// 781 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0xbf, 0x4f, 0xdc, 0x48,
+	0x14, 0x66, 0xd6, 0xfb, 0xf3, 0x2d, 0x2b, 0x81, 0xe1, 0x90, 0x45, 0x61, 0xad, 0x7c, 0x0d, 0x15,
+	0xc5, 0x5d, 0x85, 0xae, 0x3a, 0xf6, 0x38, 0xa0, 0xe0, 0x4e, 0x32, 0xa4, 0xa1, 0x9b, 0xb5, 0xdf,
+	0xae, 0xac, 0xd8, 0x9e, 0x65, 0x66, 0x96, 0xb0, 0x5d, 0xaa, 0x48, 0xe9, 0xa2, 0xa4, 0x48, 0x97,
+	0x7f, 0x20, 0xff, 0x48, 0x4a, 0xca, 0x94, 0x11, 0xfc, 0x23, 0xd1, 0x8c, 0xc7, 0x5e, 0x1b, 0x0c,
+	0x81, 0x28, 0xdd, 0x7c, 0x6f, 0xac, 0xf7, 0xbe, 0xf7, 0xcd, 0x37, 0x6f, 0x0c, 0x83, 0x04, 0x85,
+	0xa0, 0x53, 0xdc, 0x9d, 0x71, 0x26, 0x99, 0xb7, 0x07, 0xbd, 0x83, 0x2b, 0xc9, 0xe9, 0x71, 0x3a,
+	0x61, 0xb6, 0x0d, 0xcd, 0xf9, 0x3c, 0x0a, 0x1d, 0x32, 0x24, 0x3b, 0x3d, 0x5f, 0xaf, 0x6d, 0x07,
+	0x3a, 0x92, 0xd3, 0x00, 0x8f, 0x43, 0xa7, 0xa1, 0xc3, 0x39, 0xf4, 0x5e, 0x37, 0x60, 0xed, 0x9f,
+	0xfd, 0x11, 0x4b, 0x12, 0x9a, 0x86, 0x3e, 0x5e, 0xcc, 0x51, 0x48, 0x7b, 0x1b, 0xba, 0x92, 0x8a,
+	0x97, 0x67, 0x8b, 0x19, 0xea, 0x34, 0x03, 0xbf, 0xc0, 0x6a, 0x0f, 0x53, 0x19, 0xc9, 0x85, 0xc9,
+	0x65, 0xf9, 0x05, 0x56, 0x7b, 0x21, 0x95, 0x74, 0x4c, 0x05, 0x3a, 0x96, 0xae, 0x53, 0x60, 0xdb,
+	0x05, 0x08, 0x58, 0x1c, 0x63, 0x20, 0x23, 0x96, 0x3a, 0x4d, 0xbd, 0x5b, 0x8a, 0xd8, 0x5b, 0xd0,
+	0x9e, 0x44, 0xb1, 0x44, 0xee, 0xb4, 0x86, 0x64, 0x67, 0xd5, 0x37, 0x48, 0xb5, 0xa3, 0x72, 0x38,
+	0x6d, 0x1d, 0xd5, 0x6b, 0x7b, 0x1b, 0x1a, 0x78, 0xe5, 0x74, 0x86, 0x64, 0xa7, 0xff, 0x07, 0xec,
+	0x16, 0xad, 0xfb, 0x0d, 0xbc, 0x52, 0x79, 0xc2, 0xb1, 0x66, 0xde, 0xd5, 0xcc, 0x0d, 0x2a, 0x4b,
+	0xd0, 0xab, 0x4a, 0xf0, 0x81, 0xc0, 0x7a, 0x49, 0x02, 0x31, 0x63, 0xa9, 0xc0, 0x9f, 0xd6, 0x20,
+	0xe7, 0x6b, 0x95, 0xf8, 0x6e, 0x41, 0x1b, 0x39, 0x3f, 0x11, 0x53, 0xdd, 0xf7, 0xaa, 0x6f, 0x90,
+	0xe9, 0xa3, 0x55, 0xd7, 0x87, 0xf7, 0x99, 0xc0, 0xe0, 0x90, 0x26, 0x78, 0xa0, 0x13, 0xfb, 0xb3,
+	0xa0, 0xc8, 0x4c, 0xaa, 0x99, 0x05, 0x9b, 0xf3, 0x00, 0xcd, 0xb9, 0x1a, 0xa4, 0xd4, 0x9e, 0x70,
+	0x96, 0x9c, 0x22, 0xbf, 0x44, 0xae, 0xb9, 0x74, 0xfd, 0x52, 0xc4, 0x54, 0x6e, 0xd6, 0x2a, 0xe8,
+	0x40, 0x87, 0xe3, 0x2c, 0x5e, 0x9c, 0x31, 0x4d, 0xad, 0xe7, 0xe7, 0xb0, 0xac, 0x61, 0xbb, 0xaa,
+	0xe1, 0x39, 0x74, 0x4f, 0xe9, 0xe2, 0x08, 0xe3, 0x98, 0xd9, 0x43, 0xe8, 0x0b, 0xe4, 0x97, 0x51,
+	0x80, 0xff, 0xd1, 0x04, 0x8d, 0x0f, 0xcb, 0x21, 0x7b, 0x13, 0x5a, 0x51, 0x9a, 0x22, 0xd7, 0xa4,
+	0xbb, 0x7e, 0x06, 0x54, 0x2f, 0x91, 0x50, 0x2d, 0x1b, 0xbe, 0x06, 0x79, 0x6f, 0x8d, 0x12, 0x3e,
+	0x9b, 0x4b, 0xe4, 0x4a, 0x89, 0x2d, 0x68, 0x4b, 0xca, 0xa7, 0x28, 0x4d, 0x72, 0x83, 0x0a, 0x85,
+	0x1a, 0xf7, 0xbc, 0x62, 0x3d, 0xe4, 0x15, 0xa3, 0x5e, 0xb3, 0xa2, 0x5e, 0xa9, 0xcf, 0x56, 0xb5,
+	0x4f, 0x01, 0xeb, 0xc5, 0x81, 0x14, 0x56, 0x79, 0x0e, 0x9d, 0xa5, 0x15, 0xb2, 0x0b, 0x52, 0xb5,
+	0x42, 0xed, 0x81, 0x78, 0x17, 0xb0, 0x31, 0xe2, 0x48, 0x65, 0xee, 0x05, 0x73, 0x4b, 0x5d, 0x80,
+	0xcc, 0x75, 0x25, 0x99, 0x4b, 0x11, 0xb5, 0x2f, 0xf4, 0x69, 0xeb, 0xfd, 0xcc, 0x1f, 0xa5, 0xc8,
+	0x63, 0xca, 0x78, 0x6f, 0x08, 0x6c, 0x56, 0x6b, 0x2e, 0xaf, 0x45, 0x61, 0x7d, 0x72, 0xc7, 0xfa,
+	0xcb, 0xde, 0x1a, 0x95, 0xde, 0xaa, 0x44, 0xac, 0x07, 0x88, 0xd4, 0xf7, 0xbe, 0x07, 0xfd, 0xcc,
+	0xb2, 0x07, 0x9c, 0x33, 0x5e, 0x2a, 0x41, 0x2a, 0x25, 0x6c, 0x68, 0x52, 0x3e, 0x15, 0x4e, 0x63,
+	0x68, 0xa9, 0xa1, 0xa7, 0xd6, 0xde, 0x18, 0xd6, 0x46, 0x71, 0x84, 0xa9, 0xdc, 0x8f, 0xd2, 0x30,
+	0x6b, 0xe3, 0x51, 0xfa, 0xdb, 0xd0, 0x0d, 0xf4, 0xf7, 0xe6, 0x56, 0x0f, 0xfc, 0x02, 0xab, 0xba,
+	0xf3, 0x74, 0x1c, 0xa5, 0x61, 0xee, 0xcd, 0x0c, 0x79, 0x87, 0xb0, 0x71, 0x8a, 0x32, 0x63, 0x78,
+	0x16, 0x25, 0xf8, 0xff, 0x64, 0x22, 0x50, 0xaa, 0xcf, 0x99, 0x5e, 0xe9, 0x22, 0x2d, 0xdf, 0x20,
+	0x6d, 0x2c, 0xed, 0x8d, 0x9c, 0x69, 0x0e, 0xbd, 0xf7, 0x04, 0x36, 0x4f, 0xa2, 0x29, 0xbf, 0x77,
+	0xca, 0x3f, 0x10, 0xbc, 0xf6, 0xf6, 0x2f, 0x0d, 0x69, 0xd5, 0x1a, 0xb2, 0x79, 0xef, 0x7e, 0xd4,
+	0xcf, 0xa0, 0x8f, 0x04, 0x7e, 0xbb, 0x43, 0xea, 0x69, 0x36, 0x78, 0x16, 0xab, 0xea, 0x74, 0xec,
+	0x3d, 0x69, 0x3a, 0x7e, 0x22, 0x60, 0x57, 0x98, 0xfd, 0x3d, 0x66, 0xfc, 0xd7, 0x8a, 0x35, 0x84,
+	0x7e, 0x92, 0x55, 0x78, 0xa1, 0x9e, 0xd3, 0x8c, 0x5b, 0x39, 0xf4, 0x28, 0xc1, 0x4b, 0xb0, 0xff,
+	0x65, 0xfc, 0x15, 0xe5, 0xa1, 0x1a, 0x5d, 0x27, 0xd9, 0x73, 0xfd, 0xe0, 0xa4, 0x70, 0xa0, 0x93,
+	0x88, 0xa9, 0x7e, 0x6b, 0x32, 0xe7, 0xe5, 0xb0, 0x62, 0x4a, 0xeb, 0x8e, 0x29, 0x6b, 0x8e, 0xd3,
+	0x3b, 0x82, 0x55, 0x1f, 0xc5, 0x3c, 0xc1, 0xcc, 0xfa, 0xaa, 0x0b, 0x16, 0x87, 0xa3, 0x3c, 0x45,
+	0xf6, 0x92, 0x95, 0x43, 0x8a, 0x13, 0x47, 0xb1, 0x48, 0x03, 0x33, 0x8d, 0x0d, 0xda, 0xff, 0xfd,
+	0xcb, 0x8d, 0x4b, 0xae, 0x6f, 0x5c, 0xf2, 0xed, 0xc6, 0x25, 0xef, 0x6e, 0xdd, 0x95, 0xeb, 0x5b,
+	0x77, 0xe5, 0xeb, 0xad, 0xbb, 0x72, 0xde, 0xdb, 0xfd, 0xcb, 0xfc, 0x7f, 0x8c, 0xdb, 0xfa, 0x07,
+	0xe4, 0xcf, 0xef, 0x03, 0x00, 0x9a, 0xb0, 0x1a, 0x13, 0x91, 0x08, 0x00, 0x00,
+}
+
+func (m *ExtraInfo) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExtraInfo) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExtraInfo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.TraceId) > 0 {
+		i -= len(m.TraceId)
+		copy(dAtA[i:], m.TraceId)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.TraceId)))
 		i--
-		dAtA[i] = 0x10
+		dAtA[i] = 0x12
 	}
-	if m.TaskType != 0 {
-		i = encodeVarintMessage(dAtA, i, uint64(m.TaskType))
+	if len(m.Uuid) > 0 {
+		i -= len(m.Uuid)
+		copy(dAtA[i:], m.Uuid)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Uuid)))
 		i--
-		dAtA[i] = 0x8
+		dAtA[i] = 0xa
 	}
 	return len(dAtA) - i, nil
 }
 
-func (m *DBCommandResponse) Marshal() (dAtA []byte, err error) {
+func (m *DBCommandRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -873,16 +1324,28 @@ func (m *DBCommandResponse) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *DBCommandResponse) MarshalTo(dAtA []byte) (int, error) {
+func (m *DBCommandRequest) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *DBCommandResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *DBCommandRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
+	if len(m.TraceId) > 0 {
+		i -= len(m.TraceId)
+		copy(dAtA[i:], m.TraceId)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.TraceId)))
+		i--
+		dAtA[i] = 0x4a
+	}
+	if m.DbType != 0 {
+		i = encodeVarintMessage(dAtA, i, uint64(m.DbType))
+		i--
+		dAtA[i] = 0x40
+	}
 	if m.Ex != nil {
 		{
 			size, err := m.Ex.MarshalToSizedBuffer(dAtA[:i])
@@ -893,20 +1356,34 @@ func (m *DBCommandResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			i = encodeVarintMessage(dAtA, i, uint64(size))
 		}
 		i--
-		dAtA[i] = 0x2a
-	}
-	if len(m.ErrMsg) > 0 {
-		i -= len(m.ErrMsg)
-		copy(dAtA[i:], m.ErrMsg)
-		i = encodeVarintMessage(dAtA, i, uint64(len(m.ErrMsg)))
-		i--
-		dAtA[i] = 0x22
+		dAtA[i] = 0x3a
 	}
 	if len(m.Data) > 0 {
 		i -= len(m.Data)
 		copy(dAtA[i:], m.Data)
 		i = encodeVarintMessage(dAtA, i, uint64(len(m.Data)))
 		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.Filter) > 0 {
+		i -= len(m.Filter)
+		copy(dAtA[i:], m.Filter)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Filter)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if len(m.Collection) > 0 {
+		i -= len(m.Collection)
+		copy(dAtA[i:], m.Collection)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Collection)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.Database) > 0 {
+		i -= len(m.Database)
+		copy(dAtA[i:], m.Database)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Database)))
+		i--
 		dAtA[i] = 0x1a
 	}
 	if m.EntityId != 0 {
@@ -922,7 +1399,7 @@ func (m *DBCommandResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func (m *GameEntityRpc) Marshal() (dAtA []byte, err error) {
+func (m *DBCommandResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -932,22 +1409,107 @@ func (m *GameEntityRpc) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *GameEntityRpc) MarshalTo(dAtA []byte) (int, error) {
+func (m *DBCommandResponse) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *GameEntityRpc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *DBCommandResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if m.FromServer {
-		i--
-		if m.FromServer {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
+	if m.Ex != nil {
+		{
+			size, err := m.Ex.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMessage(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x2a
+	}
+	if len(m.ErrMsg) > 0 {
+		i -= len(m.ErrMsg)
+		copy(dAtA[i:], m.ErrMsg)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.ErrMsg)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.Data) > 0 {
+		i -= len(m.Data)
+		copy(dAtA[i:], m.Data)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Data)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.EntityId != 0 {
+		i = encodeVarintMessage(dAtA, i, uint64(m.EntityId))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.TaskType != 0 {
+		i = encodeVarintMessage(dAtA, i, uint64(m.TaskType))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GameEntityRpc) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GameEntityRpc) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GameEntityRpc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.TraceId) > 0 {
+		i -= len(m.TraceId)
+		copy(dAtA[i:], m.TraceId)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.TraceId)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.ReplyTo) > 0 {
+		i -= len(m.ReplyTo)
+		copy(dAtA[i:], m.ReplyTo)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.ReplyTo)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Ex != nil {
+		{
+			size, err := m.Ex.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMessage(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.FromServer {
+		i--
+		if m.FromServer {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
 		}
 		i--
 		dAtA[i] = 0x18
@@ -989,6 +1551,16 @@ func (m *SayHello) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.IsGame {
+		i--
+		if m.IsGame {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
 	if m.Inner {
 		i--
 		if m.Inner {
@@ -1029,6 +1601,88 @@ func (m *GameRouterRpc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.TraceId) > 0 {
+		i -= len(m.TraceId)
+		copy(dAtA[i:], m.TraceId)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.TraceId)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if len(m.Source) > 0 {
+		i -= len(m.Source)
+		copy(dAtA[i:], m.Source)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Source)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Ex != nil {
+		{
+			size, err := m.Ex.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMessage(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Data) > 0 {
+		i -= len(m.Data)
+		copy(dAtA[i:], m.Data)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Data)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Target) > 0 {
+		i -= len(m.Target)
+		copy(dAtA[i:], m.Target)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Target)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *EntityRpcResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *EntityRpcResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *EntityRpcResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Ex != nil {
+		{
+			size, err := m.Ex.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMessage(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.ErrMsg) > 0 {
+		i -= len(m.ErrMsg)
+		copy(dAtA[i:], m.ErrMsg)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.ErrMsg)))
+		i--
+		dAtA[i] = 0x1a
+	}
 	if len(m.Data) > 0 {
 		i -= len(m.Data)
 		copy(dAtA[i:], m.Data)
@@ -1169,6 +1823,15 @@ func (m *ServerError) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Args) > 0 {
+		for iNdEx := len(m.Args) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Args[iNdEx])
+			copy(dAtA[i:], m.Args[iNdEx])
+			i = encodeVarintMessage(dAtA, i, uint64(len(m.Args[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
 	if len(m.ErrMsg) > 0 {
 		i -= len(m.ErrMsg)
 		copy(dAtA[i:], m.ErrMsg)
@@ -1259,159 +1922,327 @@ func (m *SetServerTimeOffset) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintMessage(dAtA []byte, offset int, v uint64) int {
-	offset -= sovMessage(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *MigrateEntityRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return dAtA[:n], nil
 }
-func (m *ExtraInfo) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	l = len(m.Uuid)
-	if l > 0 {
-		n += 1 + l + sovMessage(uint64(l))
-	}
-	return n
+
+func (m *MigrateEntityRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *DBCommandRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+func (m *MigrateEntityRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.TaskType != 0 {
-		n += 1 + sovMessage(uint64(m.TaskType))
-	}
-	if m.EntityId != 0 {
-		n += 1 + sovMessage(uint64(m.EntityId))
+	if m.Ex != nil {
+		{
+			size, err := m.Ex.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMessage(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x2a
 	}
-	l = len(m.Database)
-	if l > 0 {
-		n += 1 + l + sovMessage(uint64(l))
+	if len(m.Data) > 0 {
+		i -= len(m.Data)
+		copy(dAtA[i:], m.Data)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Data)))
+		i--
+		dAtA[i] = 0x22
 	}
-	l = len(m.Collection)
-	if l > 0 {
-		n += 1 + l + sovMessage(uint64(l))
+	if len(m.Target) > 0 {
+		i -= len(m.Target)
+		copy(dAtA[i:], m.Target)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Target)))
+		i--
+		dAtA[i] = 0x1a
 	}
-	l = len(m.Filter)
-	if l > 0 {
-		n += 1 + l + sovMessage(uint64(l))
+	if len(m.Source) > 0 {
+		i -= len(m.Source)
+		copy(dAtA[i:], m.Source)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Source)))
+		i--
+		dAtA[i] = 0x12
 	}
-	l = len(m.Data)
-	if l > 0 {
-		n += 1 + l + sovMessage(uint64(l))
+	if m.EntityId != 0 {
+		i = encodeVarintMessage(dAtA, i, uint64(m.EntityId))
+		i--
+		dAtA[i] = 0x8
 	}
-	if m.Ex != nil {
-		l = m.Ex.Size()
-		n += 1 + l + sovMessage(uint64(l))
+	return len(dAtA) - i, nil
+}
+
+func (m *MigrateEntityResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	if m.DbType != 0 {
-		n += 1 + sovMessage(uint64(m.DbType))
+	return dAtA[:n], nil
+}
+
+func (m *MigrateEntityResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MigrateEntityResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Ex != nil {
+		{
+			size, err := m.Ex.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMessage(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x2a
 	}
-	return n
+	if len(m.ErrMsg) > 0 {
+		i -= len(m.ErrMsg)
+		copy(dAtA[i:], m.ErrMsg)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.ErrMsg)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.Target) > 0 {
+		i -= len(m.Target)
+		copy(dAtA[i:], m.Target)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Target)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Source) > 0 {
+		i -= len(m.Source)
+		copy(dAtA[i:], m.Source)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Source)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.EntityId != 0 {
+		i = encodeVarintMessage(dAtA, i, uint64(m.EntityId))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
 }
 
-func (m *DBCommandResponse) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *MigrateEntityAbort) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *MigrateEntityAbort) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MigrateEntityAbort) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.TaskType != 0 {
-		n += 1 + sovMessage(uint64(m.TaskType))
+	if m.Ex != nil {
+		{
+			size, err := m.Ex.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMessage(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x2a
 	}
-	if m.EntityId != 0 {
-		n += 1 + sovMessage(uint64(m.EntityId))
+	if len(m.MigrateUuid) > 0 {
+		i -= len(m.MigrateUuid)
+		copy(dAtA[i:], m.MigrateUuid)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.MigrateUuid)))
+		i--
+		dAtA[i] = 0x22
 	}
-	l = len(m.Data)
-	if l > 0 {
-		n += 1 + l + sovMessage(uint64(l))
+	if len(m.Target) > 0 {
+		i -= len(m.Target)
+		copy(dAtA[i:], m.Target)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Target)))
+		i--
+		dAtA[i] = 0x1a
 	}
-	l = len(m.ErrMsg)
-	if l > 0 {
-		n += 1 + l + sovMessage(uint64(l))
+	if len(m.Source) > 0 {
+		i -= len(m.Source)
+		copy(dAtA[i:], m.Source)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Source)))
+		i--
+		dAtA[i] = 0x12
 	}
-	if m.Ex != nil {
-		l = m.Ex.Size()
-		n += 1 + l + sovMessage(uint64(l))
+	if m.EntityId != 0 {
+		i = encodeVarintMessage(dAtA, i, uint64(m.EntityId))
+		i--
+		dAtA[i] = 0x8
 	}
-	return n
+	return len(dAtA) - i, nil
 }
 
-func (m *GameEntityRpc) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *ForwardGameMessage) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ForwardGameMessage) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ForwardGameMessage) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Data)
-	if l > 0 {
-		n += 1 + l + sovMessage(uint64(l))
+	if len(m.Data) > 0 {
+		i -= len(m.Data)
+		copy(dAtA[i:], m.Data)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Data)))
+		i--
+		dAtA[i] = 0x22
 	}
-	l = len(m.Source)
-	if l > 0 {
-		n += 1 + l + sovMessage(uint64(l))
+	if m.ClientId != 0 {
+		i = encodeVarintMessage(dAtA, i, uint64(m.ClientId))
+		i--
+		dAtA[i] = 0x18
 	}
-	if m.FromServer {
-		n += 2
+	if m.MsgType != 0 {
+		i = encodeVarintMessage(dAtA, i, uint64(m.MsgType))
+		i--
+		dAtA[i] = 0x10
 	}
-	return n
+	if len(m.Target) > 0 {
+		i -= len(m.Target)
+		copy(dAtA[i:], m.Target)
+		i = encodeVarintMessage(dAtA, i, uint64(len(m.Target)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
 }
 
-func (m *SayHello) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *ResumeClient) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ResumeClient) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ResumeClient) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	l = len(m.ServiceName)
-	if l > 0 {
-		n += 1 + l + sovMessage(uint64(l))
+	if m.Resync {
+		i--
+		if m.Resync {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x10
 	}
-	if m.Inner {
-		n += 2
+	if m.OldClientId != 0 {
+		i = encodeVarintMessage(dAtA, i, uint64(m.OldClientId))
+		i--
+		dAtA[i] = 0x8
 	}
-	return n
+	return len(dAtA) - i, nil
 }
 
-func (m *GameRouterRpc) Size() (n int) {
+func encodeVarintMessage(dAtA []byte, offset int, v uint64) int {
+	offset -= sovMessage(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *ExtraInfo) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	l = len(m.Target)
+	l = len(m.Uuid)
 	if l > 0 {
 		n += 1 + l + sovMessage(uint64(l))
 	}
-	l = len(m.Data)
+	l = len(m.TraceId)
 	if l > 0 {
 		n += 1 + l + sovMessage(uint64(l))
 	}
 	return n
 }
 
-func (m *CreateEntityRequest) Size() (n int) {
+func (m *DBCommandRequest) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	l = len(m.EntityName)
+	if m.TaskType != 0 {
+		n += 1 + sovMessage(uint64(m.TaskType))
+	}
+	if m.EntityId != 0 {
+		n += 1 + sovMessage(uint64(m.EntityId))
+	}
+	l = len(m.Database)
 	if l > 0 {
 		n += 1 + l + sovMessage(uint64(l))
 	}
-	l = len(m.ServerName)
+	l = len(m.Collection)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.Filter)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.Data)
 	if l > 0 {
 		n += 1 + l + sovMessage(uint64(l))
 	}
@@ -1419,23 +2250,33 @@ func (m *CreateEntityRequest) Size() (n int) {
 		l = m.Ex.Size()
 		n += 1 + l + sovMessage(uint64(l))
 	}
+	if m.DbType != 0 {
+		n += 1 + sovMessage(uint64(m.DbType))
+	}
+	l = len(m.TraceId)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
 	return n
 }
 
-func (m *CreateEntityResponse) Size() (n int) {
+func (m *DBCommandResponse) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
+	if m.TaskType != 0 {
+		n += 1 + sovMessage(uint64(m.TaskType))
+	}
 	if m.EntityId != 0 {
 		n += 1 + sovMessage(uint64(m.EntityId))
 	}
-	l = len(m.ErrMsg)
+	l = len(m.Data)
 	if l > 0 {
 		n += 1 + l + sovMessage(uint64(l))
 	}
-	l = len(m.ServerName)
+	l = len(m.ErrMsg)
 	if l > 0 {
 		n += 1 + l + sovMessage(uint64(l))
 	}
@@ -1446,16 +2287,172 @@ func (m *CreateEntityResponse) Size() (n int) {
 	return n
 }
 
-func (m *ServerError) Size() (n int) {
+func (m *GameEntityRpc) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	l = len(m.ErrMsg)
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.Source)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	if m.FromServer {
+		n += 2
+	}
+	if m.Ex != nil {
+		l = m.Ex.Size()
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.ReplyTo)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.TraceId)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	return n
+}
+
+func (m *SayHello) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.ServiceName)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	if m.Inner {
+		n += 2
+	}
+	if m.IsGame {
+		n += 2
+	}
+	return n
+}
+
+func (m *GameRouterRpc) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Target)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	if m.Ex != nil {
+		l = m.Ex.Size()
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.Source)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.TraceId)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	return n
+}
+
+func (m *EntityRpcResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Target)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.ErrMsg)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	if m.Ex != nil {
+		l = m.Ex.Size()
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	return n
+}
+
+func (m *CreateEntityRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.EntityName)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.ServerName)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	if m.Ex != nil {
+		l = m.Ex.Size()
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	return n
+}
+
+func (m *CreateEntityResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.EntityId != 0 {
+		n += 1 + sovMessage(uint64(m.EntityId))
+	}
+	l = len(m.ErrMsg)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.ServerName)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	if m.Ex != nil {
+		l = m.Ex.Size()
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	return n
+}
+
+func (m *ServerError) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.ErrMsg)
 	if l > 0 {
 		n += 1 + l + sovMessage(uint64(l))
 	}
+	if len(m.Args) > 0 {
+		for _, s := range m.Args {
+			l = len(s)
+			n += 1 + l + sovMessage(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -1495,44 +2492,1271 @@ func (m *SetServerTimeOffset) Size() (n int) {
 	return n
 }
 
-func sovMessage(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
+func (m *MigrateEntityRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.EntityId != 0 {
+		n += 1 + sovMessage(uint64(m.EntityId))
+	}
+	l = len(m.Source)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.Target)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	if m.Ex != nil {
+		l = m.Ex.Size()
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	return n
 }
-func sozMessage(x uint64) (n int) {
-	return sovMessage(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+
+func (m *MigrateEntityResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.EntityId != 0 {
+		n += 1 + sovMessage(uint64(m.EntityId))
+	}
+	l = len(m.Source)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.Target)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.ErrMsg)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	if m.Ex != nil {
+		l = m.Ex.Size()
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	return n
 }
-func (m *ExtraInfo) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMessage
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
+
+func (m *MigrateEntityAbort) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.EntityId != 0 {
+		n += 1 + sovMessage(uint64(m.EntityId))
+	}
+	l = len(m.Source)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.Target)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	l = len(m.MigrateUuid)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	if m.Ex != nil {
+		l = m.Ex.Size()
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	return n
+}
+
+func (m *ForwardGameMessage) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Target)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	if m.MsgType != 0 {
+		n += 1 + sovMessage(uint64(m.MsgType))
+	}
+	if m.ClientId != 0 {
+		n += 1 + sovMessage(uint64(m.ClientId))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovMessage(uint64(l))
+	}
+	return n
+}
+
+func (m *ResumeClient) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.OldClientId != 0 {
+		n += 1 + sovMessage(uint64(m.OldClientId))
+	}
+	if m.Resync {
+		n += 2
+	}
+	return n
+}
+
+func sovMessage(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozMessage(x uint64) (n int) {
+	return sovMessage(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *ExtraInfo) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMessage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExtraInfo: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExtraInfo: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Uuid", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Uuid = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TraceId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TraceId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMessage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *DBCommandRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMessage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DBCommandRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DBCommandRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TaskType", wireType)
+			}
+			m.TaskType = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TaskType |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EntityId", wireType)
+			}
+			m.EntityId = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EntityId |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Database", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Database = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Collection", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Collection = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Filter", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Filter = append(m.Filter[:0], dAtA[iNdEx:postIndex]...)
+			if m.Filter == nil {
+				m.Filter = []byte{}
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ex", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Ex == nil {
+				m.Ex = &ExtraInfo{}
+			}
+			if err := m.Ex.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DbType", wireType)
+			}
+			m.DbType = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DbType |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TraceId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TraceId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMessage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *DBCommandResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMessage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DBCommandResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DBCommandResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TaskType", wireType)
+			}
+			m.TaskType = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TaskType |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EntityId", wireType)
+			}
+			m.EntityId = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EntityId |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ErrMsg", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ErrMsg = append(m.ErrMsg[:0], dAtA[iNdEx:postIndex]...)
+			if m.ErrMsg == nil {
+				m.ErrMsg = []byte{}
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ex", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Ex == nil {
+				m.Ex = &ExtraInfo{}
+			}
+			if err := m.Ex.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMessage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GameEntityRpc) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMessage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GameEntityRpc: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GameEntityRpc: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Source", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Source = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FromServer", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.FromServer = bool(v != 0)
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ex", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Ex == nil {
+				m.Ex = &ExtraInfo{}
+			}
+			if err := m.Ex.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReplyTo", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ReplyTo = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TraceId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TraceId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMessage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SayHello) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMessage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SayHello: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SayHello: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ServiceName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Inner", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Inner = bool(v != 0)
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsGame", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsGame = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMessage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GameRouterRpc) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMessage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GameRouterRpc: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GameRouterRpc: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Target", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Target = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Ex", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Ex == nil {
+				m.Ex = &ExtraInfo{}
+			}
+			if err := m.Ex.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Source", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: ExtraInfo: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ExtraInfo: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+			m.Source = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Uuid", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field TraceId", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1560,7 +3784,7 @@ func (m *ExtraInfo) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Uuid = string(dAtA[iNdEx:postIndex])
+			m.TraceId = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -1583,7 +3807,7 @@ func (m *ExtraInfo) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *DBCommandRequest) Unmarshal(dAtA []byte) error {
+func (m *EntityRpcResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1606,17 +3830,17 @@ func (m *DBCommandRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: DBCommandRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: EntityRpcResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: DBCommandRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: EntityRpcResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TaskType", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Target", wireType)
 			}
-			m.TaskType = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMessage
@@ -1626,16 +3850,29 @@ func (m *DBCommandRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.TaskType |= uint32(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Target = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EntityId", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
 			}
-			m.EntityId = 0
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMessage
@@ -1645,14 +3882,29 @@ func (m *DBCommandRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.EntityId |= int64(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
 		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Database", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ErrMsg", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1680,13 +3932,13 @@ func (m *DBCommandRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Database = string(dAtA[iNdEx:postIndex])
+			m.ErrMsg = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Collection", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Ex", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMessage
@@ -1696,29 +3948,83 @@ func (m *DBCommandRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthMessage
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthMessage
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Collection = string(dAtA[iNdEx:postIndex])
+			if m.Ex == nil {
+				m.Ex = &ExtraInfo{}
+			}
+			if err := m.Ex.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
-		case 5:
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMessage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CreateEntityRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMessage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CreateEntityRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CreateEntityRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Filter", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field EntityName", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMessage
@@ -1728,31 +4034,29 @@ func (m *DBCommandRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthMessage
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthMessage
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Filter = append(m.Filter[:0], dAtA[iNdEx:postIndex]...)
-			if m.Filter == nil {
-				m.Filter = []byte{}
-			}
+			m.EntityName = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 6:
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ServerName", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMessage
@@ -1762,27 +4066,25 @@ func (m *DBCommandRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthMessage
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthMessage
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
-			if m.Data == nil {
-				m.Data = []byte{}
-			}
+			m.ServerName = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 7:
+		case 3:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Ex", wireType)
 			}
@@ -1818,25 +4120,6 @@ func (m *DBCommandRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
-		case 8:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field DbType", wireType)
-			}
-			m.DbType = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMessage
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.DbType |= uint32(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMessage(dAtA[iNdEx:])
@@ -1858,7 +4141,7 @@ func (m *DBCommandRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *DBCommandResponse) Unmarshal(dAtA []byte) error {
+func (m *CreateEntityResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1881,32 +4164,13 @@ func (m *DBCommandResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: DBCommandResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: CreateEntityResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: DBCommandResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: CreateEntityResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field TaskType", wireType)
-			}
-			m.TaskType = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowMessage
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.TaskType |= uint32(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 2:
 			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field EntityId", wireType)
 			}
@@ -1925,11 +4189,11 @@ func (m *DBCommandResponse) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-		case 3:
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ErrMsg", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMessage
@@ -1939,31 +4203,29 @@ func (m *DBCommandResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthMessage
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthMessage
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
-			if m.Data == nil {
-				m.Data = []byte{}
-			}
+			m.ErrMsg = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 4:
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ErrMsg", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ServerName", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMessage
@@ -1973,27 +4235,25 @@ func (m *DBCommandResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthMessage
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthMessage
 			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.ErrMsg = append(m.ErrMsg[:0], dAtA[iNdEx:postIndex]...)
-			if m.ErrMsg == nil {
-				m.ErrMsg = []byte{}
-			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ServerName = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 5:
+		case 4:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Ex", wireType)
 			}
@@ -2050,7 +4310,7 @@ func (m *DBCommandResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GameEntityRpc) Unmarshal(dAtA []byte) error {
+func (m *ServerError) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2073,17 +4333,17 @@ func (m *GameEntityRpc) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GameEntityRpc: wiretype end group for non-group")
+			return fmt.Errorf("proto: ServerError: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GameEntityRpc: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ServerError: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ErrMsg", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMessage
@@ -2093,29 +4353,27 @@ func (m *GameEntityRpc) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthMessage
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthMessage
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
-			if m.Data == nil {
-				m.Data = []byte{}
-			}
+			m.ErrMsg = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Source", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Args", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2143,11 +4401,99 @@ func (m *GameEntityRpc) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Source = string(dAtA[iNdEx:postIndex])
+			m.Args = append(m.Args, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMessage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ClientBindEntity) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMessage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ClientBindEntity: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ClientBindEntity: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EntityId", wireType)
+			}
+			m.EntityId = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EntityId |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ClientId", wireType)
+			}
+			m.ClientId = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ClientId |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FromServer", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Unbind", wireType)
 			}
 			var v int
 			for shift := uint(0); ; shift += 7 {
@@ -2164,7 +4510,7 @@ func (m *GameEntityRpc) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-			m.FromServer = bool(v != 0)
+			m.Unbind = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMessage(dAtA[iNdEx:])
@@ -2186,7 +4532,7 @@ func (m *GameEntityRpc) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SayHello) Unmarshal(dAtA []byte) error {
+func (m *SetServerTimeOffset) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2209,17 +4555,17 @@ func (m *SayHello) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SayHello: wiretype end group for non-group")
+			return fmt.Errorf("proto: SetServerTimeOffset: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SayHello: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SetServerTimeOffset: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ServiceName", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
 			}
-			var stringLen uint64
+			m.Offset = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMessage
@@ -2229,29 +4575,16 @@ func (m *SayHello) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.Offset |= int32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthMessage
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMessage
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.ServiceName = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Inner", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Targets", wireType)
 			}
-			var v int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMessage
@@ -2261,12 +4594,24 @@ func (m *SayHello) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.Inner = bool(v != 0)
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Targets = append(m.Targets, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMessage(dAtA[iNdEx:])
@@ -2288,7 +4633,7 @@ func (m *SayHello) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GameRouterRpc) Unmarshal(dAtA []byte) error {
+func (m *MigrateEntityRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2311,13 +4656,64 @@ func (m *GameRouterRpc) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GameRouterRpc: wiretype end group for non-group")
+			return fmt.Errorf("proto: MigrateEntityRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GameRouterRpc: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MigrateEntityRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EntityId", wireType)
+			}
+			m.EntityId = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EntityId |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Source", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Source = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Target", wireType)
 			}
@@ -2331,29 +4727,63 @@ func (m *GameRouterRpc) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Target = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthMessage
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + byteLen
 			if postIndex < 0 {
 				return ErrInvalidLengthMessage
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Target = string(dAtA[iNdEx:postIndex])
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
 			iNdEx = postIndex
-		case 2:
+		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Ex", wireType)
 			}
-			var byteLen int
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMessage
@@ -2363,24 +4793,26 @@ func (m *GameRouterRpc) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthMessage
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthMessage
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
-			if m.Data == nil {
-				m.Data = []byte{}
+			if m.Ex == nil {
+				m.Ex = &ExtraInfo{}
+			}
+			if err := m.Ex.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
 			iNdEx = postIndex
 		default:
@@ -2404,7 +4836,7 @@ func (m *GameRouterRpc) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *CreateEntityRequest) Unmarshal(dAtA []byte) error {
+func (m *MigrateEntityResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2427,15 +4859,34 @@ func (m *CreateEntityRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: CreateEntityRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: MigrateEntityResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: CreateEntityRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MigrateEntityResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EntityId", wireType)
+			}
+			m.EntityId = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EntityId |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EntityName", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Source", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2463,11 +4914,11 @@ func (m *CreateEntityRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.EntityName = string(dAtA[iNdEx:postIndex])
+			m.Source = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 2:
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ServerName", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Target", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2495,9 +4946,41 @@ func (m *CreateEntityRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.ServerName = string(dAtA[iNdEx:postIndex])
+			m.Target = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 3:
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ErrMsg", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ErrMsg = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Ex", wireType)
 			}
@@ -2554,7 +5037,7 @@ func (m *CreateEntityRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *CreateEntityResponse) Unmarshal(dAtA []byte) error {
+func (m *MigrateEntityAbort) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2577,10 +5060,10 @@ func (m *CreateEntityResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: CreateEntityResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: MigrateEntityAbort: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: CreateEntityResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MigrateEntityAbort: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -2604,7 +5087,7 @@ func (m *CreateEntityResponse) Unmarshal(dAtA []byte) error {
 			}
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ErrMsg", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Source", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2632,11 +5115,11 @@ func (m *CreateEntityResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.ErrMsg = string(dAtA[iNdEx:postIndex])
+			m.Source = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ServerName", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Target", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2664,9 +5147,41 @@ func (m *CreateEntityResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.ServerName = string(dAtA[iNdEx:postIndex])
+			m.Target = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MigrateUuid", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMessage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.MigrateUuid = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Ex", wireType)
 			}
@@ -2723,7 +5238,7 @@ func (m *CreateEntityResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ServerError) Unmarshal(dAtA []byte) error {
+func (m *ForwardGameMessage) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2746,15 +5261,15 @@ func (m *ServerError) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ServerError: wiretype end group for non-group")
+			return fmt.Errorf("proto: ForwardGameMessage: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ServerError: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ForwardGameMessage: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ErrMsg", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Target", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2782,63 +5297,13 @@ func (m *ServerError) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.ErrMsg = string(dAtA[iNdEx:postIndex])
+			m.Target = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipMessage(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if (skippy < 0) || (iNdEx+skippy) < 0 {
-				return ErrInvalidLengthMessage
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *ClientBindEntity) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowMessage
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: ClientBindEntity: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ClientBindEntity: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EntityId", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field MsgType", wireType)
 			}
-			m.EntityId = 0
+			m.MsgType = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMessage
@@ -2848,12 +5313,12 @@ func (m *ClientBindEntity) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.EntityId |= int64(b&0x7F) << shift
+				m.MsgType |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
+		case 3:
 			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field ClientId", wireType)
 			}
@@ -2872,11 +5337,11 @@ func (m *ClientBindEntity) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Unbind", wireType)
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
 			}
-			var v int
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMessage
@@ -2886,12 +5351,26 @@ func (m *ClientBindEntity) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.Unbind = bool(v != 0)
+			if byteLen < 0 {
+				return ErrInvalidLengthMessage
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthMessage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMessage(dAtA[iNdEx:])
@@ -2913,7 +5392,7 @@ func (m *ClientBindEntity) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SetServerTimeOffset) Unmarshal(dAtA []byte) error {
+func (m *ResumeClient) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2936,17 +5415,17 @@ func (m *SetServerTimeOffset) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SetServerTimeOffset: wiretype end group for non-group")
+			return fmt.Errorf("proto: ResumeClient: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SetServerTimeOffset: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ResumeClient: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field OldClientId", wireType)
 			}
-			m.Offset = 0
+			m.OldClientId = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMessage
@@ -2956,16 +5435,16 @@ func (m *SetServerTimeOffset) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Offset |= int32(b&0x7F) << shift
+				m.OldClientId |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Targets", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Resync", wireType)
 			}
-			var stringLen uint64
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowMessage
@@ -2975,24 +5454,12 @@ func (m *SetServerTimeOffset) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthMessage
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthMessage
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Targets = append(m.Targets, string(dAtA[iNdEx:postIndex]))
-			iNdEx = postIndex
+			m.Resync = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMessage(dAtA[iNdEx:])
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/ecdh"
 	"go.uber.org/atomic"
 	"os"
 	"rpg/engine/engine"
@@ -20,10 +21,17 @@ type client struct {
 	conn                  *engine.TcpClient
 	lastRecvHeartbeatTime int64
 	id                    int32
+	handshakeKey          *ecdh.PrivateKey      //握手中的临时私钥
+	crypto                *engine.CryptoSession //握手完成后的加密会话
+	typed                 bool                  //是否已协商为typed编码
 }
 
 func (m *client) init() {
-	m.conn = engine.NewTcpClient(engine.WithTcpClientCodec(m), engine.WithTcpClientHandle(m))
+	opts := []engine.Option{engine.WithTcpClientCodec(m), engine.WithTcpClientHandle(m)}
+	if engine.GetConfig().ServerConfig().Kcp != "" {
+		opts = append(opts, engine.WithTcpClientDialer(engine.DialKcp))
+	}
+	m.conn = engine.NewTcpClient(opts...)
 }
 
 func (m *client) Connect(addr string) {
@@ -36,11 +44,17 @@ func (m *client) HandleMainTick() {
 }
 
 func (m *client) Encode(data []byte) ([]byte, error) {
-	return engine.GetProtocol().Encode(data)
+	if m.typed {
+		var err error
+		if data, err = genTypedMessage(data); err != nil {
+			return nil, err
+		}
+	}
+	return engine.GetProtocol().EncodeWithSession(data, m.crypto)
 }
 
 func (m *client) Decode(data []byte) (int, []byte, error) {
-	return engine.GetProtocol().Decode(data)
+	return engine.GetProtocol().DecodeWithSession(data, m.crypto)
 }
 
 func (m *client) OnConnect(conn *engine.TcpClient) {
@@ -50,6 +64,68 @@ func (m *client) OnConnect(conn *engine.TcpClient) {
 	m.id = globalId.Load()
 	allClients[m.id] = m
 
+	if cipher := engine.GetConfig().ServerConfig().Encrypt; cipher != "" {
+		m.sendHandshake(cipher)
+		return
+	}
+	m.negotiate()
+}
+
+// sendHandshake 发起加密握手, 握手完成后再登录
+func (m *client) sendHandshake(cipher string) {
+	key, err := engine.NewCryptoKey()
+	if err != nil {
+		log.Errorf("generate handshake key error: %s", err.Error())
+		m.conn.Disconnect()
+		return
+	}
+	m.handshakeKey = key
+	_, _ = m.conn.Send(genHandshakeMessage(cipher, key.PublicKey().Bytes()))
+}
+
+// onHandshake 校验gate身份私钥对临时公钥的签名后建立加密会话, 校验失败说明连接可能被中间人劫持
+func (m *client) onHandshake(cipher string, serverPub, sig []byte) {
+	if m.handshakeKey == nil {
+		log.Warn("receive handshake response without handshake")
+		return
+	}
+	//配置加载时已校验
+	identity, _ := engine.ParseCryptoIdentityPub(engine.GetConfig().ServerConfig().EncryptPub)
+	if !engine.VerifyHandshake(identity, cipher, m.handshakeKey.PublicKey().Bytes(), serverPub, sig) {
+		m.handshakeKey = nil
+		log.Error("handshake signature verify failed")
+		m.conn.Disconnect()
+		return
+	}
+	crypto, err := engine.NewCryptoSession(cipher, m.handshakeKey, serverPub, false)
+	m.handshakeKey = nil
+	if err != nil {
+		log.Errorf("handshake error: %s", err.Error())
+		m.conn.Disconnect()
+		return
+	}
+	m.crypto = crypto
+	log.Infof("handshake success, cipher: %s", cipher)
+	m.negotiate()
+}
+
+// negotiate 配置了typed编码时先协商编码, 协商完成后再登录
+func (m *client) negotiate() {
+	if engine.GetConfig().ServerConfig().Codec != engine.ClientCodecTyped {
+		m.login()
+		return
+	}
+	_, _ = m.conn.Send(genCodecMessage(engine.ClientCodecTyped))
+}
+
+func (m *client) onCodec(codec string) {
+	m.typed = codec == engine.ClientCodecTyped
+	log.Infof("use codec: %s", codec)
+	m.login()
+}
+
+func (m *client) login() {
+	conn := m.conn
 	engine.GetTimer().AddTimer(time.Second, time.Second, func(_ ...interface{}) {
 		entityId := engine.EntityIdType(0)
 		if myself != nil {
@@ -76,7 +152,13 @@ func (m *client) OnDisconnect(conn *engine.TcpClient) {
 }
 
 func (m *client) OnMessage(_ *engine.TcpClient, buf []byte) error {
-	r, err := engine.GetProtocol().UnMarshal(buf)
+	var r map[string]interface{}
+	var err error
+	if m.typed {
+		_, r, err = engine.UnMarshalTyped(buf, engine.GetRobotManager().EntityName, true)
+	} else {
+		r, err = engine.GetProtocol().UnMarshal(buf)
+	}
 	if err != nil {
 		return err
 	}
@@ -21,8 +21,13 @@ func main() {
 
 	allClients = make(map[int32]*client)
 
+	//配置了kcp地址时通过kcp连接gate
+	addr := gateAddr
+	if kcpAddr := engine.GetConfig().ServerConfig().Kcp; kcpAddr != "" {
+		addr = kcpAddr
+	}
 	c := NewClient()
-	c.Connect(gateAddr)
+	c.Connect(addr)
 
 	for {
 		select {
@@ -7,12 +7,46 @@ import (
 
 func handlerCreateEntity(c *client, id engine.EntityIdType, args []interface{}) {
 	entityName := args[0].(string)
-	entity, err := engine.GetRobotManager().CreateEntity(id, entityName, map[string]interface{}{}, c.conn)
+	props := map[string]interface{}{}
+	if len(args) > 1 {
+		if v, ok := args[1].(map[string]interface{}); ok {
+			props = v
+		}
+	}
+	//第3个参数标记是否是自己的entity, 否则是aoi内其他玩家的entity
+	own := true
+	if len(args) > 2 {
+		if v, ok := args[2].(bool); ok {
+			own = v
+		}
+	}
+	entity, err := engine.GetRobotManager().CreateEntity(id, entityName, props, c.conn)
 	if err != nil {
 		log.Errorf("create [%s:%d] error: %s", entityName, id, err.Error())
-	} else {
+		return
+	}
+	if len(args) > 3 {
+		if versions, ok := args[3].(map[string]interface{}); ok {
+			entity.SetSyncTableVersions(versions)
+		}
+	}
+	if own {
 		log.Infof("create robot %s success", entity.String())
 		myself = entity
+	} else {
+		log.Debugf("create other %s success", entity.String())
+	}
+}
+
+func handlerDestroyEntity(id engine.EntityIdType) {
+	ent := engine.GetRobotManager().GetEntityById(id)
+	if ent == nil {
+		log.Debugf("handler destroy entity, entity %d not found", id)
+		return
+	}
+	engine.GetRobotManager().DestroyEntity(ent)
+	if myself == ent {
+		myself = nil
 	}
 }
 
@@ -36,7 +70,10 @@ func handlerEntityPropsUpdate(id engine.EntityIdType, args []interface{}) {
 		log.Debugf("handler entity props update, entity %d not found", id)
 		return
 	}
-	ent.OnServerSyncProp(args[0].(string), engine.InterfaceToLValue(args[1]))
+	//参数格式: [propName1, value1, propName2, value2, ...]
+	for i := 0; i+1 < len(args); i += 2 {
+		ent.OnServerSyncProp(args[i].(string), engine.InterfaceToLValue(args[i+1]))
+	}
 }
 
 func handlerEntityPropsPartUpdate(id engine.EntityIdType, args []interface{}) {
@@ -45,15 +82,58 @@ func handlerEntityPropsPartUpdate(id engine.EntityIdType, args []interface{}) {
 		log.Debugf("handler entity props update, entity %d not found", id)
 		return
 	}
-	ent.OnServerSyncPropPart(args[0].(string), engine.InterfaceToLValue(args[1]), engine.InterfaceToLValue(args[2]))
+	//参数格式: [propName1, version1, path1, value1, ...]
+	resync := make(map[string]bool)
+	for i := 0; i+3 < len(args); i += 4 {
+		name := args[i].(string)
+		if resync[name] {
+			continue
+		}
+		path, _ := args[i+2].([]interface{})
+		version := uint32(engine.InterfaceToInt(args[i+1]))
+		if !ent.OnServerSyncPropPart(name, version, engine.InterfaceToLValues(path), engine.InterfaceToLValue(args[i+3])) {
+			resync[name] = true
+		}
+	}
+	for name := range resync {
+		ent.RequestResync(name)
+	}
 }
 
 func handlerHeartbeat(c *client) {
 	c.lastRecvHeartbeatTime = time.Now().Unix()
 }
 
+func handlerHandshake(c *client, args []interface{}) {
+	if len(args) < 3 {
+		log.Warn("invalid handshake response")
+		return
+	}
+	cipher, _ := args[0].(string)
+	pub, _ := args[1].([]byte)
+	sig, _ := args[2].([]byte)
+	c.onHandshake(cipher, pub, sig)
+}
+
+func handlerCodec(c *client, args []interface{}) {
+	if len(args) < 1 {
+		log.Warn("invalid codec response")
+		return
+	}
+	codec, _ := args[0].(string)
+	c.onCodec(codec)
+}
+
 func handlerServerTips(_ *client, args []interface{}) {
 	msg := args[0].(string)
+	if msg == engine.ErrMsgDefVersionMismatch && len(args) > 1 {
+		log.Errorf("def version mismatch, local: %s, required: %v", engine.DefHash(), args[1])
+		return
+	}
+	if msg == engine.ErrMsgReconnectGate && len(args) > 1 {
+		log.Warnf("gate is draining, reconnect to: %v", args[1])
+		return
+	}
 	log.Info(msg)
 }
 
@@ -78,7 +158,13 @@ func dispatchMessage(c *client, data map[string]interface{}) {
 		handlerEntityPropsUpdate(entityId, args)
 	case engine.ClientMsgTypePropSyncUpdate:
 		handlerEntityPropsPartUpdate(entityId, args)
+	case engine.ClientMsgTypeDestroyEntity:
+		handlerDestroyEntity(entityId)
 	case engine.ClientMsgTypeTips:
 		handlerServerTips(c, args)
+	case engine.ClientMsgTypeHandshake:
+		handlerHandshake(c, args)
+	case engine.ClientMsgTypeCodec:
+		handlerCodec(c, args)
 	}
 }
@@ -19,7 +19,8 @@ func messageWithHead(head, body []byte) []byte {
 
 func genLoginMessage(args []interface{}) []byte {
 	data := map[string]interface{}{
-		engine.ClientMsgDataFieldArgs: args,
+		engine.ClientMsgDataFieldArgs:    args,
+		engine.ClientMsgDataFieldVersion: engine.DefHash(),
 	}
 	if buf, err := engine.GetProtocol().Marshal(data); err != nil {
 		log.Errorf("genLoginMessage Marshal error: %s", err.Error())
@@ -39,3 +40,37 @@ func genHeartbeatMessage(entityId engine.EntityIdType) []byte {
 		return messageWithHead([]byte{engine.ClientMsgTypeHeartBeat}, buf)
 	}
 }
+
+func genHandshakeMessage(cipher string, pub []byte) []byte {
+	data := map[string]interface{}{
+		engine.ClientMsgDataFieldArgs: []interface{}{cipher, pub},
+	}
+	if buf, err := engine.GetProtocol().Marshal(data); err != nil {
+		log.Errorf("genHandshakeMessage Marshal error: %s", err.Error())
+		return nil
+	} else {
+		return messageWithHead([]byte{engine.ClientMsgTypeHandshake}, buf)
+	}
+}
+
+func genCodecMessage(codec string) []byte {
+	data := map[string]interface{}{
+		engine.ClientMsgDataFieldArgs: []interface{}{codec},
+	}
+	if buf, err := engine.GetProtocol().Marshal(data); err != nil {
+		log.Errorf("genCodecMessage Marshal error: %s", err.Error())
+		return nil
+	} else {
+		return messageWithHead([]byte{engine.ClientMsgTypeCodec}, buf)
+	}
+}
+
+// genTypedMessage [消息类型][msgpack字典]格式的消息转换为typed编码
+func genTypedMessage(data []byte) ([]byte, error) {
+	r, err := engine.GetProtocol().UnMarshal(data[1:])
+	if err != nil {
+		return nil, err
+	}
+	entityId := engine.EntityIdType(engine.InterfaceToInt(r[engine.ClientMsgDataFieldEntityID]))
+	return engine.MarshalTyped(data[0], r, engine.GetRobotManager().EntityName(entityId), false)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"rpg/engine/engine"
+	"strings"
+)
+
+var luaReserved = map[string]bool{
+	"and": true, "break": true, "do": true, "else": true, "elseif": true, "end": true, "false": true,
+	"for": true, "function": true, "goto": true, "if": true, "in": true, "local": true, "nil": true,
+	"not": true, "or": true, "repeat": true, "return": true, "then": true, "true": true, "until": true, "while": true,
+}
+
+// luaType 类型的EmmyLua注解
+func luaType(t *engine.TypeDesc) string {
+	switch t.Name {
+	case "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64":
+		return "integer"
+	case "float":
+		return "number"
+	case "string":
+		return "string"
+	case "bool":
+		return "boolean"
+	case "array":
+		return luaType(t.Value) + "[]"
+	case "map":
+		return "table<" + luaType(t.Key) + ", " + luaType(t.Value) + ">"
+	case "struct":
+		fields := make([]string, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			fields = append(fields, f.Name+": "+luaType(f.Type))
+		}
+		return "{ " + strings.Join(fields, ", ") + " }"
+	}
+	return "table"
+}
+
+func genLua(entities []*engine.EntityDesc) string {
+	b := new(strings.Builder)
+	b.WriteString("-- Code generated by sdkgen from entity defs. DO NOT EDIT.\n\n")
+	b.WriteString("local unpack = table.unpack or unpack\n\n")
+	b.WriteString("local M = {}\n\n")
+	b.WriteString("-- 调用entity函数的消息类型与消息字段\n")
+	fmt.Fprintf(b, "M.MSG_TYPE_ENTITY_RPC = %d\n", engine.ClientMsgTypeEntityRpc)
+	fmt.Fprintf(b, "M.FIELD_ENTITY_ID = %q\n", engine.ClientMsgDataFieldEntityID)
+	fmt.Fprintf(b, "M.FIELD_ARGS = %q\n", engine.ClientMsgDataFieldArgs)
+	b.WriteString("-- def版本号, 登录消息中以FIELD_VERSION字段发送给服务端校验\n")
+	fmt.Fprintf(b, "M.FIELD_VERSION = %q\n", engine.ClientMsgDataFieldVersion)
+	fmt.Fprintf(b, "M.DEF_VERSION = %q\n", engine.DefHash())
+
+	for _, ent := range entities {
+		b.WriteString("\n")
+		fmt.Fprintf(b, "---@class %s\n", ent.Name)
+		fmt.Fprintf(b, "---@field id integer\n")
+		for _, prop := range ent.Props {
+			fmt.Fprintf(b, "---@field %s %s @%s\n", prop.Name, luaType(prop.Type), syncFlagsComment(prop))
+		}
+		fmt.Fprintf(b, "M.%s = {\n", ent.Name)
+		fmt.Fprintf(b, "    name = %q,\n", ent.Name)
+		fmt.Fprintf(b, "    has_client = %v,\n", ent.HasClient)
+		b.WriteString("    props = {\n")
+		for _, prop := range ent.Props {
+			fmt.Fprintf(b, "        %s = { type = %q, own_client = %v, other_clients = %v },\n", prop.Name, prop.Type.Name, prop.OwnClient, prop.OtherClients)
+		}
+		b.WriteString("    },\n")
+		b.WriteString("    -- 客户端函数, mask name -> 函数名\n")
+		b.WriteString("    client_methods = {\n")
+		for _, method := range ent.ClientMethods {
+			fmt.Fprintf(b, "        [%q] = %q,\n", method.MaskName, method.Name)
+		}
+		b.WriteString("    },\n")
+		b.WriteString("}\n\n")
+
+		b.WriteString("-- 服务端函数, send(entity_id, mask_name, args)由客户端网络层实现\n")
+		fmt.Fprintf(b, "function M.%s.new_server(entity_id, send)\n", ent.Name)
+		b.WriteString("    local server = {}\n")
+		for _, method := range ent.ServerMethods {
+			names := argNames(method.Args, luaReserved)
+			for i, arg := range method.Args {
+				fmt.Fprintf(b, "    ---@param %s %s\n", names[i], luaType(arg.Type))
+			}
+			fmt.Fprintf(b, "    function server.%s(%s)\n", method.Name, strings.Join(names, ", "))
+			fmt.Fprintf(b, "        send(entity_id, %q, { %s })\n", method.MaskName, strings.Join(names, ", "))
+			b.WriteString("    end\n")
+		}
+		b.WriteString("    return server\n")
+		b.WriteString("end\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString("-- 分发服务端对客户端函数的调用, handler为实现了客户端函数的对象, 未找到函数时返回false\n")
+	b.WriteString("function M.dispatch(entity_name, handler, mask_name, args)\n")
+	b.WriteString("    local ent = M[entity_name]\n")
+	b.WriteString("    local name = ent and ent.client_methods and ent.client_methods[mask_name]\n")
+	b.WriteString("    if name == nil or handler[name] == nil then\n")
+	b.WriteString("        return false\n")
+	b.WriteString("    end\n")
+	b.WriteString("    handler[name](handler, unpack(args))\n")
+	b.WriteString("    return true\n")
+	b.WriteString("end\n\n")
+	b.WriteString("return M\n")
+	return b.String()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rpg/engine/engine"
+	"strings"
+)
+
+// 支持的语言及对应的生成函数与输出文件名
+var generators = map[string]struct {
+	gen      func(entities []*engine.EntityDesc) string
+	fileName string
+}{
+	"lua": {gen: genLua, fileName: "rpg_defs.lua"},
+	"ts":  {gen: genTypeScript, fileName: "rpg_defs.ts"},
+}
+
+// sdkgen 根据def文件生成客户端代码
+func main() {
+	path, out, langs := "", "", ""
+	flag.StringVar(&path, "path", "", "工作路径, def文件位于该路径下的defs目录")
+	flag.StringVar(&out, "out", ".", "输出目录")
+	flag.StringVar(&langs, "lang", "lua,ts", "生成的语言, 多个以逗号分隔, 支持: lua, ts")
+	flag.Parse()
+	if path == "" {
+		fmt.Print("Usage: \n--path=/path/to/scripts\n--out=/path/to/output\n--lang=lua,ts\n")
+		os.Exit(2)
+	}
+
+	entities, err := engine.ClientDefs(path)
+	if err != nil {
+		fmt.Println("load defs error:", err.Error())
+		os.Exit(1)
+	}
+	if err = os.MkdirAll(out, 0755); err != nil {
+		fmt.Println("create output dir error:", err.Error())
+		os.Exit(1)
+	}
+	for _, lang := range strings.Split(langs, ",") {
+		lang = strings.TrimSpace(lang)
+		g, ok := generators[lang]
+		if !ok {
+			fmt.Printf("unsupported lang: %s\n", lang)
+			os.Exit(1)
+		}
+		fileName := filepath.Join(out, g.fileName)
+		if err = os.WriteFile(fileName, []byte(g.gen(entities)), 0644); err != nil {
+			fmt.Printf("write %s error: %s\n", fileName, err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("generated %s\n", fileName)
+	}
+}
+
+// argNames 生成合法且不重复的参数名, def中的标签名不可用时使用argN
+func argNames(args []*engine.ArgDesc, reserved map[string]bool) []string {
+	r := make([]string, 0, len(args))
+	used := make(map[string]bool)
+	for i, arg := range args {
+		name := arg.Name
+		if !isIdentifier(name) || reserved[name] || used[name] {
+			name = fmt.Sprintf("arg%d", i+1)
+		}
+		used[name] = true
+		r = append(r, name)
+	}
+	return r
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// syncFlagsComment 属性同步方式的注释
+func syncFlagsComment(prop *engine.PropDesc) string {
+	switch {
+	case prop.OwnClient && prop.OtherClients:
+		return "all_clients"
+	case prop.OwnClient:
+		return "own_client"
+	default:
+		return "other_clients"
+	}
+}
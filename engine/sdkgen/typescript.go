@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"rpg/engine/engine"
+	"strings"
+)
+
+var tsReserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true, "debugger": true,
+	"default": true, "delete": true, "do": true, "else": true, "enum": true, "export": true, "extends": true,
+	"false": true, "finally": true, "for": true, "function": true, "if": true, "import": true, "in": true,
+	"instanceof": true, "new": true, "null": true, "return": true, "super": true, "switch": true, "this": true,
+	"throw": true, "true": true, "try": true, "typeof": true, "var": true, "void": true, "while": true, "with": true,
+}
+
+// tsType 类型对应的TypeScript类型
+func tsType(t *engine.TypeDesc) string {
+	switch t.Name {
+	case "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64", "float":
+		return "number"
+	case "string":
+		return "string"
+	case "bool":
+		return "boolean"
+	case "array":
+		return tsType(t.Value) + "[]"
+	case "map":
+		return "Record<" + tsType(t.Key) + ", " + tsType(t.Value) + ">"
+	case "struct":
+		fields := make([]string, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			fields = append(fields, f.Name+": "+tsType(f.Type))
+		}
+		return "{ " + strings.Join(fields, "; ") + " }"
+	}
+	return "Record<string, unknown>"
+}
+
+func genTypeScript(entities []*engine.EntityDesc) string {
+	b := new(strings.Builder)
+	b.WriteString("// Code generated by sdkgen from entity defs. DO NOT EDIT.\n\n")
+	b.WriteString("/** 调用entity函数的消息类型与消息字段 */\n")
+	fmt.Fprintf(b, "export const MsgTypeEntityRpc = %d;\n", engine.ClientMsgTypeEntityRpc)
+	fmt.Fprintf(b, "export const FieldEntityId = %q;\n", engine.ClientMsgDataFieldEntityID)
+	fmt.Fprintf(b, "export const FieldArgs = %q;\n", engine.ClientMsgDataFieldArgs)
+	fmt.Fprintf(b, "export const FieldVersion = %q;\n", engine.ClientMsgDataFieldVersion)
+	fmt.Fprintf(b, "export const DefVersion = %q;\n\n", engine.DefHash())
+	b.WriteString("/** 发送服务端函数调用, 由客户端网络层实现 */\n")
+	b.WriteString("export type RpcSender = (entityId: number, maskName: string, args: unknown[]) => void;\n")
+
+	for _, ent := range entities {
+		b.WriteString("\n")
+		fmt.Fprintf(b, "export interface %sProps {\n", ent.Name)
+		b.WriteString("    id: number;\n")
+		for _, prop := range ent.Props {
+			fmt.Fprintf(b, "    /** %s */\n", syncFlagsComment(prop))
+			fmt.Fprintf(b, "    %s: %s;\n", prop.Name, tsType(prop.Type))
+		}
+		b.WriteString("}\n\n")
+
+		fmt.Fprintf(b, "export class %sServer {\n", ent.Name)
+		b.WriteString("    constructor(private readonly entityId: number, private readonly send: RpcSender) {}\n")
+		for _, method := range ent.ServerMethods {
+			names := argNames(method.Args, tsReserved)
+			params := make([]string, 0, len(names))
+			for i, arg := range method.Args {
+				params = append(params, names[i]+": "+tsType(arg.Type))
+			}
+			b.WriteString("\n")
+			fmt.Fprintf(b, "    %s(%s): void {\n", method.Name, strings.Join(params, ", "))
+			fmt.Fprintf(b, "        this.send(this.entityId, %q, [%s]);\n", method.MaskName, strings.Join(names, ", "))
+			b.WriteString("    }\n")
+		}
+		b.WriteString("}\n\n")
+
+		fmt.Fprintf(b, "export interface %sClient {\n", ent.Name)
+		for _, method := range ent.ClientMethods {
+			names := argNames(method.Args, tsReserved)
+			params := make([]string, 0, len(names))
+			for i, arg := range method.Args {
+				params = append(params, names[i]+": "+tsType(arg.Type))
+			}
+			fmt.Fprintf(b, "    %s(%s): void;\n", method.Name, strings.Join(params, ", "))
+		}
+		b.WriteString("}\n\n")
+
+		fmt.Fprintf(b, "/** 客户端函数, mask name -> 函数名 */\n")
+		fmt.Fprintf(b, "export const %sClientMethods: Readonly<Record<string, string>> = {\n", ent.Name)
+		for _, method := range ent.ClientMethods {
+			fmt.Fprintf(b, "    %q: %q,\n", method.MaskName, method.Name)
+		}
+		b.WriteString("};\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString("const clientMethods: Readonly<Record<string, Readonly<Record<string, string>>>> = {\n")
+	for _, ent := range entities {
+		fmt.Fprintf(b, "    %s: %sClientMethods,\n", ent.Name, ent.Name)
+	}
+	b.WriteString("};\n\n")
+	b.WriteString("/** 分发服务端对客户端函数的调用, handler为实现了客户端函数的对象, 未找到函数时返回false */\n")
+	b.WriteString("export function dispatch(entityName: string, handler: object, maskName: string, args: unknown[]): boolean {\n")
+	b.WriteString("    const name = clientMethods[entityName]?.[maskName];\n")
+	b.WriteString("    const fn = name === undefined ? undefined : (handler as Record<string, unknown>)[name];\n")
+	b.WriteString("    if (typeof fn !== \"function\") {\n")
+	b.WriteString("        return false;\n")
+	b.WriteString("    }\n")
+	b.WriteString("    fn.apply(handler, args);\n")
+	b.WriteString("    return true;\n")
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -20,6 +20,10 @@ function Avatar:test(_, t1, t2, skill_info)
     print("t1: ", t1, ", t2: ", t2, ", skill: ", skill_info)
 end
 
+function Avatar:get_level()
+    return self.level
+end
+
 function Avatar:on_lose_client()
     print("on_lose_client: ", self.id)
 end
@@ -30,12 +30,17 @@ function create_stubs()
     for key, stubs in pairs(all_stubs) do
         if key == server_key then
             for _, stub_name in ipairs(stubs) do
-                id = rpg.createEntityLocally(stub_name)
-                if id == 0 then
-                    log.error("create stub: ", stub_name, " failed")
-                    return false
+                if rpg.hasStub(stub_name) then
+                    -- stub已由其他game故障转移重建
+                    log.info("stub: ", stub_name, " already exists, skip create")
                 else
-                    log.info("create stub: ", stub_name, " success, id: ", id)
+                    local id = rpg.createEntityLocally(stub_name)
+                    if id == 0 then
+                        log.error("create stub: ", stub_name, " failed")
+                        return false
+                    else
+                        log.info("create stub: ", stub_name, " success, id: ", id)
+                    end
                 end
             end
         end